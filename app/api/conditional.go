@@ -0,0 +1,97 @@
+package api
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// CachePolicy describes how clients and shared caches may store a route's responses.
+type CachePolicy struct {
+	MaxAge  time.Duration
+	Private bool
+	NoStore bool
+}
+
+// String renders the policy as a Cache-Control header value.
+func (p CachePolicy) String() string {
+	if p.NoStore {
+		return "no-store"
+	}
+	visibility := "public"
+	if p.Private {
+		visibility = "private"
+	}
+	return fmt.Sprintf("%s, max-age=%d", visibility, int(p.MaxAge.Seconds()))
+}
+
+// Conditional applies the cache policy to a GET route and answers
+// If-None-Match requests with 304 Not Modified when the ETag still matches.
+func Conditional(policy CachePolicy, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			next(w, r)
+			return
+		}
+
+		buf := &bufferedWriter{ResponseWriter: w}
+		next(buf, r)
+		if buf.status == 0 {
+			buf.status = http.StatusOK
+		}
+
+		if buf.status == http.StatusOK {
+			w.Header().Set("Cache-Control", policy.String())
+
+			etag := w.Header().Get("ETag")
+			if etag != "" && etagMatches(r.Header.Get("If-None-Match"), etag) {
+				w.Header().Del("Content-Type")
+				w.Header().Del("Content-Length")
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+		}
+
+		w.WriteHeader(buf.status)
+		w.Write(buf.body.Bytes())
+	}
+}
+
+// etagMatches reports whether an If-None-Match header matches the ETag,
+// using the weak comparison required for If-None-Match.
+func etagMatches(header, etag string) bool {
+	if header == "" {
+		return false
+	}
+	etag = strings.TrimPrefix(etag, "W/")
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// bufferedWriter holds the response back so the status can be decided
+// once the handler has produced its headers and body.
+type bufferedWriter struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (b *bufferedWriter) WriteHeader(status int) {
+	if b.status == 0 {
+		b.status = status
+	}
+}
+
+func (b *bufferedWriter) Write(p []byte) (int, error) {
+	if b.status == 0 {
+		b.status = http.StatusOK
+	}
+	return b.body.Write(p)
+}
@@ -0,0 +1,88 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCachePolicy(t *testing.T) {
+	assert.Equal(t, "public, max-age=60", CachePolicy{MaxAge: time.Minute}.String())
+	assert.Equal(t, "private, max-age=0", CachePolicy{Private: true}.String())
+	assert.Equal(t, "no-store", CachePolicy{NoStore: true, MaxAge: time.Hour}.String())
+}
+
+func TestConditional(t *testing.T) {
+	policy := CachePolicy{MaxAge: time.Minute}
+	lastModified := time.Date(2025, 3, 4, 5, 6, 7, 0, time.UTC)
+	handler := Conditional(policy, func(w http.ResponseWriter, r *http.Request) {
+		SetLastModified(w, lastModified)
+		OKResponse(w, map[string]string{"code": "PROD001"})
+	})
+
+	fresh := httptest.NewRecorder()
+	handler(fresh, httptest.NewRequest("GET", "/catalog/PROD001", nil))
+	etag := fresh.Header().Get("ETag")
+
+	t.Run("sends validators and cache policy", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, fresh.Code)
+		assert.NotEmpty(t, etag)
+		assert.Equal(t, "public, max-age=60", fresh.Header().Get("Cache-Control"))
+		assert.Equal(t, "Tue, 04 Mar 2025 05:06:07 GMT", fresh.Header().Get("Last-Modified"))
+		assert.JSONEq(t, `{"code":"PROD001"}`, fresh.Body.String())
+	})
+
+	t.Run("returns 304 when the etag matches", func(t *testing.T) {
+		recorder := httptest.NewRecorder()
+		request := httptest.NewRequest("GET", "/catalog/PROD001", nil)
+		request.Header.Set("If-None-Match", `"other", `+etag)
+
+		handler(recorder, request)
+
+		assert.Equal(t, http.StatusNotModified, recorder.Code)
+		assert.Empty(t, recorder.Body.String())
+		assert.Equal(t, etag, recorder.Header().Get("ETag"))
+		assert.Equal(t, "public, max-age=60", recorder.Header().Get("Cache-Control"))
+		assert.Empty(t, recorder.Header().Get("Content-Type"))
+	})
+
+	t.Run("matches weak validators", func(t *testing.T) {
+		recorder := httptest.NewRecorder()
+		request := httptest.NewRequest("GET", "/catalog/PROD001", nil)
+		request.Header.Set("If-None-Match", "W/"+etag)
+
+		handler(recorder, request)
+
+		assert.Equal(t, http.StatusNotModified, recorder.Code)
+	})
+
+	t.Run("returns the full body when the etag changed", func(t *testing.T) {
+		recorder := httptest.NewRecorder()
+		request := httptest.NewRequest("GET", "/catalog/PROD001", nil)
+		request.Header.Set("If-None-Match", `"stale"`)
+
+		handler(recorder, request)
+
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		assert.JSONEq(t, `{"code":"PROD001"}`, recorder.Body.String())
+	})
+
+	t.Run("passes errors through without cache headers", func(t *testing.T) {
+		failing := Conditional(policy, func(w http.ResponseWriter, r *http.Request) {
+			ErrorResponse(w, http.StatusNotFound, "Product not found")
+		})
+
+		recorder := httptest.NewRecorder()
+		request := httptest.NewRequest("GET", "/catalog/INVALID", nil)
+		request.Header.Set("If-None-Match", "*")
+
+		failing(recorder, request)
+
+		assert.Equal(t, http.StatusNotFound, recorder.Code)
+		assert.Empty(t, recorder.Header().Get("Cache-Control"))
+		assert.Contains(t, recorder.Body.String(), "Product not found")
+	})
+}
@@ -0,0 +1,54 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Precondition is the resource version a write is conditioned on.
+type Precondition struct {
+	Version    uint
+	FromHeader bool
+}
+
+// RequireVersion resolves the version a write is conditioned on from the If-Match
+// header, falling back to the version sent in the request body. It writes an error
+// response and returns false when no usable version was provided.
+func RequireVersion(w http.ResponseWriter, r *http.Request, bodyVersion *uint) (Precondition, bool) {
+	if header := r.Header.Get("If-Match"); header != "" {
+		version, err := parseVersionTag(header)
+		if err != nil {
+			ErrorResponse(w, http.StatusBadRequest, "If-Match must carry the resource version, e.g. \"3\"")
+			return Precondition{}, false
+		}
+		return Precondition{Version: version, FromHeader: true}, true
+	}
+
+	if bodyVersion != nil {
+		return Precondition{Version: *bodyVersion}, true
+	}
+
+	ErrorResponse(w, http.StatusPreconditionRequired, "If-Match header or version is required")
+	return Precondition{}, false
+}
+
+// VersionConflictResponse reports a lost update: 412 when the precondition came
+// from If-Match, 409 when it came from the request body.
+func VersionConflictResponse(w http.ResponseWriter, p Precondition) {
+	status := http.StatusConflict
+	if p.FromHeader {
+		status = http.StatusPreconditionFailed
+	}
+	ErrorResponse(w, status, "Resource has been modified since it was read")
+}
+
+// parseVersionTag parses an entity tag holding a version number: 3, "3" or W/"3",
+// or an ETag issued by VersionedResponse, "3-9f86d0…", whose version it takes.
+func parseVersionTag(tag string) (uint, error) {
+	tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+	tag = strings.Trim(tag, `"`)
+	tag, _, _ = strings.Cut(tag, "-")
+	version, err := strconv.ParseUint(tag, 10, 32)
+	return uint(version), err
+}
@@ -0,0 +1,75 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequireVersion(t *testing.T) {
+	t.Run("reads the version from If-Match", func(t *testing.T) {
+		for _, header := range []string{`"3"`, `W/"3"`, `3`, `"3-9f86d081884c7d659a2feaa0c55ad015"`, `"3-9f86d081884c7d659a2feaa0c55ad015-gzip"`} {
+			recorder := httptest.NewRecorder()
+			request := httptest.NewRequest("DELETE", "/categories/shoes", nil)
+			request.Header.Set("If-Match", header)
+			body := uint(7)
+
+			p, ok := RequireVersion(recorder, request, &body)
+
+			assert.True(t, ok, header)
+			assert.Equal(t, Precondition{Version: 3, FromHeader: true}, p, header)
+		}
+	})
+
+	t.Run("falls back to the body version", func(t *testing.T) {
+		recorder := httptest.NewRecorder()
+		request := httptest.NewRequest("PATCH", "/admin/catalog/PROD001/prices", nil)
+		body := uint(7)
+
+		p, ok := RequireVersion(recorder, request, &body)
+
+		assert.True(t, ok)
+		assert.Equal(t, Precondition{Version: 7}, p)
+	})
+
+	t.Run("requires a version", func(t *testing.T) {
+		recorder := httptest.NewRecorder()
+		request := httptest.NewRequest("DELETE", "/categories/shoes", nil)
+
+		_, ok := RequireVersion(recorder, request, nil)
+
+		assert.False(t, ok)
+		assert.Equal(t, http.StatusPreconditionRequired, recorder.Code)
+	})
+
+	t.Run("rejects a malformed If-Match", func(t *testing.T) {
+		for _, header := range []string{"*", `"9f86d081884c7d659a2feaa0c55ad015"`} {
+			recorder := httptest.NewRecorder()
+			request := httptest.NewRequest("DELETE", "/categories/shoes", nil)
+			request.Header.Set("If-Match", header)
+
+			_, ok := RequireVersion(recorder, request, nil)
+
+			assert.False(t, ok, header)
+			assert.Equal(t, http.StatusBadRequest, recorder.Code, header)
+		}
+	})
+}
+
+func TestVersionConflictResponse(t *testing.T) {
+	t.Run("412 for If-Match preconditions", func(t *testing.T) {
+		recorder := httptest.NewRecorder()
+		VersionConflictResponse(recorder, Precondition{Version: 1, FromHeader: true})
+
+		assert.Equal(t, http.StatusPreconditionFailed, recorder.Code)
+	})
+
+	t.Run("409 for body versions", func(t *testing.T) {
+		recorder := httptest.NewRecorder()
+		VersionConflictResponse(recorder, Precondition{Version: 1})
+
+		assert.Equal(t, http.StatusConflict, recorder.Code)
+	})
+}
@@ -0,0 +1,44 @@
+package api
+
+import "net/http"
+
+// StatusRecorder captures the status code and body size written through it,
+// for middleware that reports on responses after the handler returns.
+type StatusRecorder struct {
+	http.ResponseWriter
+	Status int
+	Bytes  int64
+
+	wroteHeader bool
+}
+
+// NewStatusRecorder wraps w. Status defaults to 200, as for a handler that only writes a body.
+func NewStatusRecorder(w http.ResponseWriter) *StatusRecorder {
+	return &StatusRecorder{ResponseWriter: w, Status: http.StatusOK}
+}
+
+func (rec *StatusRecorder) WriteHeader(status int) {
+	if !rec.wroteHeader {
+		rec.Status = status
+		rec.wroteHeader = true
+	}
+	rec.ResponseWriter.WriteHeader(status)
+}
+
+func (rec *StatusRecorder) Write(b []byte) (int, error) {
+	rec.wroteHeader = true
+	n, err := rec.ResponseWriter.Write(b)
+	rec.Bytes += int64(n)
+	return n, err
+}
+
+// WroteHeader reports whether the response has started, after which the
+// status can no longer be changed.
+func (rec *StatusRecorder) WroteHeader() bool {
+	return rec.wroteHeader
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer.
+func (rec *StatusRecorder) Unwrap() http.ResponseWriter {
+	return rec.ResponseWriter
+}
@@ -0,0 +1,83 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/mytheresa/go-hiring-challenge/app/logging"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithRequestID(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, nil))
+
+	var seen string
+	handler := WithRequestID(logger, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = RequestID(r.Context())
+		logging.FromContext(r.Context()).Info("handled")
+	}))
+
+	t.Run("reuses the ID sent by the client", func(t *testing.T) {
+		buf.Reset()
+		recorder := httptest.NewRecorder()
+		request := httptest.NewRequest("GET", "/catalog", nil)
+		request.Header.Set("X-Request-ID", "lb-7f3a.1")
+
+		handler.ServeHTTP(recorder, request)
+
+		assert.Equal(t, "lb-7f3a.1", seen)
+		assert.Equal(t, "lb-7f3a.1", recorder.Header().Get("X-Request-ID"))
+		assert.Contains(t, buf.String(), `"request_id":"lb-7f3a.1"`)
+	})
+
+	t.Run("generates an ID when none is sent", func(t *testing.T) {
+		recorder := httptest.NewRecorder()
+
+		handler.ServeHTTP(recorder, httptest.NewRequest("GET", "/catalog", nil))
+
+		assert.Len(t, seen, 32)
+		assert.Equal(t, seen, recorder.Header().Get("X-Request-ID"))
+	})
+
+	t.Run("replaces a malformed ID", func(t *testing.T) {
+		recorder := httptest.NewRecorder()
+		request := httptest.NewRequest("GET", "/catalog", nil)
+		request.Header.Set("X-Request-ID", `evil" level=ERROR`)
+
+		handler.ServeHTTP(recorder, request)
+
+		assert.Len(t, seen, 32)
+		assert.Equal(t, seen, recorder.Header().Get("X-Request-ID"))
+	})
+}
+
+func TestAccessLog(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, nil))
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /catalog/{code}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"error":"Product not found"}`))
+	})
+
+	request := httptest.NewRequest("GET", "/catalog/PROD009", nil)
+	request = request.WithContext(logging.WithLogger(request.Context(), logger.With("request_id", "abc")))
+	AccessLog(mux).ServeHTTP(httptest.NewRecorder(), request)
+
+	var record map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &record))
+	assert.Equal(t, "request", record["msg"])
+	assert.Equal(t, "abc", record["request_id"])
+	assert.Equal(t, "GET", record["method"])
+	assert.Equal(t, "GET /catalog/{code}", record["route"])
+	assert.Equal(t, "/catalog/PROD009", record["path"])
+	assert.EqualValues(t, http.StatusNotFound, record["status"])
+	assert.EqualValues(t, len(`{"error":"Product not found"}`), record["bytes"])
+	assert.Contains(t, record, "duration_ms")
+}
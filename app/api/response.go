@@ -1,17 +1,47 @@
 package api
 
 import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mytheresa/go-hiring-challenge/app/logging"
 )
 
 // OKResponse writes a successful JSON response with the provided data.
+// The response carries a strong ETag derived from the encoded body.
 func OKResponse(w http.ResponseWriter, data any) {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	if err := json.NewEncoder(w).Encode(data); err != nil {
+	writeOK(w, data, ETag)
+}
+
+// VersionedResponse writes a successful JSON response for a single resource
+// at the given row version. Its ETag starts with the version, e.g. "3-9f86d0…",
+// so clients can send it back in If-Match, followed by the body hash, so it
+// still changes when the representation does, e.g. with the running promotions.
+func VersionedResponse(w http.ResponseWriter, version uint, data any) {
+	writeOK(w, data, func(body []byte) string {
+		return `"` + strconv.FormatUint(uint64(version), 10) + "-" + strings.Trim(ETag(body), `"`) + `"`
+	})
+}
+
+func writeOK(w http.ResponseWriter, data any, etag func([]byte) string) {
+	var body bytes.Buffer
+	if err := json.NewEncoder(&body).Encode(data); err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
 	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("ETag", etag(body.Bytes()))
+	w.WriteHeader(http.StatusOK)
+	w.Write(body.Bytes())
 }
 
 // ErrorResponse writes an error JSON response with the provided status and message.
@@ -23,3 +53,57 @@ func ErrorResponse(w http.ResponseWriter, status int, message string) {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 	}
 }
+
+// Problem is an RFC 9457 problem details body. Responses carrying extension
+// members embed it in a struct of their own.
+type Problem struct {
+	Type   string `json:"type"`
+	Title  string `json:"title"`
+	Status int    `json:"status"`
+	Detail string `json:"detail,omitempty"`
+}
+
+// NewProblem returns a problem of the generic about:blank type for status.
+func NewProblem(status int, detail string) Problem {
+	return Problem{
+		Type:   "about:blank",
+		Title:  http.StatusText(status),
+		Status: status,
+		Detail: detail,
+	}
+}
+
+// ProblemResponse writes problem, a Problem or a struct embedding one, as an
+// application/problem+json response with the given status.
+func ProblemResponse(w http.ResponseWriter, status int, problem any) {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(problem); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// InternalError logs err with the request logger and writes it as a 500
+// response, or as a 503 when the request ran out of time.
+func InternalError(w http.ResponseWriter, r *http.Request, err error) {
+	logging.FromContext(r.Context()).ErrorContext(r.Context(), "request failed", "error", err)
+	if errors.Is(err, context.DeadlineExceeded) {
+		ErrorResponse(w, http.StatusServiceUnavailable, "Request timed out")
+		return
+	}
+	ErrorResponse(w, http.StatusInternalServerError, err.Error())
+}
+
+// ETag returns a strong entity tag for the given response body.
+func ETag(body []byte) string {
+	sum := sha256.Sum256(body)
+	return `"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// SetLastModified sets the Last-Modified header. Zero times are ignored.
+func SetLastModified(w http.ResponseWriter, t time.Time) {
+	if t.IsZero() {
+		return
+	}
+	w.Header().Set("Last-Modified", t.UTC().Format(http.TimeFormat))
+}
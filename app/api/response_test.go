@@ -1,10 +1,17 @@
 package api
 
 import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
+	"github.com/mytheresa/go-hiring-challenge/app/logging"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -26,6 +33,50 @@ func TestOKResponse(t *testing.T) {
 		expected := `{"message":"Success"}`
 		assert.JSONEq(t, expected, recorder.Body.String(), "Response body does not match expected")
 	})
+
+	t.Run("strong etag derived from the body", func(t *testing.T) {
+		first := httptest.NewRecorder()
+		OKResponse(first, sample)
+
+		second := httptest.NewRecorder()
+		OKResponse(second, sample)
+
+		other := httptest.NewRecorder()
+		OKResponse(other, sampleResponse{Message: "Other"})
+
+		etag := first.Header().Get("ETag")
+		assert.Regexp(t, `^"[0-9a-f]{32}"$`, etag, "Expected a strong ETag")
+		assert.Equal(t, etag, second.Header().Get("ETag"), "Expected identical bodies to share an ETag")
+		assert.NotEqual(t, etag, other.Header().Get("ETag"), "Expected different bodies to have different ETags")
+	})
+}
+
+func TestVersionedResponse(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	VersionedResponse(recorder, 3, map[string]string{"code": "shoes"})
+
+	other := httptest.NewRecorder()
+	VersionedResponse(other, 3, map[string]string{"code": "bags"})
+
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Regexp(t, `^"3-[0-9a-f]{32}"$`, recorder.Header().Get("ETag"))
+	assert.NotEqual(t, recorder.Header().Get("ETag"), other.Header().Get("ETag"), "Expected the body hash to tell representations apart")
+}
+
+func TestSetLastModified(t *testing.T) {
+	t.Run("formats the time as an HTTP date", func(t *testing.T) {
+		recorder := httptest.NewRecorder()
+		SetLastModified(recorder, time.Date(2025, 3, 4, 5, 6, 7, 0, time.FixedZone("CET", 3600)))
+
+		assert.Equal(t, "Tue, 04 Mar 2025 04:06:07 GMT", recorder.Header().Get("Last-Modified"))
+	})
+
+	t.Run("ignores zero time", func(t *testing.T) {
+		recorder := httptest.NewRecorder()
+		SetLastModified(recorder, time.Time{})
+
+		assert.Empty(t, recorder.Header().Get("Last-Modified"))
+	})
 }
 
 func TestErrorResponse(t *testing.T) {
@@ -40,3 +91,41 @@ func TestErrorResponse(t *testing.T) {
 		assert.JSONEq(t, expected, recorder.Body.String(), "Response body does not match expected")
 	})
 }
+
+func TestProblemResponse(t *testing.T) {
+	t.Run("writes extension members next to the standard ones", func(t *testing.T) {
+		recorder := httptest.NewRecorder()
+		ProblemResponse(recorder, http.StatusForbidden, struct {
+			Problem
+			Permission string `json:"permission"`
+		}{NewProblem(http.StatusForbidden, "Not allowed"), "categories.delete"})
+
+		assert.Equal(t, http.StatusForbidden, recorder.Code)
+		assert.Equal(t, "application/problem+json", recorder.Header().Get("Content-Type"))
+		assert.JSONEq(t, `{"type":"about:blank","title":"Forbidden","status":403,"detail":"Not allowed","permission":"categories.delete"}`, recorder.Body.String())
+	})
+}
+
+func TestInternalError(t *testing.T) {
+	t.Run("logs the error with the request logger", func(t *testing.T) {
+		var logs bytes.Buffer
+		logger := slog.New(slog.NewJSONHandler(&logs, nil)).With("request_id", "abc")
+		request := httptest.NewRequest("GET", "/catalog", nil)
+		request = request.WithContext(logging.WithLogger(request.Context(), logger))
+
+		recorder := httptest.NewRecorder()
+		InternalError(recorder, request, errors.New("connection reset"))
+
+		assert.Equal(t, http.StatusInternalServerError, recorder.Code)
+		assert.JSONEq(t, `{"error":"connection reset"}`, recorder.Body.String())
+		assert.Contains(t, logs.String(), `"request_id":"abc"`)
+		assert.Contains(t, logs.String(), `"error":"connection reset"`)
+	})
+	t.Run("answers a request that ran out of time with 503", func(t *testing.T) {
+		recorder := httptest.NewRecorder()
+		InternalError(recorder, httptest.NewRequest("GET", "/catalog", nil), fmt.Errorf("loading products: %w", context.DeadlineExceeded))
+
+		assert.Equal(t, http.StatusServiceUnavailable, recorder.Code)
+		assert.JSONEq(t, `{"error":"Request timed out"}`, recorder.Body.String())
+	})
+}
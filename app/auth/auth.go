@@ -0,0 +1,199 @@
+// Package auth authenticates API clients and restricts routes to the clients
+// granted the scope they require.
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+
+	"github.com/mytheresa/go-hiring-challenge/app/api"
+	"github.com/mytheresa/go-hiring-challenge/app/logging"
+	"github.com/mytheresa/go-hiring-challenge/app/middleware"
+)
+
+// Scopes granted to API clients.
+const (
+	ScopeCatalogRead     = "catalog:read"
+	ScopeCatalogWrite    = "catalog:write"
+	ScopeCategoriesWrite = "categories:write"
+	ScopePromotionsWrite = "promotions:write"
+)
+
+// Scopes lists every scope a client can be granted.
+var Scopes = []string{ScopeCatalogRead, ScopeCatalogWrite, ScopeCategoriesWrite, ScopePromotionsWrite}
+
+var (
+	// ErrNoCredentials is returned by an Authenticator when the request carries
+	// no credentials it understands.
+	ErrNoCredentials = errors.New("no credentials")
+	// ErrInvalidCredentials is returned by an Authenticator when the request
+	// credentials are unknown, revoked or expired.
+	ErrInvalidCredentials = errors.New("invalid credentials")
+)
+
+// Principal is an authenticated API client.
+type Principal struct {
+	// Subject identifies the client in logs, e.g. "apikey:mhc_Ab3dE6gH".
+	Subject string
+	Name    string
+	Scopes  []string
+	Roles   []string
+}
+
+// HasScope reports whether the principal was granted scope.
+func (p *Principal) HasScope(scope string) bool {
+	return slices.Contains(p.Scopes, scope)
+}
+
+// Authenticator identifies the client sending a request.
+type Authenticator interface {
+	// Authenticate returns the client sending r. It fails with
+	// ErrNoCredentials when r carries none of the credentials it checks, and
+	// with an error wrapping ErrInvalidCredentials when they are not accepted.
+	Authenticate(r *http.Request) (*Principal, error)
+	// Challenges returns the WWW-Authenticate challenges telling rejected
+	// clients how to authenticate.
+	Challenges() []string
+}
+
+// Any authenticates requests with the first of its authenticators that finds
+// credentials in the request.
+type Any []Authenticator
+
+// Authenticate implements Authenticator.
+func (a Any) Authenticate(r *http.Request) (*Principal, error) {
+	for _, authenticator := range a {
+		p, err := authenticator.Authenticate(r)
+		if !errors.Is(err, ErrNoCredentials) {
+			return p, err
+		}
+	}
+	return nil, ErrNoCredentials
+}
+
+// Challenges implements Authenticator.
+func (a Any) Challenges() []string {
+	var challenges []string
+	for _, authenticator := range a {
+		challenges = append(challenges, authenticator.Challenges()...)
+	}
+	return challenges
+}
+
+// ValidateScopes reports the scopes that are not known.
+func ValidateScopes(scopes []string) error {
+	if len(scopes) == 0 {
+		return errors.New("at least one scope is required")
+	}
+	for _, s := range scopes {
+		if !slices.Contains(Scopes, s) {
+			return fmt.Errorf("unknown scope %q", s)
+		}
+	}
+	return nil
+}
+
+type principalKey struct{}
+
+// WithPrincipal returns a copy of ctx carrying p.
+func WithPrincipal(ctx context.Context, p *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+// FromContext returns the principal authenticated for the request, if any.
+func FromContext(ctx context.Context) (*Principal, bool) {
+	p, ok := ctx.Value(principalKey{}).(*Principal)
+	return p, ok
+}
+
+// Require only lets requests through from clients authenticated by a and
+// granted scope. Others get 401 without valid credentials and 403 without the
+// scope. The principal is added to the request context and its logger.
+func Require(a Authenticator, scope string) middleware.Middleware {
+	return guard(a, scope)
+}
+
+// Authenticated only lets requests through from clients authenticated by a,
+// whatever their scopes, adding the principal as Require does.
+func Authenticated(a Authenticator) middleware.Middleware {
+	return guard(a, "")
+}
+
+// missingScopeProblem is the body of a request lacking the scope of its route.
+type missingScopeProblem struct {
+	api.Problem
+	Scope string `json:"scope"`
+}
+
+func guard(a Authenticator, scope string) middleware.Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := r.Context()
+			p, ok := FromContext(ctx)
+			if !ok {
+				var err error
+				p, err = a.Authenticate(r)
+				switch {
+				case errors.Is(err, ErrNoCredentials):
+					unauthorized(w, a, "Missing credentials")
+					return
+				case errors.Is(err, ErrInvalidCredentials):
+					logging.FromContext(ctx).InfoContext(ctx, "authentication failed", "error", err)
+					unauthorized(w, a, "Invalid credentials")
+					return
+				case err != nil:
+					api.InternalError(w, r, err)
+					return
+				}
+				ctx = withPrincipal(ctx, p)
+			}
+
+			if scope != "" && !p.HasScope(scope) {
+				logging.FromContext(ctx).WarnContext(ctx, "missing scope", "scope", scope)
+				api.ProblemResponse(w, http.StatusForbidden, missingScopeProblem{
+					Problem: api.NewProblem(http.StatusForbidden, "Your credentials lack the "+scope+" scope this route requires."),
+					Scope:   scope,
+				})
+				return
+			}
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// Identify adds the principal of requests carrying credentials a accepts, as
+// Require does, but lets every request through. Requests without or with
+// invalid credentials continue anonymously, so open routes can tell known
+// clients apart. A later Require reuses the principal.
+func Identify(a Authenticator) middleware.Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := r.Context()
+			p, err := a.Authenticate(r)
+			if err != nil {
+				if !errors.Is(err, ErrNoCredentials) && !errors.Is(err, ErrInvalidCredentials) {
+					logging.FromContext(ctx).WarnContext(ctx, "identifying the client failed", "error", err)
+				}
+				next.ServeHTTP(w, r)
+				return
+			}
+			next.ServeHTTP(w, r.WithContext(withPrincipal(ctx, p)))
+		})
+	}
+}
+
+// withPrincipal adds p to ctx and to the logger it carries.
+func withPrincipal(ctx context.Context, p *Principal) context.Context {
+	logger := logging.FromContext(ctx).With("principal", p.Subject)
+	return logging.WithLogger(WithPrincipal(ctx, p), logger)
+}
+
+func unauthorized(w http.ResponseWriter, a Authenticator, message string) {
+	for _, challenge := range a.Challenges() {
+		w.Header().Add("WWW-Authenticate", challenge)
+	}
+	api.ErrorResponse(w, http.StatusUnauthorized, message)
+}
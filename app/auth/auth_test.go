@@ -0,0 +1,222 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/mytheresa/go-hiring-challenge/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// fakeKeyStore holds active keys by hash.
+type fakeKeyStore struct {
+	keys    map[string]*models.APIKey
+	err     error
+	lookups int
+}
+
+func (s *fakeKeyStore) FindActiveByHash(_ context.Context, hash string) (*models.APIKey, error) {
+	s.lookups++
+	if s.err != nil {
+		return nil, s.err
+	}
+	key, ok := s.keys[hash]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return key, nil
+}
+
+// issue returns a new key stored in s with the given role and scopes.
+func (s *fakeKeyStore) issue(t *testing.T, role string, scopes ...string) string {
+	t.Helper()
+
+	key, stored, err := NewKey("test client", scopes, []string{role})
+	require.NoError(t, err)
+	s.keys[stored.Hash] = stored
+	return key
+}
+
+func TestRequire(t *testing.T) {
+	store := &fakeKeyStore{keys: map[string]*models.APIKey{}}
+	writer := store.issue(t, RoleEditor, ScopeCategoriesWrite)
+	reader := store.issue(t, RoleAdmin, ScopeCatalogRead)
+
+	var principal *Principal
+	handler := Require(NewAPIKeys(store), ScopeCategoriesWrite)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		principal, _ = FromContext(r.Context())
+		w.WriteHeader(http.StatusCreated)
+	}))
+	serve := func(key string) *httptest.ResponseRecorder {
+		principal = nil
+		recorder := httptest.NewRecorder()
+		request := httptest.NewRequest("POST", "/categories", nil)
+		if key != "" {
+			request.Header.Set(APIKeyHeader, key)
+		}
+		handler.ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	t.Run("lets a key with the scope through", func(t *testing.T) {
+		recorder := serve(writer)
+
+		assert.Equal(t, http.StatusCreated, recorder.Code)
+		require.NotNil(t, principal)
+		assert.Equal(t, "apikey:"+writer[:prefixLen], principal.Subject)
+		assert.Equal(t, "test client", principal.Name)
+		assert.Equal(t, []string{RoleEditor}, principal.Roles)
+	})
+
+	t.Run("rejects a request without a key", func(t *testing.T) {
+		recorder := serve("")
+
+		assert.Equal(t, http.StatusUnauthorized, recorder.Code)
+		assert.Equal(t, `ApiKey header="X-API-Key"`, recorder.Header().Get("WWW-Authenticate"))
+		assert.JSONEq(t, `{"error":"Missing credentials"}`, recorder.Body.String())
+		assert.Nil(t, principal)
+	})
+
+	t.Run("rejects unknown and malformed keys", func(t *testing.T) {
+		unknown, _, err := NewKey("unknown", []string{ScopeCategoriesWrite}, []string{RoleAdmin})
+		require.NoError(t, err)
+
+		for _, key := range []string{unknown, "mhc_", "secret"} {
+			recorder := serve(key)
+
+			assert.Equal(t, http.StatusUnauthorized, recorder.Code, key)
+			assert.JSONEq(t, `{"error":"Invalid credentials"}`, recorder.Body.String())
+		}
+	})
+
+	t.Run("forbids a key without the scope", func(t *testing.T) {
+		recorder := serve(reader)
+
+		assert.Equal(t, http.StatusForbidden, recorder.Code)
+		assert.Equal(t, "application/problem+json", recorder.Header().Get("Content-Type"))
+		assert.JSONEq(t, `{
+			"type": "about:blank",
+			"title": "Forbidden",
+			"status": 403,
+			"detail": "Your credentials lack the categories:write scope this route requires.",
+			"scope": "categories:write"
+		}`, recorder.Body.String())
+		assert.Nil(t, principal)
+	})
+
+	t.Run("reports store failures as internal errors", func(t *testing.T) {
+		store.err = errors.New("connection refused")
+		defer func() { store.err = nil }()
+
+		recorder := serve(writer)
+
+		assert.Equal(t, http.StatusInternalServerError, recorder.Code)
+	})
+}
+
+func TestIdentify(t *testing.T) {
+	store := &fakeKeyStore{keys: map[string]*models.APIKey{}}
+	key := store.issue(t, RoleEditor, ScopeCatalogWrite)
+	apiKeys := NewAPIKeys(store)
+
+	var principal *Principal
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		principal, _ = FromContext(r.Context())
+	})
+	serve := func(h http.Handler, key string) *httptest.ResponseRecorder {
+		principal = nil
+		recorder := httptest.NewRecorder()
+		request := httptest.NewRequest("GET", "/catalog", nil)
+		if key != "" {
+			request.Header.Set(APIKeyHeader, key)
+		}
+		h.ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	t.Run("identifies clients with valid credentials", func(t *testing.T) {
+		serve(Identify(apiKeys)(handler), key)
+
+		require.NotNil(t, principal)
+		assert.Equal(t, "test client", principal.Name)
+	})
+
+	t.Run("lets anonymous clients and invalid credentials through", func(t *testing.T) {
+		for _, key := range []string{"", "mhc_unknown-key"} {
+			recorder := serve(Identify(apiKeys)(handler), key)
+
+			assert.Equal(t, http.StatusOK, recorder.Code)
+			assert.Nil(t, principal)
+		}
+	})
+
+	t.Run("lets Require reuse the principal", func(t *testing.T) {
+		store.lookups = 0
+
+		recorder := serve(Identify(apiKeys)(Require(apiKeys, ScopeCatalogWrite)(handler)), key)
+
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		assert.NotNil(t, principal)
+		assert.Equal(t, 1, store.lookups)
+	})
+}
+
+func TestAny(t *testing.T) {
+	store := &fakeKeyStore{keys: map[string]*models.APIKey{}}
+	key := store.issue(t, RoleEditor, ScopeCatalogWrite)
+	signer := newECKey(t, "ec-1")
+	keys, err := LoadJWKS(context.Background(), writeJWKS(t, signer), 0)
+	require.NoError(t, err)
+	authenticator := Any{NewAPIKeys(store), NewJWT(keys, JWTOptions{Audience: "catalog-api"})}
+
+	t.Run("uses the authenticator finding credentials", func(t *testing.T) {
+		request := httptest.NewRequest("PATCH", "/admin/catalog/PROD001/prices", nil)
+		request.Header.Set(APIKeyHeader, key)
+		p, err := authenticator.Authenticate(request)
+		require.NoError(t, err)
+		assert.Equal(t, "apikey:"+key[:prefixLen], p.Subject)
+
+		token := signer.sign(t, map[string]any{"sub": "pricing", "aud": "catalog-api", "exp": time.Now().Add(time.Minute).Unix()})
+		p, err = authenticator.Authenticate(bearer(token))
+		require.NoError(t, err)
+		assert.Equal(t, "jwt:pricing", p.Subject)
+	})
+
+	t.Run("challenges with every scheme", func(t *testing.T) {
+		recorder := httptest.NewRecorder()
+		handler := Require(authenticator, ScopeCatalogWrite)(http.NotFoundHandler())
+
+		handler.ServeHTTP(recorder, httptest.NewRequest("PATCH", "/admin/catalog/PROD001/prices", nil))
+
+		assert.Equal(t, http.StatusUnauthorized, recorder.Code)
+		assert.Equal(t, []string{`ApiKey header="X-API-Key"`, "Bearer"}, recorder.Header().Values("WWW-Authenticate"))
+	})
+}
+
+func TestNewKey(t *testing.T) {
+	key, stored, err := NewKey("ci", []string{ScopeCatalogRead, ScopeCatalogWrite}, []string{RoleEditor})
+	require.NoError(t, err)
+
+	assert.Regexp(t, `^mhc_[A-Za-z0-9_-]{43}$`, key)
+	assert.Equal(t, key[:prefixLen], stored.Prefix)
+	assert.Equal(t, HashKey(key), stored.Hash)
+	assert.NotContains(t, stored.Hash, key[prefixLen:])
+	assert.Equal(t, []string{ScopeCatalogRead, ScopeCatalogWrite}, stored.ScopeList())
+	assert.Equal(t, []string{RoleEditor}, stored.RoleList())
+
+	other, _, err := NewKey("ci", []string{ScopeCatalogRead}, []string{RoleViewer})
+	require.NoError(t, err)
+	assert.NotEqual(t, key, other)
+}
+
+func TestValidateScopes(t *testing.T) {
+	assert.NoError(t, ValidateScopes([]string{ScopeCatalogWrite, ScopeCategoriesWrite}))
+	assert.Error(t, ValidateScopes(nil))
+	assert.ErrorContains(t, ValidateScopes([]string{ScopeCatalogRead, "catalog:admin"}), `"catalog:admin"`)
+}
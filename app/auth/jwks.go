@@ -0,0 +1,237 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math/big"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxJWKSSize bounds the key set document read from a file or URL.
+const maxJWKSSize = 1 << 20
+
+// jwk is a verification key of a key set. alg is empty when the key set does
+// not restrict the key to one algorithm.
+type jwk struct {
+	key crypto.PublicKey
+	alg string
+}
+
+// JWKS is a JSON Web Key Set, the public keys our identity provider signs
+// tokens with. It is read from a file or an http(s) URL and can be refreshed
+// periodically so rotated keys are picked up without a restart.
+type JWKS struct {
+	source string
+	client *http.Client
+
+	mu   sync.RWMutex
+	keys map[string]jwk
+
+	stop context.CancelFunc
+	done sync.WaitGroup
+}
+
+// LoadJWKS reads the key set from source, a file path or an http(s) URL, and
+// reloads it every interval when interval is positive. A failed reload keeps
+// the previous keys.
+func LoadJWKS(ctx context.Context, source string, interval time.Duration) (*JWKS, error) {
+	s := &JWKS{
+		source: source,
+		client: &http.Client{Timeout: 10 * time.Second},
+		stop:   func() {},
+	}
+	if err := s.Refresh(ctx); err != nil {
+		return nil, err
+	}
+
+	if interval > 0 {
+		refreshCtx, stop := context.WithCancel(context.Background())
+		s.stop = stop
+		s.done.Add(1)
+		go s.refreshLoop(refreshCtx, interval)
+	}
+	return s, nil
+}
+
+// Refresh reloads the key set from its source.
+func (s *JWKS) Refresh(ctx context.Context) error {
+	data, err := s.read(ctx)
+	if err != nil {
+		return fmt.Errorf("reading JWKS from %s: %w", s.source, err)
+	}
+	keys, err := parseJWKS(data)
+	if err != nil {
+		return fmt.Errorf("parsing JWKS from %s: %w", s.source, err)
+	}
+
+	s.mu.Lock()
+	s.keys = keys
+	s.mu.Unlock()
+	return nil
+}
+
+// key returns the key with the given ID. Tokens without an ID can only be
+// verified by a key set holding a single key.
+func (s *JWKS) key(kid string) (jwk, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if kid == "" && len(s.keys) == 1 {
+		for _, k := range s.keys {
+			return k, true
+		}
+	}
+	k, ok := s.keys[kid]
+	return k, ok
+}
+
+func (s *JWKS) read(ctx context.Context) ([]byte, error) {
+	if !strings.HasPrefix(s.source, "http://") && !strings.HasPrefix(s.source, "https://") {
+		return os.ReadFile(s.source)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.source, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, maxJWKSSize))
+}
+
+func (s *JWKS) refreshLoop(ctx context.Context, interval time.Duration) {
+	defer s.done.Done()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if err := s.Refresh(ctx); err != nil && ctx.Err() == nil {
+				slog.WarnContext(ctx, "JWKS refresh failed, keeping the previous keys", "error", err)
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// Close stops the periodic refresh.
+func (s *JWKS) Close() {
+	s.stop()
+	s.done.Wait()
+}
+
+// parseJWKS returns the RSA and P-256 signing keys of a key set by ID. Keys
+// meant for encryption or of other types are skipped.
+func parseJWKS(data []byte) (map[string]jwk, error) {
+	var set struct {
+		Keys []struct {
+			Kty string `json:"kty"`
+			Kid string `json:"kid"`
+			Use string `json:"use"`
+			Alg string `json:"alg"`
+			N   string `json:"n"`
+			E   string `json:"e"`
+			Crv string `json:"crv"`
+			X   string `json:"x"`
+			Y   string `json:"y"`
+		} `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, err
+	}
+
+	keys := make(map[string]jwk, len(set.Keys))
+	for _, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		var (
+			key crypto.PublicKey
+			err error
+		)
+		switch {
+		case k.Kty == "RSA":
+			key, err = rsaKey(k.N, k.E)
+		case k.Kty == "EC" && k.Crv == "P-256":
+			key, err = p256Key(k.X, k.Y)
+		default:
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("key %q: %w", k.Kid, err)
+		}
+		keys[k.Kid] = jwk{key: key, alg: k.Alg}
+	}
+	if len(keys) == 0 {
+		return nil, errors.New("no RSA or P-256 signing keys")
+	}
+	return keys, nil
+}
+
+func rsaKey(n, e string) (*rsa.PublicKey, error) {
+	nb, err := base64.RawURLEncoding.DecodeString(n)
+	if err != nil {
+		return nil, fmt.Errorf("invalid modulus: %w", err)
+	}
+	eb, err := base64.RawURLEncoding.DecodeString(e)
+	if err != nil {
+		return nil, fmt.Errorf("invalid exponent: %w", err)
+	}
+	key := &rsa.PublicKey{N: new(big.Int).SetBytes(nb)}
+	exponent := new(big.Int).SetBytes(eb)
+	if !exponent.IsInt64() || exponent.Int64() < 3 || exponent.Int64() > 1<<31-1 {
+		return nil, errors.New("invalid exponent")
+	}
+	key.E = int(exponent.Int64())
+	if key.N.BitLen() < 2048 {
+		return nil, fmt.Errorf("%d-bit modulus is too short", key.N.BitLen())
+	}
+	return key, nil
+}
+
+func p256Key(x, y string) (*ecdsa.PublicKey, error) {
+	xb, err := base64.RawURLEncoding.DecodeString(x)
+	if err != nil {
+		return nil, fmt.Errorf("invalid x coordinate: %w", err)
+	}
+	yb, err := base64.RawURLEncoding.DecodeString(y)
+	if err != nil {
+		return nil, fmt.Errorf("invalid y coordinate: %w", err)
+	}
+	if len(xb) != 32 || len(yb) != 32 {
+		return nil, errors.New("invalid P-256 coordinates")
+	}
+	// ecdh checks that the point is on the curve.
+	point := append(append([]byte{4}, xb...), yb...)
+	if _, err := ecdh.P256().NewPublicKey(point); err != nil {
+		return nil, err
+	}
+	return &ecdsa.PublicKey{
+		Curve: elliptic.P256(),
+		X:     new(big.Int).SetBytes(xb),
+		Y:     new(big.Int).SetBytes(yb),
+	}, nil
+}
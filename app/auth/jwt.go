@@ -0,0 +1,219 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+)
+
+// clockSkew is the tolerance applied to the expiry and not-before times of a
+// token, for clocks slightly out of sync with the identity provider.
+const clockSkew = 30 * time.Second
+
+// JWTOptions configures bearer token authentication.
+type JWTOptions struct {
+	// JWKS is the file path or http(s) URL of the key set tokens are signed
+	// with. Tokens are not accepted when it is empty.
+	JWKS string
+	// RefreshInterval is how often the key set is reloaded; 0 loads it once.
+	RefreshInterval time.Duration
+	// Audience must be one of the audiences of a token.
+	Audience string
+	// Issuer, when set, must be the issuer of a token.
+	Issuer string
+}
+
+// Validate reports settings missing for the key set to be used.
+func (o JWTOptions) Validate() error {
+	var errs []error
+	if o.JWKS != "" && o.Audience == "" {
+		errs = append(errs, errors.New("JWT audience is required with a JWKS"))
+	}
+	if o.RefreshInterval < 0 {
+		errs = append(errs, errors.New("JWKS refresh interval must not be negative"))
+	}
+	return errors.Join(errs...)
+}
+
+// JWT authenticates requests by the RS256 or ES256 signed JSON Web Token in
+// their Authorization: Bearer header. The token must not be expired, must be
+// issued for the configured audience and issuer, and grants the known scopes
+// listed in its scope or scp claim and the known roles of its roles claim.
+type JWT struct {
+	keys     *JWKS
+	audience string
+	issuer   string
+	now      func() time.Time
+}
+
+func NewJWT(keys *JWKS, opts JWTOptions) *JWT {
+	return &JWT{
+		keys:     keys,
+		audience: opts.Audience,
+		issuer:   opts.Issuer,
+		now:      time.Now,
+	}
+}
+
+// Authenticate implements Authenticator.
+func (j *JWT) Authenticate(r *http.Request) (*Principal, error) {
+	scheme, token, _ := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !strings.EqualFold(scheme, "Bearer") || token == "" {
+		return nil, ErrNoCredentials
+	}
+
+	c, err := j.verify(strings.TrimSpace(token))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidCredentials, err)
+	}
+
+	return &Principal{
+		Subject: "jwt:" + c.Subject,
+		Name:    c.Subject,
+		Scopes:  known(Scopes, append(strings.Fields(c.Scope), c.Scp...)),
+		Roles:   known(Roles, c.Roles),
+	}, nil
+}
+
+// Challenges implements Authenticator.
+func (j *JWT) Challenges() []string {
+	return []string{"Bearer"}
+}
+
+type claims struct {
+	Subject   string     `json:"sub"`
+	Issuer    string     `json:"iss"`
+	Audience  stringList `json:"aud"`
+	ExpiresAt *float64   `json:"exp"`
+	NotBefore *float64   `json:"nbf"`
+	Scope     string     `json:"scope"`
+	Scp       stringList `json:"scp"`
+	Roles     stringList `json:"roles"`
+}
+
+// stringList is a claim holding either a single string or a list of them.
+type stringList []string
+
+func (l *stringList) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*l = strings.Fields(single)
+		return nil
+	}
+	return json.Unmarshal(data, (*[]string)(l))
+}
+
+// verify checks the signature of token and the validity of its claims.
+func (j *JWT) verify(token string) (*claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, errors.New("malformed token")
+	}
+
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, fmt.Errorf("malformed header: %w", err)
+	}
+	key, ok := j.keys.key(header.Kid)
+	if !ok {
+		return nil, fmt.Errorf("unknown key %q", header.Kid)
+	}
+	if key.alg != "" && key.alg != header.Alg {
+		return nil, fmt.Errorf("key %q does not sign with %s", header.Kid, header.Alg)
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("malformed signature: %w", err)
+	}
+	if err := verifySignature(header.Alg, key.key, parts[0]+"."+parts[1], signature); err != nil {
+		return nil, err
+	}
+
+	var c claims
+	if err := decodeSegment(parts[1], &c); err != nil {
+		return nil, fmt.Errorf("malformed claims: %w", err)
+	}
+	now := j.now()
+	switch {
+	case c.ExpiresAt == nil:
+		return nil, errors.New("token has no expiry")
+	case now.After(unixTime(*c.ExpiresAt).Add(clockSkew)):
+		return nil, errors.New("token expired")
+	case c.NotBefore != nil && now.Add(clockSkew).Before(unixTime(*c.NotBefore)):
+		return nil, errors.New("token not valid yet")
+	case !slices.Contains(c.Audience, j.audience):
+		return nil, fmt.Errorf("token not issued for audience %q", j.audience)
+	case j.issuer != "" && c.Issuer != j.issuer:
+		return nil, fmt.Errorf("unexpected issuer %q", c.Issuer)
+	}
+	return &c, nil
+}
+
+// verifySignature checks an RS256 or ES256 signature of the signing input.
+// The algorithm must match the type of the key, so a token cannot pick an
+// algorithm the key was not meant for.
+func verifySignature(alg string, key crypto.PublicKey, input string, signature []byte) error {
+	digest := sha256.Sum256([]byte(input))
+	switch alg {
+	case "RS256":
+		pub, ok := key.(*rsa.PublicKey)
+		if !ok {
+			return errors.New("RS256 token signed with a non-RSA key")
+		}
+		if err := rsa.VerifyPKCS1v15(pub, crypto.SHA256, digest[:], signature); err != nil {
+			return errors.New("invalid signature")
+		}
+	case "ES256":
+		pub, ok := key.(*ecdsa.PublicKey)
+		if !ok {
+			return errors.New("ES256 token signed with a non-EC key")
+		}
+		if len(signature) != 64 {
+			return errors.New("invalid signature")
+		}
+		r := new(big.Int).SetBytes(signature[:32])
+		s := new(big.Int).SetBytes(signature[32:])
+		if !ecdsa.Verify(pub, digest[:], r, s) {
+			return errors.New("invalid signature")
+		}
+	default:
+		return fmt.Errorf("unsupported algorithm %q", alg)
+	}
+	return nil
+}
+
+// known returns the values of claimed found in valid, without duplicates.
+func known(valid, claimed []string) []string {
+	var values []string
+	for _, v := range claimed {
+		if slices.Contains(valid, v) && !slices.Contains(values, v) {
+			values = append(values, v)
+		}
+	}
+	return values
+}
+
+func decodeSegment(segment string, v any) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+func unixTime(seconds float64) time.Time {
+	return time.Unix(int64(seconds), 0)
+}
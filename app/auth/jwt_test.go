@@ -0,0 +1,311 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testKey is a signing key generated for a test, with its JWK form.
+type testKey struct {
+	kid    string
+	alg    string
+	signer crypto.Signer
+}
+
+func newRSAKey(t *testing.T, kid string) testKey {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	return testKey{kid: kid, alg: "RS256", signer: key}
+}
+
+func newECKey(t *testing.T, kid string) testKey {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	return testKey{kid: kid, alg: "ES256", signer: key}
+}
+
+func (k testKey) jwk() map[string]string {
+	b64 := base64.RawURLEncoding.EncodeToString
+	switch pub := k.signer.Public().(type) {
+	case *rsa.PublicKey:
+		return map[string]string{"kty": "RSA", "kid": k.kid, "use": "sig", "n": b64(pub.N.Bytes()), "e": "AQAB"}
+	case *ecdsa.PublicKey:
+		x, y := make([]byte, 32), make([]byte, 32)
+		pub.X.FillBytes(x)
+		pub.Y.FillBytes(y)
+		return map[string]string{"kty": "EC", "kid": k.kid, "crv": "P-256", "x": b64(x), "y": b64(y)}
+	}
+	panic("unsupported key")
+}
+
+// sign returns a token with the given claims, signed by k.
+func (k testKey) sign(t *testing.T, claims map[string]any) string {
+	t.Helper()
+	b64 := base64.RawURLEncoding.EncodeToString
+	header, err := json.Marshal(map[string]string{"alg": k.alg, "kid": k.kid, "typ": "JWT"})
+	require.NoError(t, err)
+	payload, err := json.Marshal(claims)
+	require.NoError(t, err)
+	input := b64(header) + "." + b64(payload)
+
+	digest := sha256.Sum256([]byte(input))
+	var signature []byte
+	switch signer := k.signer.(type) {
+	case *rsa.PrivateKey:
+		signature, err = rsa.SignPKCS1v15(rand.Reader, signer, crypto.SHA256, digest[:])
+		require.NoError(t, err)
+	case *ecdsa.PrivateKey:
+		r, s, err := ecdsa.Sign(rand.Reader, signer, digest[:])
+		require.NoError(t, err)
+		signature = make([]byte, 64)
+		r.FillBytes(signature[:32])
+		s.FillBytes(signature[32:])
+	}
+	return input + "." + b64(signature)
+}
+
+func jwksDocument(t *testing.T, keys ...testKey) []byte {
+	t.Helper()
+	set := map[string][]map[string]string{"keys": {}}
+	for _, k := range keys {
+		set["keys"] = append(set["keys"], k.jwk())
+	}
+	data, err := json.Marshal(set)
+	require.NoError(t, err)
+	return data
+}
+
+func writeJWKS(t *testing.T, keys ...testKey) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	require.NoError(t, os.WriteFile(path, jwksDocument(t, keys...), 0o600))
+	return path
+}
+
+func bearer(token string) *http.Request {
+	r := httptest.NewRequest("POST", "/categories", nil)
+	r.Header.Set("Authorization", "Bearer "+token)
+	return r
+}
+
+func TestJWT(t *testing.T) {
+	rsaKey := newRSAKey(t, "rsa-1")
+	ecKey := newECKey(t, "ec-1")
+	keys, err := LoadJWKS(context.Background(), writeJWKS(t, rsaKey, ecKey), 0)
+	require.NoError(t, err)
+
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	authenticator := NewJWT(keys, JWTOptions{Audience: "catalog-api", Issuer: "https://id.example.com"})
+	authenticator.now = func() time.Time { return now }
+
+	validClaims := func() map[string]any {
+		return map[string]any{
+			"sub":   "pricing-service",
+			"iss":   "https://id.example.com",
+			"aud":   []string{"catalog-api", "search-api"},
+			"exp":   now.Add(5 * time.Minute).Unix(),
+			"scope": "openid catalog:write",
+			"scp":   []string{"categories:write", "catalog:write"},
+			"roles": []string{"editor", "catalog-owner"},
+		}
+	}
+
+	t.Run("accepts RS256 and ES256 tokens and maps their scopes and roles", func(t *testing.T) {
+		for _, key := range []testKey{rsaKey, ecKey} {
+			p, err := authenticator.Authenticate(bearer(key.sign(t, validClaims())))
+
+			require.NoError(t, err, key.alg)
+			assert.Equal(t, "jwt:pricing-service", p.Subject)
+			assert.Equal(t, []string{ScopeCatalogWrite, ScopeCategoriesWrite}, p.Scopes)
+			assert.Equal(t, []string{RoleEditor}, p.Roles)
+		}
+	})
+
+	t.Run("accepts a single audience and an expiry within the clock skew", func(t *testing.T) {
+		claims := validClaims()
+		claims["aud"] = "catalog-api"
+		claims["exp"] = now.Add(-10 * time.Second).Unix()
+
+		_, err := authenticator.Authenticate(bearer(rsaKey.sign(t, claims)))
+
+		assert.NoError(t, err)
+	})
+
+	t.Run("ignores requests without a bearer token", func(t *testing.T) {
+		r := httptest.NewRequest("POST", "/categories", nil)
+		_, err := authenticator.Authenticate(r)
+		assert.ErrorIs(t, err, ErrNoCredentials)
+
+		r.Header.Set("Authorization", "Basic YWRtaW46YWRtaW4=")
+		_, err = authenticator.Authenticate(r)
+		assert.ErrorIs(t, err, ErrNoCredentials)
+	})
+
+	rejected := map[string]func(t *testing.T) string{
+		"expired": func(t *testing.T) string {
+			claims := validClaims()
+			claims["exp"] = now.Add(-time.Minute).Unix()
+			return rsaKey.sign(t, claims)
+		},
+		"without expiry": func(t *testing.T) string {
+			claims := validClaims()
+			delete(claims, "exp")
+			return rsaKey.sign(t, claims)
+		},
+		"not valid yet": func(t *testing.T) string {
+			claims := validClaims()
+			claims["nbf"] = now.Add(time.Minute).Unix()
+			return rsaKey.sign(t, claims)
+		},
+		"for another audience": func(t *testing.T) string {
+			claims := validClaims()
+			claims["aud"] = "search-api"
+			return ecKey.sign(t, claims)
+		},
+		"from another issuer": func(t *testing.T) string {
+			claims := validClaims()
+			claims["iss"] = "https://evil.example.com"
+			return ecKey.sign(t, claims)
+		},
+		"signed by an unknown key": func(t *testing.T) string {
+			return newRSAKey(t, "rsa-2").sign(t, validClaims())
+		},
+		"signed by another key with a known ID": func(t *testing.T) string {
+			return newECKey(t, "ec-1").sign(t, validClaims())
+		},
+		"with an algorithm not matching the key": func(t *testing.T) string {
+			return testKey{kid: "rsa-1", alg: "ES256", signer: ecKey.signer}.sign(t, validClaims())
+		},
+		"with a tampered payload": func(t *testing.T) string {
+			parts := strings.Split(rsaKey.sign(t, validClaims()), ".")
+			claims := validClaims()
+			claims["scope"] = "catalog:read catalog:write categories:write promotions:write"
+			payload, err := json.Marshal(claims)
+			require.NoError(t, err)
+			return parts[0] + "." + base64.RawURLEncoding.EncodeToString(payload) + "." + parts[2]
+		},
+		"unsigned": func(t *testing.T) string {
+			b64 := base64.RawURLEncoding.EncodeToString
+			payload, _ := json.Marshal(validClaims())
+			return b64([]byte(`{"alg":"none","kid":"rsa-1"}`)) + "." + b64(payload) + "."
+		},
+		"malformed": func(t *testing.T) string { return "not-a-token" },
+	}
+	for name, token := range rejected {
+		t.Run("rejects a token "+name, func(t *testing.T) {
+			_, err := authenticator.Authenticate(bearer(token(t)))
+
+			assert.ErrorIs(t, err, ErrInvalidCredentials)
+		})
+	}
+}
+
+func TestJWTOptionsValidate(t *testing.T) {
+	assert.NoError(t, JWTOptions{}.Validate())
+	assert.NoError(t, JWTOptions{JWKS: "jwks.json", Audience: "catalog-api"}.Validate())
+	assert.Error(t, JWTOptions{JWKS: "jwks.json"}.Validate())
+	assert.Error(t, JWTOptions{RefreshInterval: -time.Minute}.Validate())
+}
+
+func TestLoadJWKS(t *testing.T) {
+	t.Run("refreshes the keys from a URL", func(t *testing.T) {
+		first, second := newRSAKey(t, "2026-01"), newECKey(t, "2026-02")
+		var document atomic.Value
+		document.Store(jwksDocument(t, first))
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write(document.Load().([]byte))
+		}))
+		defer server.Close()
+
+		keys, err := LoadJWKS(context.Background(), server.URL, 10*time.Millisecond)
+		require.NoError(t, err)
+		defer keys.Close()
+
+		_, ok := keys.key("2026-01")
+		assert.True(t, ok)
+
+		document.Store(jwksDocument(t, second))
+		assert.Eventually(t, func() bool {
+			_, rotated := keys.key("2026-02")
+			_, retired := keys.key("2026-01")
+			return rotated && !retired
+		}, time.Second, 10*time.Millisecond)
+	})
+
+	t.Run("keeps the previous keys when a refresh fails", func(t *testing.T) {
+		path := writeJWKS(t, newECKey(t, "ec-1"))
+		keys, err := LoadJWKS(context.Background(), path, 0)
+		require.NoError(t, err)
+
+		require.NoError(t, os.WriteFile(path, []byte(`{"keys":[`), 0o600))
+
+		assert.Error(t, keys.Refresh(context.Background()))
+		_, ok := keys.key("ec-1")
+		assert.True(t, ok)
+	})
+
+	t.Run("uses a single key for tokens without a key ID", func(t *testing.T) {
+		keys, err := LoadJWKS(context.Background(), writeJWKS(t, newECKey(t, "ec-1")), 0)
+		require.NoError(t, err)
+
+		_, ok := keys.key("")
+		assert.True(t, ok)
+	})
+
+	t.Run("skips encryption and unsupported keys", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "jwks.json")
+		signing := newECKey(t, "sig").jwk()
+		encryption := newRSAKey(t, "enc").jwk()
+		encryption["use"] = "enc"
+		document, err := json.Marshal(map[string]any{"keys": []any{
+			signing,
+			encryption,
+			map[string]string{"kty": "OKP", "kid": "ed", "crv": "Ed25519", "x": "11qYAYKxCrfVS_7TyWQHOg7hcvPapiMlrwIaaPcHURo"},
+		}})
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(path, document, 0o600))
+
+		keys, err := LoadJWKS(context.Background(), path, 0)
+		require.NoError(t, err)
+
+		_, ok := keys.key("sig")
+		assert.True(t, ok)
+		_, ok = keys.key("enc")
+		assert.False(t, ok)
+	})
+
+	t.Run("fails without usable keys", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "jwks.json")
+		require.NoError(t, os.WriteFile(path, []byte(`{"keys":[]}`), 0o600))
+
+		_, err := LoadJWKS(context.Background(), path, 0)
+
+		assert.ErrorContains(t, err, "no RSA or P-256 signing keys")
+	})
+
+	t.Run("fails when the source is unavailable", func(t *testing.T) {
+		_, err := LoadJWKS(context.Background(), filepath.Join(t.TempDir(), "missing.json"), 0)
+
+		assert.Error(t, err)
+	})
+}
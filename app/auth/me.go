@@ -0,0 +1,42 @@
+package auth
+
+import (
+	"net/http"
+
+	"github.com/mytheresa/go-hiring-challenge/app/api"
+)
+
+type MeResponse struct {
+	Subject     string       `json:"subject"`
+	Name        string       `json:"name"`
+	Roles       []string     `json:"roles"`
+	Scopes      []string     `json:"scopes"`
+	Permissions []Permission `json:"permissions"`
+}
+
+// HandleMe describes the authenticated caller with its effective permissions.
+// It must run behind Authenticated or Require.
+func HandleMe(w http.ResponseWriter, r *http.Request) {
+	p, ok := FromContext(r.Context())
+	if !ok {
+		api.ErrorResponse(w, http.StatusUnauthorized, "Missing credentials")
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	api.OKResponse(w, MeResponse{
+		Subject:     p.Subject,
+		Name:        p.Name,
+		Roles:       nonNil(p.Roles),
+		Scopes:      nonNil(p.Scopes),
+		Permissions: nonNil(p.Permissions()),
+	})
+}
+
+// nonNil keeps empty lists encoded as [] rather than null.
+func nonNil[T any](s []T) []T {
+	if s == nil {
+		return []T{}
+	}
+	return s
+}
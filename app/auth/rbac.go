@@ -0,0 +1,144 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+
+	"github.com/mytheresa/go-hiring-challenge/app/api"
+	"github.com/mytheresa/go-hiring-challenge/app/logging"
+)
+
+// Roles granted to API clients. Each role includes the permissions of the
+// previous one.
+const (
+	RoleViewer = "viewer"
+	RoleEditor = "editor"
+	RoleAdmin  = "admin"
+)
+
+// Roles lists every role a client can be granted.
+var Roles = []string{RoleViewer, RoleEditor, RoleAdmin}
+
+// Permission is an operation the handlers check before running it.
+type Permission string
+
+const (
+	PermissionViewCatalog      Permission = "catalog.view"
+	PermissionEditProducts     Permission = "products.edit"
+	PermissionChangePrices     Permission = "prices.change"
+	PermissionDeleteProducts   Permission = "products.delete"
+	PermissionCreateCategories Permission = "categories.create"
+	PermissionDeleteCategories Permission = "categories.delete"
+	PermissionManagePromotions Permission = "promotions.manage"
+)
+
+// permissionScopes is the scope credentials need for a permission to apply,
+// whatever the roles of the client. Scopes limit what a credential may be used
+// for, while roles decide what its holder may do.
+var permissionScopes = []struct {
+	permission Permission
+	scope      string
+}{
+	{PermissionViewCatalog, ScopeCatalogRead},
+	{PermissionEditProducts, ScopeCatalogWrite},
+	{PermissionChangePrices, ScopeCatalogWrite},
+	{PermissionDeleteProducts, ScopeCatalogWrite},
+	{PermissionCreateCategories, ScopeCategoriesWrite},
+	{PermissionDeleteCategories, ScopeCategoriesWrite},
+	{PermissionManagePromotions, ScopePromotionsWrite},
+}
+
+// rolePermissions lists the permissions each role grants.
+var rolePermissions = map[string][]Permission{
+	RoleViewer: {PermissionViewCatalog},
+	RoleEditor: {PermissionViewCatalog, PermissionEditProducts, PermissionChangePrices, PermissionCreateCategories,
+		PermissionManagePromotions},
+	RoleAdmin: {PermissionViewCatalog, PermissionEditProducts, PermissionChangePrices, PermissionDeleteProducts,
+		PermissionCreateCategories, PermissionDeleteCategories, PermissionManagePromotions},
+}
+
+// ValidateRoles reports the roles that are not known.
+func ValidateRoles(roles []string) error {
+	if len(roles) == 0 {
+		return errors.New("at least one role is required")
+	}
+	for _, r := range roles {
+		if _, ok := rolePermissions[r]; !ok {
+			return fmt.Errorf("unknown role %q", r)
+		}
+	}
+	return nil
+}
+
+// Permissions returns the effective permissions of the principal: those
+// granted by one of its roles whose scope it holds.
+func (p *Principal) Permissions() []Permission {
+	var permissions []Permission
+	for _, ps := range permissionScopes {
+		if p.Can(ps.permission) {
+			permissions = append(permissions, ps.permission)
+		}
+	}
+	return permissions
+}
+
+// Can reports whether the principal holds permission.
+func (p *Principal) Can(permission Permission) bool {
+	return p.hasRoleFor(permission) && p.HasScope(scopeFor(permission))
+}
+
+func (p *Principal) hasRoleFor(permission Permission) bool {
+	for _, role := range p.Roles {
+		if slices.Contains(rolePermissions[role], permission) {
+			return true
+		}
+	}
+	return false
+}
+
+func scopeFor(permission Permission) string {
+	for _, ps := range permissionScopes {
+		if ps.permission == permission {
+			return ps.scope
+		}
+	}
+	return ""
+}
+
+// forbiddenProblem is the body of a denied request.
+type forbiddenProblem struct {
+	api.Problem
+	Permission Permission `json:"permission"`
+	Roles      []string   `json:"roles"`
+}
+
+// Authorize reports whether the client of r holds permission. Otherwise it
+// writes a 403 problem response naming the missing permission, and the caller
+// must stop handling the request. Requests without a principal are denied.
+func Authorize(w http.ResponseWriter, r *http.Request, permission Permission) bool {
+	p, ok := FromContext(r.Context())
+	if ok && p.Can(permission) {
+		return true
+	}
+
+	detail := fmt.Sprintf("The request is not authenticated, so it does not hold the %s permission.", permission)
+	roles := []string{}
+	if ok {
+		roles = append(roles, p.Roles...)
+		detail = fmt.Sprintf("None of your roles grants the %s permission.", permission)
+		if p.hasRoleFor(permission) {
+			detail = fmt.Sprintf("Your credentials lack the %s scope needed for the %s permission.", scopeFor(permission), permission)
+		}
+	}
+
+	ctx := r.Context()
+	logging.FromContext(ctx).WarnContext(ctx, "permission denied", "permission", permission)
+	api.ProblemResponse(w, http.StatusForbidden, forbiddenProblem{
+		Problem:    api.NewProblem(http.StatusForbidden, detail),
+		Permission: permission,
+		Roles:      roles,
+	})
+	return false
+}
@@ -0,0 +1,128 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrincipalPermissions(t *testing.T) {
+	tests := map[string]struct {
+		principal Principal
+		expected  []Permission
+	}{
+		"viewer": {
+			principal: Principal{Roles: []string{RoleViewer}, Scopes: Scopes},
+			expected:  []Permission{PermissionViewCatalog},
+		},
+		"editor": {
+			principal: Principal{Roles: []string{RoleEditor}, Scopes: Scopes},
+			expected:  []Permission{PermissionViewCatalog, PermissionEditProducts, PermissionChangePrices, PermissionCreateCategories, PermissionManagePromotions},
+		},
+		"admin limited by its scopes": {
+			principal: Principal{Roles: []string{RoleAdmin}, Scopes: []string{ScopeCategoriesWrite}},
+			expected:  []Permission{PermissionCreateCategories, PermissionDeleteCategories},
+		},
+		"several roles": {
+			principal: Principal{Roles: []string{RoleViewer, RoleAdmin}, Scopes: []string{ScopeCatalogRead, ScopeCatalogWrite}},
+			expected:  []Permission{PermissionViewCatalog, PermissionEditProducts, PermissionChangePrices, PermissionDeleteProducts},
+		},
+		"no roles": {
+			principal: Principal{Scopes: Scopes},
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tt.principal.Permissions())
+		})
+	}
+}
+
+func TestAuthorize(t *testing.T) {
+	serve := func(p *Principal) (*httptest.ResponseRecorder, bool) {
+		recorder := httptest.NewRecorder()
+		request := httptest.NewRequest("DELETE", "/categories/shoes", nil)
+		if p != nil {
+			request = request.WithContext(WithPrincipal(request.Context(), p))
+		}
+		return recorder, Authorize(recorder, request, PermissionDeleteCategories)
+	}
+
+	t.Run("allows a principal holding the permission", func(t *testing.T) {
+		recorder, ok := serve(&Principal{Roles: []string{RoleAdmin}, Scopes: []string{ScopeCategoriesWrite}})
+
+		assert.True(t, ok)
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		assert.Empty(t, recorder.Body.String())
+	})
+
+	t.Run("denies a role without the permission", func(t *testing.T) {
+		recorder, ok := serve(&Principal{Roles: []string{RoleEditor}, Scopes: []string{ScopeCategoriesWrite}})
+
+		assert.False(t, ok)
+		assert.Equal(t, http.StatusForbidden, recorder.Code)
+		assert.Equal(t, "application/problem+json", recorder.Header().Get("Content-Type"))
+		assert.JSONEq(t, `{
+			"type": "about:blank",
+			"title": "Forbidden",
+			"status": 403,
+			"detail": "None of your roles grants the categories.delete permission.",
+			"permission": "categories.delete",
+			"roles": ["editor"]
+		}`, recorder.Body.String())
+	})
+
+	t.Run("denies a role whose credentials lack the scope", func(t *testing.T) {
+		recorder, ok := serve(&Principal{Roles: []string{RoleAdmin}, Scopes: []string{ScopeCatalogWrite}})
+
+		assert.False(t, ok)
+		assert.Equal(t, http.StatusForbidden, recorder.Code)
+		assert.Contains(t, recorder.Body.String(), "lack the categories:write scope")
+	})
+
+	t.Run("denies unauthenticated requests", func(t *testing.T) {
+		recorder, ok := serve(nil)
+
+		assert.False(t, ok)
+		assert.Equal(t, http.StatusForbidden, recorder.Code)
+		assert.Contains(t, recorder.Body.String(), `"roles":[]`)
+	})
+}
+
+func TestHandleMe(t *testing.T) {
+	t.Run("lists the effective permissions", func(t *testing.T) {
+		recorder := httptest.NewRecorder()
+		request := httptest.NewRequest("GET", "/me", nil)
+		request = request.WithContext(WithPrincipal(request.Context(), &Principal{
+			Subject: "apikey:mhc_Ab3dE6gH",
+			Name:    "pricing tool",
+			Roles:   []string{RoleEditor},
+			Scopes:  []string{ScopeCatalogRead, ScopeCatalogWrite},
+		}))
+
+		HandleMe(recorder, request)
+
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		assert.Equal(t, "no-store", recorder.Header().Get("Cache-Control"))
+		assert.JSONEq(t, `{
+			"subject": "apikey:mhc_Ab3dE6gH",
+			"name": "pricing tool",
+			"roles": ["editor"],
+			"scopes": ["catalog:read", "catalog:write"],
+			"permissions": ["catalog.view", "products.edit", "prices.change"]
+		}`, recorder.Body.String())
+	})
+
+	t.Run("encodes missing roles as empty lists", func(t *testing.T) {
+		recorder := httptest.NewRecorder()
+		request := httptest.NewRequest("GET", "/me", nil)
+		request = request.WithContext(WithPrincipal(request.Context(), &Principal{Subject: "jwt:batch", Scopes: []string{ScopeCatalogRead}}))
+
+		HandleMe(recorder, request)
+
+		assert.Contains(t, recorder.Body.String(), `"roles":[]`)
+		assert.Contains(t, recorder.Body.String(), `"permissions":[]`)
+	})
+}
@@ -0,0 +1,232 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/mytheresa/go-hiring-challenge/app/api"
+	"github.com/mytheresa/go-hiring-challenge/app/auth"
+	"github.com/mytheresa/go-hiring-challenge/app/logging"
+	"golang.org/x/sync/singleflight"
+)
+
+// ErrNotFound is returned by Get when the key is missing or expired.
+var ErrNotFound = errors.New("cache: key not found")
+
+// Cache is a key/value store shared by the cached repositories.
+// Implementations must be safe for concurrent use, and those doing I/O give
+// up once ctx is done.
+type Cache interface {
+	// Get returns the value stored under key, or ErrNotFound.
+	Get(ctx context.Context, key string) ([]byte, error)
+	// Set stores value under key for the given ttl. A zero ttl never expires.
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	// Delete removes key. Deleting a missing key is not an error.
+	Delete(ctx context.Context, key string) error
+	// Incr atomically increments the integer stored under key, starting from zero.
+	Incr(ctx context.Context, key string) (int64, error)
+}
+
+// Stats reports the effectiveness of a ReadThrough cache.
+// Errors counts backend failures, which are served from the repository instead.
+type Stats struct {
+	Hits   uint64 `json:"hits"`
+	Misses uint64 `json:"misses"`
+	Errors uint64 `json:"errors"`
+}
+
+// ReadThrough caches repository reads under a namespace of a shared Cache.
+//
+// Values are stored as JSON, so callers always get their own copy. Concurrent
+// misses for the same key within a process share a single load. Invalidate bumps
+// a generation counter stored in the cache itself, which retires every entry of
+// the namespace on all instances sharing the backend; old entries age out by TTL.
+type ReadThrough struct {
+	cache     Cache
+	namespace string
+	ttl       time.Duration
+	timeout   time.Duration
+	group     singleflight.Group
+
+	hits   atomic.Uint64
+	misses atomic.Uint64
+	errors atomic.Uint64
+}
+
+// NewReadThrough returns a cache of entries living for ttl. A load shared
+// between callers is given up after timeout; zero never gives up.
+func NewReadThrough(c Cache, namespace string, ttl, timeout time.Duration) *ReadThrough {
+	return &ReadThrough{
+		cache:     c,
+		namespace: namespace,
+		ttl:       ttl,
+		timeout:   timeout,
+	}
+}
+
+// Namespace returns the key prefix of the cached entries.
+func (rt *ReadThrough) Namespace() string {
+	return rt.namespace
+}
+
+// Load decodes the value cached under key into dst. On a miss it calls fetch,
+// caches the result and decodes it into dst. Errors returned by fetch are not cached.
+//
+// A load shared between concurrent callers runs detached from their cancellation,
+// so one caller going away does not fail the others, but is bounded by the
+// timeout of the ReadThrough; each caller still stops waiting as soon as its
+// own ctx is done.
+func (rt *ReadThrough) Load(ctx context.Context, key string, dst any, fetch func(ctx context.Context) (any, error)) error {
+	return rt.LoadUntil(ctx, key, dst, func(ctx context.Context) (any, time.Time, error) {
+		value, err := fetch(ctx)
+		return value, time.Time{}, err
+	})
+}
+
+// LoadUntil is Load for values that only hold until the instant fetch returns
+// along with them, such as query results depending on the current time. The
+// entry lives until then if it is sooner than the TTL; a value that has
+// already expired is returned without being cached. A zero instant never
+// shortens the TTL.
+func (rt *ReadThrough) LoadUntil(ctx context.Context, key string, dst any, fetch func(ctx context.Context) (any, time.Time, error)) error {
+	generation, err := rt.generation(ctx)
+	if err != nil {
+		rt.fail(ctx, "reading generation", err)
+		return rt.fetch(ctx, dst, fetch)
+	}
+
+	entryKey := fmt.Sprintf("%s:%d:%s", rt.namespace, generation, key)
+	data, err := rt.cache.Get(ctx, entryKey)
+	if err == nil {
+		if err := json.Unmarshal(data, dst); err == nil {
+			rt.hits.Add(1)
+			return nil
+		}
+	} else if !errors.Is(err, ErrNotFound) {
+		rt.fail(ctx, "reading "+entryKey, err)
+		return rt.fetch(ctx, dst, fetch)
+	}
+	rt.misses.Add(1)
+
+	ch := rt.group.DoChan(entryKey, func() (any, error) {
+		shared, cancel := context.WithoutCancel(ctx), context.CancelFunc(func() {})
+		if rt.timeout > 0 {
+			shared, cancel = context.WithTimeout(shared, rt.timeout)
+		}
+		defer cancel()
+		value, expires, err := fetch(shared)
+		if err != nil {
+			return nil, err
+		}
+		data, err := json.Marshal(value)
+		if err != nil {
+			return nil, err
+		}
+
+		ttl := rt.ttl
+		if !expires.IsZero() {
+			until := time.Until(expires)
+			if until <= 0 {
+				return data, nil
+			}
+			if ttl <= 0 || until < ttl {
+				ttl = until
+			}
+		}
+		// A write during the load bumps the generation; the result is still
+		// returned to the callers but is not cached under the old generation.
+		if current, err := rt.generation(shared); err == nil && current == generation {
+			if err := rt.cache.Set(shared, entryKey, data, ttl); err != nil {
+				rt.fail(shared, "writing "+entryKey, err)
+			}
+		}
+		return data, nil
+	})
+
+	select {
+	case res := <-ch:
+		if res.Err != nil {
+			return res.Err
+		}
+		return json.Unmarshal(res.Val.([]byte), dst)
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Invalidate retires every entry of the namespace. It follows a write that
+// has already happened, so it runs detached from the cancellation of ctx,
+// bounded by the timeout of the ReadThrough instead.
+func (rt *ReadThrough) Invalidate(ctx context.Context) {
+	ctx, cancel := context.WithoutCancel(ctx), context.CancelFunc(func() {})
+	if rt.timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, rt.timeout)
+	}
+	defer cancel()
+	if _, err := rt.cache.Incr(ctx, rt.generationKey()); err != nil {
+		rt.fail(ctx, "invalidating", err)
+	}
+}
+
+// Stats returns the hit, miss and error counters of this process.
+func (rt *ReadThrough) Stats() Stats {
+	return Stats{
+		Hits:   rt.hits.Load(),
+		Misses: rt.misses.Load(),
+		Errors: rt.errors.Load(),
+	}
+}
+
+// HandleStats returns the counters of the given caches keyed by namespace.
+func HandleStats(caches ...*ReadThrough) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !auth.Authorize(w, r, auth.PermissionViewCatalog) {
+			return
+		}
+
+		stats := make(map[string]Stats, len(caches))
+		for _, c := range caches {
+			stats[c.namespace] = c.Stats()
+		}
+		api.OKResponse(w, stats)
+	}
+}
+
+func (rt *ReadThrough) generationKey() string {
+	return rt.namespace + ":generation"
+}
+
+func (rt *ReadThrough) generation(ctx context.Context) (int64, error) {
+	data, err := rt.cache.Get(ctx, rt.generationKey())
+	if errors.Is(err, ErrNotFound) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseInt(string(data), 10, 64)
+}
+
+func (rt *ReadThrough) fetch(ctx context.Context, dst any, fetch func(ctx context.Context) (any, time.Time, error)) error {
+	value, _, err := fetch(ctx)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, dst)
+}
+
+func (rt *ReadThrough) fail(ctx context.Context, op string, err error) {
+	rt.errors.Add(1)
+	logging.FromContext(ctx).WarnContext(ctx, "cache failed, using the repository",
+		"namespace", rt.namespace, "operation", op, "error", err)
+}
@@ -0,0 +1,266 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mytheresa/go-hiring-challenge/app/auth"
+)
+
+type item struct {
+	Name string `json:"name"`
+}
+
+// brokenCache fails every operation, like an unreachable backend.
+type brokenCache struct{}
+
+var errBroken = errors.New("backend unavailable")
+
+func (brokenCache) Get(context.Context, string) ([]byte, error)              { return nil, errBroken }
+func (brokenCache) Set(context.Context, string, []byte, time.Duration) error { return errBroken }
+func (brokenCache) Delete(context.Context, string) error                     { return errBroken }
+func (brokenCache) Incr(context.Context, string) (int64, error)              { return 0, errBroken }
+
+func TestReadThrough(t *testing.T) {
+	t.Run("serves repeated reads from the cache", func(t *testing.T) {
+		rt := NewReadThrough(NewMemory(10), "items", time.Minute, 0)
+		calls := 0
+		fetch := func(context.Context) (any, error) {
+			calls++
+			return &item{Name: "shoes"}, nil
+		}
+
+		for range 3 {
+			var got *item
+			require.NoError(t, rt.Load(context.Background(), "a", &got, fetch))
+			assert.Equal(t, &item{Name: "shoes"}, got)
+		}
+
+		assert.Equal(t, 1, calls)
+		assert.Equal(t, Stats{Hits: 2, Misses: 1}, rt.Stats())
+	})
+
+	t.Run("does not cache errors", func(t *testing.T) {
+		rt := NewReadThrough(NewMemory(10), "items", time.Minute, 0)
+		calls := 0
+		fetch := func(context.Context) (any, error) {
+			calls++
+			return nil, assert.AnError
+		}
+
+		for range 2 {
+			var got *item
+			assert.ErrorIs(t, rt.Load(context.Background(), "a", &got, fetch), assert.AnError)
+		}
+
+		assert.Equal(t, 2, calls)
+	})
+
+	t.Run("invalidates every instance sharing the backend", func(t *testing.T) {
+		shared := NewMemory(10)
+		first := NewReadThrough(shared, "items", time.Minute, 0)
+		second := NewReadThrough(shared, "items", time.Minute, 0)
+		calls := 0
+		fetch := func(context.Context) (any, error) {
+			calls++
+			return &item{Name: "shoes"}, nil
+		}
+
+		var got *item
+		require.NoError(t, first.Load(context.Background(), "a", &got, fetch))
+		require.NoError(t, second.Load(context.Background(), "a", &got, fetch))
+		assert.Equal(t, 1, calls)
+
+		first.Invalidate(context.Background())
+
+		require.NoError(t, second.Load(context.Background(), "a", &got, fetch))
+		assert.Equal(t, 2, calls)
+	})
+
+	t.Run("keeps namespaces apart", func(t *testing.T) {
+		shared := NewMemory(10)
+		items := NewReadThrough(shared, "items", time.Minute, 0)
+		others := NewReadThrough(shared, "others", time.Minute, 0)
+
+		var got *item
+		require.NoError(t, items.Load(context.Background(), "a", &got, func(context.Context) (any, error) { return &item{Name: "item"}, nil }))
+		require.NoError(t, others.Load(context.Background(), "a", &got, func(context.Context) (any, error) { return &item{Name: "other"}, nil }))
+		assert.Equal(t, "other", got.Name)
+
+		items.Invalidate(context.Background())
+		require.NoError(t, others.Load(context.Background(), "a", &got, func(context.Context) (any, error) { return nil, assert.AnError }))
+		assert.Equal(t, "other", got.Name)
+	})
+
+	t.Run("falls back to the repository when the backend fails", func(t *testing.T) {
+		rt := NewReadThrough(brokenCache{}, "items", time.Minute, 0)
+
+		var got *item
+		require.NoError(t, rt.Load(context.Background(), "a", &got, func(context.Context) (any, error) { return &item{Name: "shoes"}, nil }))
+		rt.Invalidate(context.Background())
+
+		assert.Equal(t, "shoes", got.Name)
+		assert.Equal(t, uint64(2), rt.Stats().Errors)
+	})
+
+	t.Run("shares one load between concurrent misses", func(t *testing.T) {
+		rt := NewReadThrough(NewMemory(10), "items", time.Minute, 0)
+		release := make(chan struct{})
+		var calls atomic.Int32
+		fetch := func(context.Context) (any, error) {
+			calls.Add(1)
+			<-release
+			return &item{Name: "shoes"}, nil
+		}
+
+		var wg sync.WaitGroup
+		for range 5 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				var got *item
+				assert.NoError(t, rt.Load(context.Background(), "a", &got, fetch))
+				assert.Equal(t, "shoes", got.Name)
+			}()
+		}
+
+		assert.Eventually(t, func() bool { return rt.Stats().Misses == 5 }, time.Second, time.Millisecond)
+		close(release)
+		wg.Wait()
+
+		assert.Equal(t, int32(1), calls.Load())
+	})
+
+	t.Run("stops waiting when the caller is cancelled", func(t *testing.T) {
+		rt := NewReadThrough(NewMemory(10), "items", time.Minute, 0)
+		release := make(chan struct{})
+		var fetchErr atomic.Value
+		fetch := func(ctx context.Context) (any, error) {
+			<-release
+			if err := ctx.Err(); err != nil {
+				fetchErr.Store(err)
+			}
+			return &item{Name: "shoes"}, nil
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan error)
+		go func() {
+			var got *item
+			done <- rt.Load(ctx, "a", &got, fetch)
+		}()
+		assert.Eventually(t, func() bool { return rt.Stats().Misses == 1 }, time.Second, time.Millisecond)
+
+		cancel()
+		assert.ErrorIs(t, <-done, context.Canceled)
+
+		// The shared load is not cancelled and still fills the cache.
+		close(release)
+		var got *item
+		assert.Eventually(t, func() bool {
+			return rt.Load(context.Background(), "a", &got, func(context.Context) (any, error) {
+				return nil, assert.AnError
+			}) == nil
+		}, time.Second, time.Millisecond)
+		assert.Equal(t, "shoes", got.Name)
+		assert.Nil(t, fetchErr.Load())
+	})
+
+	t.Run("bounds the shared load by the timeout", func(t *testing.T) {
+		rt := NewReadThrough(NewMemory(10), "items", time.Minute, 10*time.Millisecond)
+		fetch := func(ctx context.Context) (any, error) {
+			<-ctx.Done()
+			return nil, ctx.Err()
+		}
+
+		var got *item
+		err := rt.Load(context.Background(), "a", &got, fetch)
+
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+
+	t.Run("bounds entries by the expiry of their value", func(t *testing.T) {
+		server := newRESPServer(t, "")
+		backend := NewRedis(RedisOptions{Addr: server.addr()})
+		defer backend.Close()
+		rt := NewReadThrough(backend, "items", time.Minute, 0)
+		until := func(expires time.Time) func(context.Context) (any, time.Time, error) {
+			return func(context.Context) (any, time.Time, error) { return &item{Name: "shoes"}, expires, nil }
+		}
+
+		var got *item
+		require.NoError(t, rt.LoadUntil(context.Background(), "soon", &got, until(time.Now().Add(10*time.Second))))
+		require.NoError(t, rt.LoadUntil(context.Background(), "later", &got, until(time.Now().Add(time.Hour))))
+		require.NoError(t, rt.LoadUntil(context.Background(), "past", &got, until(time.Now().Add(-time.Second))))
+
+		sets := server.received("SET")
+		require.Len(t, sets, 2)
+		assert.Equal(t, "items:0:soon", sets[0][1])
+		ms, err := strconv.Atoi(sets[0][4])
+		require.NoError(t, err)
+		assert.InDelta(t, 10000, ms, 1000)
+		assert.Equal(t, []string{"SET", "items:0:later", `{"name":"shoes"}`, "PX", "60000"}, sets[1])
+		assert.Equal(t, "shoes", got.Name)
+	})
+
+	t.Run("works over the Redis protocol", func(t *testing.T) {
+		server := newRESPServer(t, "")
+		backend := NewRedis(RedisOptions{Addr: server.addr()})
+		defer backend.Close()
+
+		rt := NewReadThrough(backend, "items", time.Minute, 0)
+		calls := 0
+		fetch := func(context.Context) (any, error) {
+			calls++
+			return &item{Name: "shoes"}, nil
+		}
+
+		var got *item
+		require.NoError(t, rt.Load(context.Background(), "a", &got, fetch))
+		require.NoError(t, rt.Load(context.Background(), "a", &got, fetch))
+		rt.Invalidate(context.Background())
+		require.NoError(t, rt.Load(context.Background(), "a", &got, fetch))
+
+		assert.Equal(t, 2, calls)
+		assert.Equal(t, "shoes", got.Name)
+		assert.Equal(t, [][]string{
+			{"SET", "items:0:a", `{"name":"shoes"}`, "PX", "60000"},
+			{"SET", "items:1:a", `{"name":"shoes"}`, "PX", "60000"},
+		}, server.received("SET"))
+	})
+}
+
+func TestHandleStats(t *testing.T) {
+	rt := NewReadThrough(NewMemory(10), "items", time.Minute, 0)
+	handler := HandleStats(rt)
+	request := func(roles ...string) *http.Request {
+		r := httptest.NewRequest(http.MethodGet, "/admin/cache/stats", nil)
+		principal := &auth.Principal{Subject: "apikey:mhc_test", Roles: roles, Scopes: []string{auth.ScopeCatalogRead}}
+		return r.WithContext(auth.WithPrincipal(r.Context(), principal))
+	}
+
+	t.Run("returns the counters to viewers", func(t *testing.T) {
+		res := httptest.NewRecorder()
+		handler(res, request(auth.RoleViewer))
+
+		assert.Equal(t, http.StatusOK, res.Code)
+		assert.JSONEq(t, `{"items":{"hits":0,"misses":0,"errors":0}}`, res.Body.String())
+	})
+
+	t.Run("returns 403 without a role", func(t *testing.T) {
+		res := httptest.NewRecorder()
+		handler(res, request())
+
+		assert.Equal(t, http.StatusForbidden, res.Code)
+	})
+}
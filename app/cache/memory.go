@@ -0,0 +1,128 @@
+package cache
+
+import (
+	"container/list"
+	"context"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Memory is a size-bounded, least recently used Cache local to the process.
+// Counters created by Incr are kept outside the LRU so they are never evicted.
+type Memory struct {
+	mu       sync.Mutex
+	size     int
+	now      func() time.Time
+	order    *list.List
+	items    map[string]*list.Element
+	counters map[string]int64
+}
+
+type memoryEntry struct {
+	key     string
+	value   []byte
+	expires time.Time
+}
+
+var _ Cache = (*Memory)(nil)
+
+func NewMemory(size int) *Memory {
+	return &Memory{
+		size:     size,
+		now:      time.Now,
+		order:    list.New(),
+		items:    make(map[string]*list.Element),
+		counters: make(map[string]int64),
+	}
+}
+
+func (m *Memory) Get(_ context.Context, key string) ([]byte, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if n, ok := m.counters[key]; ok {
+		return []byte(strconv.FormatInt(n, 10)), nil
+	}
+
+	el, ok := m.items[key]
+	if !ok {
+		return nil, ErrNotFound
+	}
+
+	entry := el.Value.(*memoryEntry)
+	if !entry.expires.IsZero() && !m.now().Before(entry.expires) {
+		m.order.Remove(el)
+		delete(m.items, key)
+		return nil, ErrNotFound
+	}
+
+	m.order.MoveToFront(el)
+	return entry.value, nil
+}
+
+func (m *Memory) Set(_ context.Context, key string, value []byte, ttl time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.counters, key)
+
+	var expires time.Time
+	if ttl > 0 {
+		expires = m.now().Add(ttl)
+	}
+
+	if el, ok := m.items[key]; ok {
+		entry := el.Value.(*memoryEntry)
+		entry.value = value
+		entry.expires = expires
+		m.order.MoveToFront(el)
+		return nil
+	}
+
+	m.items[key] = m.order.PushFront(&memoryEntry{key: key, value: value, expires: expires})
+	for m.order.Len() > m.size {
+		oldest := m.order.Back()
+		m.order.Remove(oldest)
+		delete(m.items, oldest.Value.(*memoryEntry).key)
+	}
+	return nil
+}
+
+func (m *Memory) Delete(_ context.Context, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.counters, key)
+	if el, ok := m.items[key]; ok {
+		m.order.Remove(el)
+		delete(m.items, key)
+	}
+	return nil
+}
+
+func (m *Memory) Incr(_ context.Context, key string) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if el, ok := m.items[key]; ok {
+		n, err := strconv.ParseInt(string(el.Value.(*memoryEntry).value), 10, 64)
+		if err != nil {
+			return 0, err
+		}
+		m.order.Remove(el)
+		delete(m.items, key)
+		m.counters[key] = n
+	}
+
+	m.counters[key]++
+	return m.counters[key], nil
+}
+
+// Len returns the number of cached entries, counters excluded.
+func (m *Memory) Len() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.order.Len()
+}
@@ -0,0 +1,85 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemory(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("stores, reads and deletes values", func(t *testing.T) {
+		m := NewMemory(10)
+
+		_, err := m.Get(ctx, "key")
+		assert.ErrorIs(t, err, ErrNotFound)
+
+		require.NoError(t, m.Set(ctx, "key", []byte("value"), time.Minute))
+		got, err := m.Get(ctx, "key")
+		require.NoError(t, err)
+		assert.Equal(t, []byte("value"), got)
+
+		require.NoError(t, m.Delete(ctx, "key"))
+		_, err = m.Get(ctx, "key")
+		assert.ErrorIs(t, err, ErrNotFound)
+	})
+
+	t.Run("expires entries after their ttl", func(t *testing.T) {
+		m := NewMemory(10)
+		now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+		m.now = func() time.Time { return now }
+
+		require.NoError(t, m.Set(ctx, "short", []byte("1"), time.Minute))
+		require.NoError(t, m.Set(ctx, "forever", []byte("2"), 0))
+
+		now = now.Add(59 * time.Second)
+		_, err := m.Get(ctx, "short")
+		assert.NoError(t, err)
+
+		now = now.Add(time.Second)
+		_, err = m.Get(ctx, "short")
+		assert.ErrorIs(t, err, ErrNotFound)
+
+		now = now.Add(24 * time.Hour)
+		_, err = m.Get(ctx, "forever")
+		assert.NoError(t, err)
+	})
+
+	t.Run("evicts the least recently used entry", func(t *testing.T) {
+		m := NewMemory(2)
+
+		require.NoError(t, m.Set(ctx, "a", []byte("a"), 0))
+		require.NoError(t, m.Set(ctx, "b", []byte("b"), 0))
+		_, _ = m.Get(ctx, "a") // a is now the most recent
+		require.NoError(t, m.Set(ctx, "c", []byte("c"), 0))
+
+		_, err := m.Get(ctx, "b")
+		assert.ErrorIs(t, err, ErrNotFound)
+		_, err = m.Get(ctx, "a")
+		assert.NoError(t, err)
+		assert.Equal(t, 2, m.Len())
+	})
+
+	t.Run("never evicts counters", func(t *testing.T) {
+		m := NewMemory(1)
+
+		n, err := m.Incr(ctx, "generation")
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), n)
+
+		require.NoError(t, m.Set(ctx, "a", []byte("a"), 0))
+		require.NoError(t, m.Set(ctx, "b", []byte("b"), 0))
+
+		got, err := m.Get(ctx, "generation")
+		require.NoError(t, err)
+		assert.Equal(t, "1", string(got))
+
+		n, err = m.Incr(ctx, "generation")
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), n)
+	})
+}
@@ -0,0 +1,345 @@
+package cache
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RedisOptions configures a Redis cache.
+type RedisOptions struct {
+	Addr        string
+	Password    string
+	DB          int
+	PoolSize    int
+	DialTimeout time.Duration
+	IOTimeout   time.Duration
+}
+
+// Redis is a Cache backed by any server speaking the Redis protocol (RESP2).
+// Connections are dialled lazily and kept in a fixed-size pool; a connection
+// that fails mid-command is discarded rather than returned to the pool.
+type Redis struct {
+	opts  RedisOptions
+	slots chan struct{}
+	idle  chan *redisConn
+}
+
+type redisConn struct {
+	net.Conn
+	r *bufio.Reader
+	w *bufio.Writer
+}
+
+// redisError is an error reply sent by the server.
+type redisError string
+
+func (e redisError) Error() string {
+	return string(e)
+}
+
+var _ Cache = (*Redis)(nil)
+
+func NewRedis(opts RedisOptions) *Redis {
+	if opts.PoolSize <= 0 {
+		opts.PoolSize = 10
+	}
+	if opts.DialTimeout <= 0 {
+		opts.DialTimeout = time.Second
+	}
+	if opts.IOTimeout <= 0 {
+		opts.IOTimeout = time.Second
+	}
+	return &Redis{
+		opts:  opts,
+		slots: make(chan struct{}, opts.PoolSize),
+		idle:  make(chan *redisConn, opts.PoolSize),
+	}
+}
+
+func (c *Redis) Get(ctx context.Context, key string) ([]byte, error) {
+	reply, err := c.do(ctx, "GET", key)
+	if err != nil {
+		return nil, err
+	}
+	if reply == nil {
+		return nil, ErrNotFound
+	}
+	data, ok := reply.([]byte)
+	if !ok {
+		return nil, fmt.Errorf("redis: unexpected GET reply %T", reply)
+	}
+	return data, nil
+}
+
+func (c *Redis) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	args := []string{"SET", key, string(value)}
+	if ttl > 0 {
+		args = append(args, "PX", strconv.FormatInt(ttl.Milliseconds(), 10))
+	}
+	_, err := c.do(ctx, args...)
+	return err
+}
+
+func (c *Redis) Delete(ctx context.Context, key string) error {
+	_, err := c.do(ctx, "DEL", key)
+	return err
+}
+
+func (c *Redis) Incr(ctx context.Context, key string) (int64, error) {
+	reply, err := c.do(ctx, "INCR", key)
+	if err != nil {
+		return 0, err
+	}
+	n, ok := reply.(int64)
+	if !ok {
+		return 0, fmt.Errorf("redis: unexpected INCR reply %T", reply)
+	}
+	return n, nil
+}
+
+// Eval runs a Lua script atomically on the server and returns its reply:
+// []byte for strings, int64 for integers and []any for tables. The script is
+// called by its SHA1 digest with EVALSHA, and its source is only sent with
+// EVAL when the server has not cached it yet, e.g. after a restart.
+func (c *Redis) Eval(ctx context.Context, script string, keys []string, args ...string) (any, error) {
+	digest := sha1.Sum([]byte(script))
+	params := append([]string{strconv.Itoa(len(keys))}, keys...)
+	params = append(params, args...)
+
+	reply, err := c.do(ctx, append([]string{"EVALSHA", hex.EncodeToString(digest[:])}, params...)...)
+	var replyErr redisError
+	if errors.As(err, &replyErr) && strings.HasPrefix(string(replyErr), "NOSCRIPT") {
+		return c.do(ctx, append([]string{"EVAL", script}, params...)...)
+	}
+	return reply, err
+}
+
+// Ping checks that the server is reachable.
+func (c *Redis) Ping(ctx context.Context) error {
+	_, err := c.do(ctx, "PING")
+	return err
+}
+
+// Close closes the idle connections.
+func (c *Redis) Close() error {
+	for {
+		select {
+		case conn := <-c.idle:
+			conn.Close()
+			<-c.slots
+		default:
+			return nil
+		}
+	}
+}
+
+// do sends a command and reads its reply, giving up when ctx ends. A
+// connection interrupted mid-command is discarded, since the reply may still
+// be on its way.
+func (c *Redis) do(ctx context.Context, args ...string) (any, error) {
+	conn, err := c.acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	reply, err := conn.roundTrip(ctx, c.opts.IOTimeout, args)
+	var replyErr redisError
+	if err != nil && !errors.As(err, &replyErr) {
+		conn.Close()
+		<-c.slots
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, ctxErr
+		}
+		return nil, err
+	}
+
+	c.idle <- conn
+	return reply, err
+}
+
+func (c *Redis) acquire(ctx context.Context) (*redisConn, error) {
+	// Prefer an idle connection; select alone would pick at random between
+	// an idle connection and a free slot, dialling more often than needed.
+	select {
+	case conn := <-c.idle:
+		return conn, nil
+	default:
+	}
+
+	select {
+	case conn := <-c.idle:
+		return conn, nil
+	case c.slots <- struct{}{}:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	conn, err := c.dial(ctx)
+	if err != nil {
+		<-c.slots
+		return nil, err
+	}
+	return conn, nil
+}
+
+func (c *Redis) dial(ctx context.Context) (*redisConn, error) {
+	dialer := net.Dialer{Timeout: c.opts.DialTimeout}
+	nc, err := dialer.DialContext(ctx, "tcp", c.opts.Addr)
+	if err != nil {
+		return nil, err
+	}
+	conn := &redisConn{Conn: nc, r: bufio.NewReader(nc), w: bufio.NewWriter(nc)}
+
+	if c.opts.Password != "" {
+		if _, err := conn.roundTrip(ctx, c.opts.IOTimeout, []string{"AUTH", c.opts.Password}); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+	if c.opts.DB != 0 {
+		if _, err := conn.roundTrip(ctx, c.opts.IOTimeout, []string{"SELECT", strconv.Itoa(c.opts.DB)}); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+	return conn, nil
+}
+
+// roundTrip sends a command and reads its reply within timeout, or by the
+// deadline of ctx if it is earlier. Cancelling ctx interrupts the exchange.
+func (conn *redisConn) roundTrip(ctx context.Context, timeout time.Duration, args []string) (reply any, err error) {
+	deadline := time.Now().Add(timeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	if err := conn.SetDeadline(deadline); err != nil {
+		return nil, err
+	}
+	// Once the interruption has started the connection cannot be reused, as
+	// it may still move the deadline of the next command.
+	stop := context.AfterFunc(ctx, func() { conn.SetDeadline(time.Now()) })
+	defer func() {
+		if !stop() {
+			reply, err = nil, ctx.Err()
+		}
+	}()
+
+	if err := writeCommand(conn.w, args); err != nil {
+		return nil, err
+	}
+	if err := conn.w.Flush(); err != nil {
+		return nil, err
+	}
+	return readReply(conn.r)
+}
+
+func writeCommand(w *bufio.Writer, args []string) error {
+	fmt.Fprintf(w, "*%d\r\n", len(args))
+	for _, arg := range args {
+		fmt.Fprintf(w, "$%d\r\n", len(arg))
+		w.WriteString(arg)
+		if _, err := w.WriteString("\r\n"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Replies larger than these are refused before anything is allocated for
+// them, so a misbehaving server cannot exhaust the memory of the process.
+// They are well above the largest cached response.
+const (
+	maxBulkLen  = 64 << 20
+	maxArrayLen = 1 << 20
+)
+
+// readReply parses a single RESP2 reply. Bulk strings are returned as []byte,
+// integers as int64, simple strings as string, arrays as []any and nulls as nil.
+// An error reply is returned as a redisError.
+func readReply(r *bufio.Reader) (any, error) {
+	line, err := readLine(r)
+	if err != nil {
+		return nil, err
+	}
+	if len(line) == 0 {
+		return nil, errors.New("redis: empty reply")
+	}
+
+	switch line[0] {
+	case '+':
+		return line[1:], nil
+	case '-':
+		return nil, redisError(line[1:])
+	case ':':
+		return strconv.ParseInt(line[1:], 10, 64)
+	case '$':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return nil, err
+		}
+		if n < 0 {
+			return nil, nil
+		}
+		if n > maxBulkLen {
+			return nil, fmt.Errorf("redis: bulk string of %d bytes exceeds the limit of %d", n, maxBulkLen)
+		}
+		buf := make([]byte, n+2)
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return nil, err
+		}
+		return buf[:n], nil
+	case '*':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return nil, err
+		}
+		if n < 0 {
+			return nil, nil
+		}
+		if n > maxArrayLen {
+			return nil, fmt.Errorf("redis: array of %d elements exceeds the limit of %d", n, maxArrayLen)
+		}
+		// An error reply among the elements is returned only once the whole
+		// array is read, so the connection stays in sync and can be reused.
+		items := make([]any, n)
+		var replyErr error
+		for i := range items {
+			items[i], err = readReply(r)
+			var nested redisError
+			if errors.As(err, &nested) {
+				if replyErr == nil {
+					replyErr = nested
+				}
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+		}
+		if replyErr != nil {
+			return nil, replyErr
+		}
+		return items, nil
+	}
+	return nil, fmt.Errorf("redis: unknown reply type %q", line[0])
+}
+
+func readLine(r *bufio.Reader) (string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	if len(line) < 2 || line[len(line)-2] != '\r' {
+		return "", errors.New("redis: malformed reply line")
+	}
+	return line[:len(line)-2], nil
+}
@@ -0,0 +1,365 @@
+package cache
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// respServer is an in-process stand-in for a Redis server. It understands the
+// handful of commands the Redis cache sends and records every command it receives.
+type respServer struct {
+	listener net.Listener
+	password string
+
+	mu       sync.Mutex
+	data     map[string][]byte
+	ttls     map[string]time.Duration
+	scripts  map[string]bool
+	commands [][]string
+	conns    []net.Conn
+}
+
+func newRESPServer(t *testing.T, password string) *respServer {
+	t.Helper()
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	s := &respServer{
+		listener: l,
+		password: password,
+		data:     make(map[string][]byte),
+		ttls:     make(map[string]time.Duration),
+		scripts:  make(map[string]bool),
+	}
+	go s.serve()
+	t.Cleanup(func() {
+		l.Close()
+		s.dropConnections()
+	})
+	return s
+}
+
+func (s *respServer) addr() string {
+	return s.listener.Addr().String()
+}
+
+func (s *respServer) serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		s.mu.Lock()
+		s.conns = append(s.conns, conn)
+		s.mu.Unlock()
+		go s.handle(conn)
+	}
+}
+
+// dropConnections closes every accepted connection, as a server restart would.
+func (s *respServer) dropConnections() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, conn := range s.conns {
+		conn.Close()
+	}
+	s.conns = nil
+}
+
+func (s *respServer) handle(conn net.Conn) {
+	defer conn.Close()
+
+	r := bufio.NewReader(conn)
+	authed := s.password == ""
+	for {
+		reply, err := readReply(r)
+		if err != nil {
+			return
+		}
+		items, _ := reply.([]any)
+		args := make([]string, len(items))
+		for i, item := range items {
+			b, _ := item.([]byte)
+			args[i] = string(b)
+		}
+		if len(args) == 0 {
+			return
+		}
+
+		s.mu.Lock()
+		s.commands = append(s.commands, args)
+		var out string
+		switch cmd := strings.ToUpper(args[0]); {
+		case cmd == "AUTH":
+			if args[1] == s.password {
+				authed = true
+				out = "+OK\r\n"
+			} else {
+				out = "-WRONGPASS invalid password\r\n"
+			}
+		case !authed:
+			out = "-NOAUTH Authentication required.\r\n"
+		case cmd == "PING":
+			out = "+PONG\r\n"
+		case cmd == "SELECT":
+			out = "+OK\r\n"
+		case cmd == "GET":
+			if v, ok := s.data[args[1]]; ok {
+				out = "$" + strconv.Itoa(len(v)) + "\r\n" + string(v) + "\r\n"
+			} else {
+				out = "$-1\r\n"
+			}
+		case cmd == "SET":
+			s.data[args[1]] = []byte(args[2])
+			delete(s.ttls, args[1])
+			if len(args) == 5 && strings.ToUpper(args[3]) == "PX" {
+				ms, _ := strconv.Atoi(args[4])
+				s.ttls[args[1]] = time.Duration(ms) * time.Millisecond
+			}
+			out = "+OK\r\n"
+		case cmd == "DEL":
+			_, ok := s.data[args[1]]
+			delete(s.data, args[1])
+			if ok {
+				out = ":1\r\n"
+			} else {
+				out = ":0\r\n"
+			}
+		case cmd == "INCR":
+			n, err := strconv.ParseInt(string(s.data[args[1]]), 10, 64)
+			if _, ok := s.data[args[1]]; ok && err != nil {
+				out = "-ERR value is not an integer or out of range\r\n"
+				break
+			}
+			n++
+			s.data[args[1]] = []byte(strconv.FormatInt(n, 10))
+			out = ":" + strconv.FormatInt(n, 10) + "\r\n"
+		case cmd == "EVAL" || cmd == "EVALSHA":
+			// Scripts are not run; the reply echoes the number of keys and the first one.
+			digest := args[1]
+			if cmd == "EVAL" {
+				sum := sha1.Sum([]byte(args[1]))
+				digest = hex.EncodeToString(sum[:])
+				s.scripts[digest] = true
+			}
+			if !s.scripts[digest] {
+				out = "-NOSCRIPT No matching script. Please use EVAL.\r\n"
+				break
+			}
+			out = "*2\r\n:" + args[2] + "\r\n$" + strconv.Itoa(len(args[3])) + "\r\n" + args[3] + "\r\n"
+		default:
+			out = "-ERR unknown command '" + args[0] + "'\r\n"
+		}
+		s.mu.Unlock()
+
+		if _, err := conn.Write([]byte(out)); err != nil {
+			return
+		}
+	}
+}
+
+func (s *respServer) received(cmd string) [][]string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var out [][]string
+	for _, c := range s.commands {
+		if strings.EqualFold(c[0], cmd) {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+func TestRedis(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("stores, reads and deletes values", func(t *testing.T) {
+		server := newRESPServer(t, "")
+		c := NewRedis(RedisOptions{Addr: server.addr()})
+		defer c.Close()
+
+		_, err := c.Get(ctx, "missing")
+		assert.ErrorIs(t, err, ErrNotFound)
+
+		require.NoError(t, c.Set(ctx, "key", []byte("value\r\nwith CRLF"), 0))
+		got, err := c.Get(ctx, "key")
+		require.NoError(t, err)
+		assert.Equal(t, "value\r\nwith CRLF", string(got))
+
+		require.NoError(t, c.Delete(ctx, "key"))
+		_, err = c.Get(ctx, "key")
+		assert.ErrorIs(t, err, ErrNotFound)
+	})
+
+	t.Run("sends the ttl in milliseconds", func(t *testing.T) {
+		server := newRESPServer(t, "")
+		c := NewRedis(RedisOptions{Addr: server.addr()})
+		defer c.Close()
+
+		require.NoError(t, c.Set(ctx, "key", []byte("value"), 1500*time.Millisecond))
+
+		assert.Equal(t, [][]string{{"SET", "key", "value", "PX", "1500"}}, server.received("SET"))
+	})
+
+	t.Run("increments counters", func(t *testing.T) {
+		server := newRESPServer(t, "")
+		c := NewRedis(RedisOptions{Addr: server.addr()})
+		defer c.Close()
+
+		n, err := c.Incr(ctx, "counter")
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), n)
+
+		n, err = c.Incr(ctx, "counter")
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), n)
+
+		got, err := c.Get(ctx, "counter")
+		require.NoError(t, err)
+		assert.Equal(t, "2", string(got))
+	})
+
+	t.Run("sends scripts once, then calls them by digest", func(t *testing.T) {
+		server := newRESPServer(t, "")
+		c := NewRedis(RedisOptions{Addr: server.addr()})
+		defer c.Close()
+		script := "return {#KEYS, KEYS[1]}"
+		sum := sha1.Sum([]byte(script))
+		digest := hex.EncodeToString(sum[:])
+
+		for range 2 {
+			reply, err := c.Eval(context.Background(), script, []string{"bucket"}, "10", "60000")
+			require.NoError(t, err)
+			assert.Equal(t, []any{int64(1), []byte("bucket")}, reply)
+		}
+
+		assert.Equal(t, [][]string{{"EVAL", script, "1", "bucket", "10", "60000"}}, server.received("EVAL"))
+		assert.Equal(t, [][]string{
+			{"EVALSHA", digest, "1", "bucket", "10", "60000"},
+			{"EVALSHA", digest, "1", "bucket", "10", "60000"},
+		}, server.received("EVALSHA"))
+	})
+
+	t.Run("gives up when the context ends", func(t *testing.T) {
+		// The listener accepts connections but never replies.
+		l, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		defer l.Close()
+		c := NewRedis(RedisOptions{Addr: l.Addr().String(), PoolSize: 1, IOTimeout: time.Minute})
+		defer c.Close()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		time.AfterFunc(20*time.Millisecond, cancel)
+		start := time.Now()
+		_, err = c.Eval(ctx, "return 1", nil)
+
+		assert.ErrorIs(t, err, context.Canceled)
+		assert.Less(t, time.Since(start), time.Second)
+
+		ctx, cancel = context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+		start = time.Now()
+		_, err = c.Eval(ctx, "return 1", nil)
+
+		assert.Error(t, err)
+		assert.Less(t, time.Since(start), time.Second)
+	})
+
+	t.Run("authenticates and selects the database on connect", func(t *testing.T) {
+		server := newRESPServer(t, "secret")
+		c := NewRedis(RedisOptions{Addr: server.addr(), Password: "secret", DB: 2})
+		defer c.Close()
+
+		require.NoError(t, c.Ping(ctx))
+		require.NoError(t, c.Ping(ctx))
+
+		assert.Equal(t, [][]string{{"AUTH", "secret"}}, server.received("AUTH"))
+		assert.Equal(t, [][]string{{"SELECT", "2"}}, server.received("SELECT"))
+	})
+
+	t.Run("fails with a wrong password", func(t *testing.T) {
+		server := newRESPServer(t, "secret")
+		c := NewRedis(RedisOptions{Addr: server.addr(), Password: "wrong"})
+		defer c.Close()
+
+		assert.ErrorContains(t, c.Ping(ctx), "WRONGPASS")
+	})
+
+	t.Run("keeps the connection after an error reply", func(t *testing.T) {
+		server := newRESPServer(t, "")
+		c := NewRedis(RedisOptions{Addr: server.addr(), PoolSize: 1})
+		defer c.Close()
+
+		require.NoError(t, c.Set(ctx, "key", []byte("not a number"), 0))
+		_, err := c.Incr(ctx, "key")
+		assert.ErrorContains(t, err, "not an integer")
+
+		require.NoError(t, c.Ping(ctx))
+	})
+
+	t.Run("reconnects after the server drops the connection", func(t *testing.T) {
+		server := newRESPServer(t, "")
+		c := NewRedis(RedisOptions{Addr: server.addr(), PoolSize: 1})
+		defer c.Close()
+
+		require.NoError(t, c.Set(ctx, "key", []byte("value"), 0))
+		server.dropConnections()
+
+		// The pooled connection is dead: the first command fails and discards it.
+		_, err := c.Get(ctx, "key")
+		assert.Error(t, err)
+
+		got, err := c.Get(ctx, "key")
+		require.NoError(t, err)
+		assert.Equal(t, "value", string(got))
+	})
+
+	t.Run("fails when the server is unreachable", func(t *testing.T) {
+		l, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		addr := l.Addr().String()
+		l.Close()
+
+		c := NewRedis(RedisOptions{Addr: addr, DialTimeout: 100 * time.Millisecond})
+
+		_, err = c.Get(ctx, "key")
+		assert.Error(t, err)
+		assert.NotErrorIs(t, err, ErrNotFound)
+	})
+}
+
+func TestReadReply(t *testing.T) {
+	t.Run("reads the whole array around a nested error reply", func(t *testing.T) {
+		r := bufio.NewReader(strings.NewReader("*3\r\n+OK\r\n-ERR nested\r\n$3\r\nend\r\n+NEXT\r\n"))
+
+		_, err := readReply(r)
+		var replyErr redisError
+		require.ErrorAs(t, err, &replyErr)
+		assert.Equal(t, "ERR nested", replyErr.Error())
+
+		next, err := readReply(r)
+		require.NoError(t, err)
+		assert.Equal(t, "NEXT", next)
+	})
+	t.Run("refuses oversized replies", func(t *testing.T) {
+		for _, reply := range []string{"$1073741824\r\n", "*1073741824\r\n"} {
+			_, err := readReply(bufio.NewReader(strings.NewReader(reply)))
+
+			assert.ErrorContains(t, err, "exceeds the limit", reply)
+		}
+	})
+}
@@ -0,0 +1,134 @@
+package catalog
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/mytheresa/go-hiring-challenge/app/cache"
+	"github.com/mytheresa/go-hiring-challenge/models"
+)
+
+// CachedProductsRepository is a read-through cache in front of a ProductsRepository.
+// Product reads are served from the shared cache, and every write retires all
+// cached products, on this instance and on any other sharing the same backend.
+//
+// Entries are filled from the primary, since a replica lagging behind the
+// write that retired them would otherwise have its stale rows cached for the
+// whole TTL. They also expire at the next scheduled publication change, which
+// moves products between statuses without any write.
+type CachedProductsRepository struct {
+	next  ProductsRepository
+	cache *cache.ReadThrough
+}
+
+var _ ProductsRepository = (*CachedProductsRepository)(nil)
+
+type productList struct {
+	Products []models.Product `json:"products"`
+	Total    int64            `json:"total"`
+}
+
+func NewCachedProductsRepository(next ProductsRepository, c *cache.ReadThrough) *CachedProductsRepository {
+	return &CachedProductsRepository{
+		next:  next,
+		cache: c,
+	}
+}
+
+func (c *CachedProductsRepository) GetProductsByFilter(ctx context.Context, offset, limit int, filter models.ProductFilter) ([]models.Product, int64, error) {
+	key := fmt.Sprintf("list:%d:%d:%s", offset, limit, filterKey(filter))
+	var page productList
+	err := c.cache.LoadUntil(ctx, key, &page, func(ctx context.Context) (any, time.Time, error) {
+		return c.fill(ctx, func(ctx context.Context) (any, error) {
+			products, total, err := c.next.GetProductsByFilter(ctx, offset, limit, filter)
+			return productList{Products: products, Total: total}, err
+		})
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	return page.Products, page.Total, nil
+}
+
+func (c *CachedProductsRepository) GetProductByCode(ctx context.Context, code string, status models.ProductStatus) (*models.Product, error) {
+	key := fmt.Sprintf("code:%s:%s", status, code)
+	var product *models.Product
+	err := c.cache.LoadUntil(ctx, key, &product, func(ctx context.Context) (any, time.Time, error) {
+		return c.fill(ctx, func(ctx context.Context) (any, error) {
+			return c.next.GetProductByCode(ctx, code, status)
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return product, nil
+}
+
+// fill loads an entry from the primary and returns when it stops holding.
+// The schedule is read first, so a change falling between both queries only
+// shortens the life of the entry.
+func (c *CachedProductsRepository) fill(ctx context.Context, load func(ctx context.Context) (any, error)) (any, time.Time, error) {
+	ctx = models.WithPrimary(ctx)
+	next, err := c.next.NextScheduled(ctx, time.Now())
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	value, err := load(ctx)
+	return value, next, err
+}
+
+func (c *CachedProductsRepository) NextScheduled(ctx context.Context, after time.Time) (time.Time, error) {
+	return c.next.NextScheduled(ctx, after)
+}
+
+func (c *CachedProductsRepository) GetPriceHistory(ctx context.Context, code string, status models.ProductStatus, from, to *time.Time, offset, limit int) ([]models.PriceChange, int64, error) {
+	return c.next.GetPriceHistory(ctx, code, status, from, to, offset, limit)
+}
+
+func (c *CachedProductsRepository) UpdatePrices(ctx context.Context, code string, update models.PriceUpdate) (*models.Product, error) {
+	defer c.cache.Invalidate(ctx)
+	return c.next.UpdatePrices(ctx, code, update)
+}
+
+func (c *CachedProductsRepository) UpdateStatus(ctx context.Context, code string, update models.StatusUpdate) (*models.Product, error) {
+	defer c.cache.Invalidate(ctx)
+	return c.next.UpdateStatus(ctx, code, update)
+}
+
+func (c *CachedProductsRepository) Delete(ctx context.Context, code string, version uint) (*models.Product, error) {
+	defer c.cache.Invalidate(ctx)
+	return c.next.Delete(ctx, code, version)
+}
+
+func (c *CachedProductsRepository) Restore(ctx context.Context, code string) (*models.Product, error) {
+	defer c.cache.Invalidate(ctx)
+	return c.next.Restore(ctx, code)
+}
+
+func (c *CachedProductsRepository) DeleteVariant(ctx context.Context, code, sku string, version uint) (*models.Variant, error) {
+	defer c.cache.Invalidate(ctx)
+	return c.next.DeleteVariant(ctx, code, sku, version)
+}
+
+func (c *CachedProductsRepository) RestoreVariant(ctx context.Context, code, sku string) (*models.Variant, error) {
+	defer c.cache.Invalidate(ctx)
+	return c.next.RestoreVariant(ctx, code, sku)
+}
+
+func filterKey(f models.ProductFilter) string {
+	key := string(f.Status)
+	if f.CategoryID != nil {
+		key += fmt.Sprintf(":c=%d", *f.CategoryID)
+	}
+	if f.PriceLessThan != nil {
+		key += ":p=" + f.PriceLessThan.String()
+	}
+	if f.UpdatedSince != nil {
+		key += ":u=" + f.UpdatedSince.UTC().Format(time.RFC3339Nano)
+	}
+	if f.WithDeleted {
+		key += ":d"
+	}
+	return key
+}
@@ -0,0 +1,139 @@
+package catalog
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/mytheresa/go-hiring-challenge/app/cache"
+	"github.com/mytheresa/go-hiring-challenge/models"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"gorm.io/gorm"
+)
+
+// newTestProductsCache returns a cache in front of next, where no publication
+// change is scheduled.
+func newTestProductsCache(next *MockProductsRepository) *CachedProductsRepository {
+	next.On("NextScheduled", mock.Anything, mock.Anything).Return(time.Time{}, nil).Maybe()
+	return NewCachedProductsRepository(next, cache.NewReadThrough(cache.NewMemory(10), "catalog", time.Minute, 0))
+}
+
+func TestCachedProductsRepository(t *testing.T) {
+	product := &models.Product{
+		ID:       1,
+		Code:     "PROD001",
+		Price:    decimal.RequireFromString("10.99"),
+		Status:   models.StatusPublished,
+		Version:  2,
+		Category: &models.Category{Code: "shoes", Name: "Shoes"},
+		Variants: []models.Variant{{SKU: "SKU001", Price: decimal.RequireFromString("12.50")}},
+	}
+
+	t.Run("serves repeated reads from the cache", func(t *testing.T) {
+		mockRepo := new(MockProductsRepository)
+		mockRepo.On("GetProductByCode", mock.Anything, "PROD001", models.StatusPublished).Return(product, nil).Once()
+
+		cached := newTestProductsCache(mockRepo)
+
+		for range 3 {
+			got, err := cached.GetProductByCode(context.Background(), "PROD001", models.StatusPublished)
+			assert.NoError(t, err)
+			assert.Equal(t, "PROD001", got.Code)
+			assert.Equal(t, uint(2), got.Version)
+			assert.True(t, got.Price.Equal(product.Price))
+			assert.Equal(t, "Shoes", got.Category.Name)
+			assert.True(t, got.Variants[0].Price.Equal(product.Variants[0].Price))
+		}
+
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("keys listings by pagination and filter", func(t *testing.T) {
+		mockRepo := new(MockProductsRepository)
+		categoryID := uint(2)
+		published := models.ProductFilter{Status: models.StatusPublished}
+		shoes := models.ProductFilter{Status: models.StatusPublished, CategoryID: &categoryID}
+
+		mockRepo.On("GetProductsByFilter", mock.Anything, 0, 10, published).Return([]models.Product{*product}, int64(8), nil).Once()
+		mockRepo.On("GetProductsByFilter", mock.Anything, 0, 10, shoes).Return([]models.Product{}, int64(0), nil).Once()
+		mockRepo.On("GetProductsByFilter", mock.Anything, 10, 10, published).Return([]models.Product{}, int64(8), nil).Once()
+
+		cached := newTestProductsCache(mockRepo)
+
+		for range 2 {
+			products, total, err := cached.GetProductsByFilter(context.Background(), 0, 10, published)
+			assert.NoError(t, err)
+			assert.Len(t, products, 1)
+			assert.Equal(t, int64(8), total)
+
+			_, total, _ = cached.GetProductsByFilter(context.Background(), 0, 10, shoes)
+			assert.Equal(t, int64(0), total)
+
+			_, _, _ = cached.GetProductsByFilter(context.Background(), 10, 10, published)
+		}
+
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("does not cache errors", func(t *testing.T) {
+		mockRepo := new(MockProductsRepository)
+		mockRepo.On("GetProductByCode", mock.Anything, "INVALID", models.StatusPublished).Return(nil, gorm.ErrRecordNotFound).Twice()
+
+		cached := newTestProductsCache(mockRepo)
+
+		for range 2 {
+			_, err := cached.GetProductByCode(context.Background(), "INVALID", models.StatusPublished)
+			assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+		}
+
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("invalidates on writes", func(t *testing.T) {
+		mockRepo := new(MockProductsRepository)
+		mockRepo.On("GetProductByCode", mock.Anything, "PROD001", models.StatusPublished).Return(product, nil).Twice()
+		mockRepo.On("Delete", mock.Anything, "PROD001", uint(1)).Return(product, nil)
+
+		cached := newTestProductsCache(mockRepo)
+
+		_, _ = cached.GetProductByCode(context.Background(), "PROD001", models.StatusPublished)
+		_, err := cached.Delete(context.Background(), "PROD001", 1)
+		assert.NoError(t, err)
+		_, _ = cached.GetProductByCode(context.Background(), "PROD001", models.StatusPublished)
+
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("passes writes through", func(t *testing.T) {
+		mockRepo := new(MockProductsRepository)
+		mockRepo.On("UpdateStatus", mock.Anything, "PROD001", mock.Anything).Return(product, nil)
+
+		cached := newTestProductsCache(mockRepo)
+
+		got, err := cached.UpdateStatus(context.Background(), "PROD001", models.StatusUpdate{Version: 1, Status: models.StatusArchived})
+
+		assert.NoError(t, err)
+		assert.Equal(t, product, got)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("expires entries at the next scheduled publication change", func(t *testing.T) {
+		mockRepo := new(MockProductsRepository)
+		mockRepo.On("NextScheduled", mock.Anything, mock.Anything).Return(time.Now().Add(50*time.Millisecond), nil)
+		mockRepo.On("GetProductByCode", mock.Anything, "PROD001", models.StatusPublished).Return(product, nil).Twice()
+
+		cached := newTestProductsCache(mockRepo)
+
+		for range 2 {
+			_, err := cached.GetProductByCode(context.Background(), "PROD001", models.StatusPublished)
+			assert.NoError(t, err)
+		}
+		time.Sleep(60 * time.Millisecond)
+		_, err := cached.GetProductByCode(context.Background(), "PROD001", models.StatusPublished)
+
+		assert.NoError(t, err)
+		mockRepo.AssertExpectations(t)
+	})
+}
@@ -0,0 +1,117 @@
+package catalog
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/mytheresa/go-hiring-challenge/app/api"
+	"github.com/mytheresa/go-hiring-challenge/app/auth"
+	"github.com/mytheresa/go-hiring-challenge/models"
+	"gorm.io/gorm"
+)
+
+// HandleDelete soft-deletes a product and its variants.
+// The caller must send the product version it last read.
+func (h *CatalogHandler) HandleDelete(w http.ResponseWriter, r *http.Request) {
+	if !auth.Authorize(w, r, auth.PermissionDeleteProducts) {
+		return
+	}
+
+	p, ok := api.RequireVersion(w, r, nil)
+	if !ok {
+		return
+	}
+
+	h.writeProduct(w, r, "Product not found", p, func() (*models.Product, error) {
+		return h.repo.Delete(r.Context(), r.PathValue("code"), p.Version)
+	})
+}
+
+// HandleRestore restores a soft-deleted product and its variants.
+func (h *CatalogHandler) HandleRestore(w http.ResponseWriter, r *http.Request) {
+	if !auth.Authorize(w, r, auth.PermissionDeleteProducts) {
+		return
+	}
+
+	h.writeProduct(w, r, "Deleted product not found", api.Precondition{}, func() (*models.Product, error) {
+		return h.repo.Restore(r.Context(), r.PathValue("code"))
+	})
+}
+
+// HandleDeleteVariant soft-deletes a single variant.
+// The caller must send the variant version it last read.
+func (h *CatalogHandler) HandleDeleteVariant(w http.ResponseWriter, r *http.Request) {
+	if !auth.Authorize(w, r, auth.PermissionDeleteProducts) {
+		return
+	}
+
+	p, ok := api.RequireVersion(w, r, nil)
+	if !ok {
+		return
+	}
+
+	writeVariant(w, r, "Variant not found", p, func() (*models.Variant, error) {
+		return h.repo.DeleteVariant(r.Context(), r.PathValue("code"), r.PathValue("sku"), p.Version)
+	})
+}
+
+// HandleRestoreVariant restores a soft-deleted variant.
+func (h *CatalogHandler) HandleRestoreVariant(w http.ResponseWriter, r *http.Request) {
+	if !auth.Authorize(w, r, auth.PermissionDeleteProducts) {
+		return
+	}
+
+	writeVariant(w, r, "Deleted variant not found", api.Precondition{}, func() (*models.Variant, error) {
+		return h.repo.RestoreVariant(r.Context(), r.PathValue("code"), r.PathValue("sku"))
+	})
+}
+
+func (h *CatalogHandler) writeProduct(w http.ResponseWriter, r *http.Request, notFound string, p api.Precondition, op func() (*models.Product, error)) {
+	product, err := op()
+	if err != nil {
+		writeRepositoryError(w, r, err, notFound, p)
+		return
+	}
+
+	pricer, err := h.newPricer(r.Context())
+	if err != nil {
+		api.InternalError(w, r, err)
+		return
+	}
+
+	api.VersionedResponse(w, product.Version, mapProductToDetailResponse(*product, pricer))
+}
+
+func writeVariant(w http.ResponseWriter, r *http.Request, notFound string, p api.Precondition, op func() (*models.Variant, error)) {
+	variant, err := op()
+	if err != nil {
+		writeRepositoryError(w, r, err, notFound, p)
+		return
+	}
+
+	resp := VariantResponse{
+		Name:      variant.Name,
+		SKU:       variant.SKU,
+		Version:   variant.Version,
+		CreatedAt: variant.CreatedAt,
+		UpdatedAt: variant.UpdatedAt,
+	}
+	if !variant.Price.IsZero() {
+		price := variant.Price.InexactFloat64()
+		resp.Price = &price
+	}
+
+	api.VersionedResponse(w, variant.Version, resp)
+}
+
+// writeRepositoryError maps the errors shared by the catalog write operations to their responses.
+func writeRepositoryError(w http.ResponseWriter, r *http.Request, err error, notFound string, p api.Precondition) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		api.ErrorResponse(w, http.StatusNotFound, notFound)
+	case errors.Is(err, models.ErrVersionConflict):
+		api.VersionConflictResponse(w, p)
+	default:
+		api.InternalError(w, r, err)
+	}
+}
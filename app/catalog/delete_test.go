@@ -0,0 +1,169 @@
+package catalog
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/mytheresa/go-hiring-challenge/app/auth"
+	"github.com/mytheresa/go-hiring-challenge/models"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"gorm.io/gorm"
+)
+
+func TestCatalogHandleDelete(t *testing.T) {
+	t.Run("soft-deletes a product", func(t *testing.T) {
+		mockRepo := new(MockProductsRepository)
+		mockRepo.On("Delete", mock.Anything, "PROD001", uint(4)).Return(&models.Product{ID: 1, Code: "PROD001", Price: decimal.NewFromFloat(10.99), Version: 5}, nil)
+
+		handler := NewCatalogHandler(mockRepo, noPromotions())
+		recorder := httptest.NewRecorder()
+		request := httptest.NewRequest("DELETE", "/admin/catalog/PROD001", nil)
+		request.SetPathValue("code", "PROD001")
+		request.Header.Set("If-Match", `"4"`)
+
+		handler.HandleDelete(recorder, asAdmin(request))
+
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		assert.Contains(t, recorder.Body.String(), `"code":"PROD001","version":5`)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("returns 412 when the product changed since it was read", func(t *testing.T) {
+		mockRepo := new(MockProductsRepository)
+		mockRepo.On("Delete", mock.Anything, "PROD001", uint(4)).Return(nil, models.ErrVersionConflict)
+
+		handler := NewCatalogHandler(mockRepo, noPromotions())
+		recorder := httptest.NewRecorder()
+		request := httptest.NewRequest("DELETE", "/admin/catalog/PROD001", nil)
+		request.SetPathValue("code", "PROD001")
+		request.Header.Set("If-Match", `"4"`)
+
+		handler.HandleDelete(recorder, asAdmin(request))
+
+		assert.Equal(t, http.StatusPreconditionFailed, recorder.Code)
+	})
+
+	t.Run("returns 428 without If-Match", func(t *testing.T) {
+		mockRepo := new(MockProductsRepository)
+
+		handler := NewCatalogHandler(mockRepo, noPromotions())
+		recorder := httptest.NewRecorder()
+		request := httptest.NewRequest("DELETE", "/admin/catalog/PROD001", nil)
+		request.SetPathValue("code", "PROD001")
+
+		handler.HandleDelete(recorder, asAdmin(request))
+
+		assert.Equal(t, http.StatusPreconditionRequired, recorder.Code)
+	})
+
+	t.Run("returns 404 when product not found", func(t *testing.T) {
+		mockRepo := new(MockProductsRepository)
+		mockRepo.On("Delete", mock.Anything, "INVALID", uint(1)).Return(nil, gorm.ErrRecordNotFound)
+
+		handler := NewCatalogHandler(mockRepo, noPromotions())
+		recorder := httptest.NewRecorder()
+		request := httptest.NewRequest("DELETE", "/admin/catalog/INVALID", nil)
+		request.SetPathValue("code", "INVALID")
+		request.Header.Set("If-Match", `"1"`)
+
+		handler.HandleDelete(recorder, asAdmin(request))
+
+		assert.Equal(t, http.StatusNotFound, recorder.Code)
+		assert.Contains(t, recorder.Body.String(), "Product not found")
+	})
+
+	t.Run("forbids editors to delete products", func(t *testing.T) {
+		mockRepo := new(MockProductsRepository)
+
+		handler := NewCatalogHandler(mockRepo, noPromotions())
+		recorder := httptest.NewRecorder()
+		request := httptest.NewRequest("DELETE", "/admin/catalog/PROD001", nil)
+		request.SetPathValue("code", "PROD001")
+		request.Header.Set("If-Match", `"4"`)
+
+		handler.HandleDelete(recorder, withRole(request, auth.RoleEditor))
+
+		assert.Equal(t, http.StatusForbidden, recorder.Code)
+		assert.Contains(t, recorder.Body.String(), `"permission":"products.delete"`)
+		mockRepo.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestCatalogHandleRestore(t *testing.T) {
+	t.Run("restores a deleted product", func(t *testing.T) {
+		mockRepo := new(MockProductsRepository)
+		mockRepo.On("Restore", mock.Anything, "PROD001").Return(&models.Product{
+			ID:    1,
+			Code:  "PROD001",
+			Price: decimal.NewFromFloat(10.99),
+			Variants: []models.Variant{
+				{ID: 1, ProductID: 1, Name: "Variant A", SKU: "SKU001A"},
+			},
+		}, nil)
+
+		handler := NewCatalogHandler(mockRepo, noPromotions())
+		recorder := httptest.NewRecorder()
+		request := httptest.NewRequest("POST", "/admin/catalog/PROD001/restore", nil)
+		request.SetPathValue("code", "PROD001")
+
+		handler.HandleRestore(recorder, asAdmin(request))
+
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		assert.Contains(t, recorder.Body.String(), "SKU001A")
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("returns 404 when product is not deleted", func(t *testing.T) {
+		mockRepo := new(MockProductsRepository)
+		mockRepo.On("Restore", mock.Anything, "PROD001").Return(nil, gorm.ErrRecordNotFound)
+
+		handler := NewCatalogHandler(mockRepo, noPromotions())
+		recorder := httptest.NewRecorder()
+		request := httptest.NewRequest("POST", "/admin/catalog/PROD001/restore", nil)
+		request.SetPathValue("code", "PROD001")
+
+		handler.HandleRestore(recorder, asAdmin(request))
+
+		assert.Equal(t, http.StatusNotFound, recorder.Code)
+		assert.Contains(t, recorder.Body.String(), "Deleted product not found")
+	})
+}
+
+func TestCatalogHandleVariantDeletion(t *testing.T) {
+	t.Run("soft-deletes a variant", func(t *testing.T) {
+		mockRepo := new(MockProductsRepository)
+		mockRepo.On("DeleteVariant", mock.Anything, "PROD001", "SKU001A", uint(1)).Return(&models.Variant{ID: 1, Name: "Variant A", SKU: "SKU001A", Price: decimal.NewFromFloat(11.99)}, nil)
+
+		handler := NewCatalogHandler(mockRepo, noPromotions())
+		recorder := httptest.NewRecorder()
+		request := httptest.NewRequest("DELETE", "/admin/catalog/PROD001/variants/SKU001A", nil)
+		request.SetPathValue("code", "PROD001")
+		request.SetPathValue("sku", "SKU001A")
+		request.Header.Set("If-Match", `"1"`)
+
+		handler.HandleDeleteVariant(recorder, asAdmin(request))
+
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		assert.Contains(t, recorder.Body.String(), `"sku":"SKU001A"`)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("returns 404 when variant not found", func(t *testing.T) {
+		mockRepo := new(MockProductsRepository)
+		mockRepo.On("RestoreVariant", mock.Anything, "PROD001", "SKU999").Return(nil, gorm.ErrRecordNotFound)
+
+		handler := NewCatalogHandler(mockRepo, noPromotions())
+		recorder := httptest.NewRecorder()
+		request := httptest.NewRequest("POST", "/admin/catalog/PROD001/variants/SKU999/restore", nil)
+		request.SetPathValue("code", "PROD001")
+		request.SetPathValue("sku", "SKU999")
+
+		handler.HandleRestoreVariant(recorder, asAdmin(request))
+
+		assert.Equal(t, http.StatusNotFound, recorder.Code)
+		assert.Contains(t, recorder.Body.String(), "Deleted variant not found")
+	})
+}
@@ -1,35 +1,80 @@
 package catalog
 
 import (
+	"context"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/mytheresa/go-hiring-challenge/app/api"
+	"github.com/mytheresa/go-hiring-challenge/app/auth"
+	"github.com/mytheresa/go-hiring-challenge/app/promotions"
 	"github.com/mytheresa/go-hiring-challenge/models"
 	"github.com/shopspring/decimal"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // ProductsRepository defines the interface for accessing product data
 type ProductsRepository interface {
-	GetProductsByFilter(offset, limit int, categoryID *uint, priceLessThan *decimal.Decimal) ([]models.Product, int64, error)
-	GetProductByCode(code string) (*models.Product, error)
+	GetProductsByFilter(ctx context.Context, offset, limit int, filter models.ProductFilter) ([]models.Product, int64, error)
+	GetProductByCode(ctx context.Context, code string, status models.ProductStatus) (*models.Product, error)
+	UpdateStatus(ctx context.Context, code string, update models.StatusUpdate) (*models.Product, error)
+	Delete(ctx context.Context, code string, version uint) (*models.Product, error)
+	Restore(ctx context.Context, code string) (*models.Product, error)
+	DeleteVariant(ctx context.Context, code, sku string, version uint) (*models.Variant, error)
+	RestoreVariant(ctx context.Context, code, sku string) (*models.Variant, error)
+	UpdatePrices(ctx context.Context, code string, update models.PriceUpdate) (*models.Product, error)
+	GetPriceHistory(ctx context.Context, code string, status models.ProductStatus, from, to *time.Time, offset, limit int) ([]models.PriceChange, int64, error)
+	NextScheduled(ctx context.Context, after time.Time) (time.Time, error)
+}
+
+// PromotionsRepository defines the interface for accessing running promotions
+type PromotionsRepository interface {
+	GetActive(ctx context.Context, at time.Time) ([]models.Promotion, error)
+	LastEnded(ctx context.Context, at time.Time) (time.Time, error)
+}
+
+// tracer records the phases of the catalog reads: loading the products, the
+// running promotions and encoding the response.
+var tracer = otel.Tracer("github.com/mytheresa/go-hiring-challenge/app/catalog")
+
+// endSpan ends span, marking it failed when err is set.
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
 }
 
 type CatalogHandler struct {
-	repo ProductsRepository
+	repo       ProductsRepository
+	promotions PromotionsRepository
+	now        func() time.Time
 }
 
-func NewCatalogHandler(r ProductsRepository) *CatalogHandler {
+func NewCatalogHandler(r ProductsRepository, p PromotionsRepository) *CatalogHandler {
 	return &CatalogHandler{
-		repo: r,
+		repo:       r,
+		promotions: p,
+		now:        time.Now,
 	}
 }
 
 type ProductResponse struct {
-	Code     string                `json:"code"`
-	Price    float64               `json:"price"`
-	Category *CategoryResponse     `json:"category,omitempty"`
-	Variants []VariantResponse     `json:"variants,omitempty"`
+	Code          string            `json:"code"`
+	Version       uint              `json:"version"`
+	Status        string            `json:"status"`
+	OriginalPrice float64           `json:"original_price"`
+	Price         float64           `json:"price"`
+	Discount      float64           `json:"discount"`
+	Category      *CategoryResponse `json:"category,omitempty"`
+	Variants      []VariantResponse `json:"variants,omitempty"`
+	CreatedAt     time.Time         `json:"created_at"`
+	UpdatedAt     time.Time         `json:"updated_at"`
+	DeletedAt     *time.Time        `json:"deleted_at,omitempty"`
 }
 
 type CategoryResponse struct {
@@ -38,24 +83,73 @@ type CategoryResponse struct {
 }
 
 type VariantResponse struct {
-	Name  string   `json:"name"`
-	SKU   string   `json:"sku"`
-	Price *float64 `json:"price,omitempty"`
+	Name          string    `json:"name"`
+	SKU           string    `json:"sku"`
+	Version       uint      `json:"version"`
+	OriginalPrice *float64  `json:"original_price,omitempty"`
+	Price         *float64  `json:"price,omitempty"`
+	Discount      *float64  `json:"discount,omitempty"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
 }
 
 type CatalogListResponse struct {
 	Products []ProductResponse `json:"products"`
-	Total    int64            `json:"total"`
+	Total    int64             `json:"total"`
 }
 
 type ProductDetailResponse struct {
-	Code     string           `json:"code"`
-	Price    float64          `json:"price"`
-	Category CategoryResponse `json:"category"`
-	Variants []VariantResponse `json:"variants"`
+	Code          string            `json:"code"`
+	Version       uint              `json:"version"`
+	Status        string            `json:"status"`
+	PublishAt     *time.Time        `json:"publish_at,omitempty"`
+	UnpublishAt   *time.Time        `json:"unpublish_at,omitempty"`
+	OriginalPrice float64           `json:"original_price"`
+	Price         float64           `json:"price"`
+	Discount      float64           `json:"discount"`
+	Category      CategoryResponse  `json:"category"`
+	Variants      []VariantResponse `json:"variants"`
+	CreatedAt     time.Time         `json:"created_at"`
+	UpdatedAt     time.Time         `json:"updated_at"`
 }
 
+// HandleGet returns the published products.
 func (h *CatalogHandler) HandleGet(w http.ResponseWriter, r *http.Request) {
+	h.list(w, r, models.StatusPublished, false)
+}
+
+// HandleAdminGet returns products in any status, optionally filtered by ?status=.
+// With ?updated_since= it also returns the products deleted since, marked by
+// deleted_at, so a sync can remove them.
+func (h *CatalogHandler) HandleAdminGet(w http.ResponseWriter, r *http.Request) {
+	if !auth.Authorize(w, r, auth.PermissionViewCatalog) {
+		return
+	}
+
+	status := models.ProductStatus(r.URL.Query().Get("status"))
+	if status != "" && !status.Valid() {
+		api.ErrorResponse(w, http.StatusBadRequest, "Invalid status")
+		return
+	}
+
+	h.list(w, r, status, true)
+}
+
+// HandleGetByCode returns a published product.
+func (h *CatalogHandler) HandleGetByCode(w http.ResponseWriter, r *http.Request) {
+	h.get(w, r, models.StatusPublished)
+}
+
+// HandleAdminGetByCode returns a product in any status.
+func (h *CatalogHandler) HandleAdminGetByCode(w http.ResponseWriter, r *http.Request) {
+	if !auth.Authorize(w, r, auth.PermissionViewCatalog) {
+		return
+	}
+
+	h.get(w, r, "")
+}
+
+func (h *CatalogHandler) list(w http.ResponseWriter, r *http.Request, status models.ProductStatus, withDeleted bool) {
 	// Parse query parameters
 	offset, err := strconv.Atoi(r.URL.Query().Get("offset"))
 	if err != nil || offset < 0 {
@@ -68,34 +162,51 @@ func (h *CatalogHandler) HandleGet(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Parse optional filters
-	var categoryID *uint
+	filter := models.ProductFilter{Status: status}
 	if categoryCode := r.URL.Query().Get("category"); categoryCode != "" {
 		// We'll validate the category code in the repository query
 		// For now, we pass it as a query parameter filter
 		categoryIDVal, err := parseUintFromString(categoryCode)
 		if err == nil {
-			categoryID = &categoryIDVal
+			filter.CategoryID = &categoryIDVal
 		}
 	}
 
-	var priceLessThan *decimal.Decimal
 	if priceStr := r.URL.Query().Get("price_less_than"); priceStr != "" {
 		price, err := decimal.NewFromString(priceStr)
 		if err == nil {
-			priceLessThan = &price
+			filter.PriceLessThan = &price
 		}
 	}
 
-	products, total, err := h.repo.GetProductsByFilter(offset, limit, categoryID, priceLessThan)
+	filter.UpdatedSince, err = parseTimeParam(r, "updated_since")
+	if err != nil {
+		api.ErrorResponse(w, http.StatusBadRequest, "Invalid updated_since, expected RFC 3339 timestamp")
+		return
+	}
+	filter.WithDeleted = withDeleted && filter.UpdatedSince != nil
+
+	ctx, span := tracer.Start(r.Context(), "catalog.load")
+	products, total, err := h.repo.GetProductsByFilter(ctx, offset, limit, filter)
+	endSpan(span, err)
 	if err != nil {
-		api.ErrorResponse(w, http.StatusInternalServerError, err.Error())
+		api.InternalError(w, r, err)
 		return
 	}
 
+	pricer, err := h.newPricer(r.Context())
+	if err != nil {
+		api.InternalError(w, r, err)
+		return
+	}
+
+	_, span = tracer.Start(r.Context(), "catalog.encode")
+	defer span.End()
+
 	// Map products to response
 	productResponses := make([]ProductResponse, len(products))
 	for i, p := range products {
-		productResponses[i] = mapProductToResponse(p, false)
+		productResponses[i] = mapProductToResponse(p, false, pricer)
 	}
 
 	response := CatalogListResponse{
@@ -103,46 +214,113 @@ func (h *CatalogHandler) HandleGet(w http.ResponseWriter, r *http.Request) {
 		Total:    total,
 	}
 
+	api.SetLastModified(w, lastModified(products, pricer))
 	api.OKResponse(w, response)
 }
 
-func (h *CatalogHandler) HandleGetByCode(w http.ResponseWriter, r *http.Request) {
+func (h *CatalogHandler) get(w http.ResponseWriter, r *http.Request, status models.ProductStatus) {
 	code := r.PathValue("code")
 
-	product, err := h.repo.GetProductByCode(code)
+	ctx, span := tracer.Start(r.Context(), "catalog.load")
+	product, err := h.repo.GetProductByCode(ctx, code, status)
+	endSpan(span, err)
 	if err != nil {
 		api.ErrorResponse(w, http.StatusNotFound, "Product not found")
 		return
 	}
 
-	// Map to detail response
-	variantResponses := make([]VariantResponse, len(product.Variants))
-	for i, v := range product.Variants {
-		variantResponses[i] = mapVariantToResponse(v, &product.Price)
+	pricer, err := h.newPricer(r.Context())
+	if err != nil {
+		api.InternalError(w, r, err)
+		return
 	}
 
-	var categoryResp CategoryResponse
-	if product.Category != nil {
-		categoryResp = CategoryResponse{
-			Code: product.Category.Code,
-			Name: product.Category.Name,
+	_, span = tracer.Start(r.Context(), "catalog.encode")
+	defer span.End()
+
+	api.SetLastModified(w, lastModified([]models.Product{*product}, pricer))
+	api.VersionedResponse(w, product.Version, mapProductToDetailResponse(*product, pricer))
+}
+
+// pricer evaluates the promotions running at a fixed instant,
+// so every price in a single response is computed against the same rules.
+type pricer struct {
+	promos []models.Promotion
+	at     time.Time
+	// lastEnded is when the last promotion ended, raising its prices again.
+	lastEnded time.Time
+}
+
+func (h *CatalogHandler) newPricer(ctx context.Context) (pricer, error) {
+	ctx, span := tracer.Start(ctx, "catalog.promotions")
+	at := h.now()
+	promos, err := h.promotions.GetActive(ctx, at)
+	if err != nil {
+		endSpan(span, err)
+		return pricer{}, err
+	}
+	lastEnded, err := h.promotions.LastEnded(ctx, at)
+	endSpan(span, err)
+	if err != nil {
+		return pricer{}, err
+	}
+	return pricer{promos: promos, at: at, lastEnded: lastEnded}, nil
+}
+
+func (pr pricer) price(base decimal.Decimal, p models.Product, sku string) promotions.Price {
+	target := promotions.Target{
+		ProductCode: p.Code,
+		SKU:         sku,
+	}
+	if p.Category != nil {
+		target.CategoryCode = p.Category.Code
+	}
+	return promotions.Evaluate(base, target, pr.promos, pr.at)
+}
+
+// lastModified returns the latest change among the products, their variants and
+// categories, the running promotions that may affect their prices and the end
+// of the last promotion that stopped affecting them.
+func lastModified(products []models.Product, pr pricer) time.Time {
+	var latest time.Time
+	track := func(t time.Time) {
+		if t.After(latest) {
+			latest = t
 		}
 	}
 
-	response := ProductDetailResponse{
-		Code:     product.Code,
-		Price:    product.Price.InexactFloat64(),
-		Category: categoryResp,
-		Variants: variantResponses,
+	for _, p := range products {
+		track(p.UpdatedAt)
+		if p.Category != nil {
+			track(p.Category.UpdatedAt)
+		}
+		for _, v := range p.Variants {
+			track(v.UpdatedAt)
+		}
 	}
+	for _, promo := range pr.promos {
+		track(promo.StartsAt)
+	}
+	track(pr.lastEnded)
 
-	api.OKResponse(w, response)
+	return latest
 }
 
-func mapProductToResponse(p models.Product, includeVariants bool) ProductResponse {
+func mapProductToResponse(p models.Product, includeVariants bool, pr pricer) ProductResponse {
+	price := pr.price(p.Price, p, "")
+
 	resp := ProductResponse{
-		Code:  p.Code,
-		Price: p.Price.InexactFloat64(),
+		Code:          p.Code,
+		Version:       p.Version,
+		Status:        string(p.EffectiveStatus(pr.at)),
+		OriginalPrice: price.Original.InexactFloat64(),
+		Price:         price.Final.InexactFloat64(),
+		Discount:      price.Discount.InexactFloat64(),
+		CreatedAt:     p.CreatedAt,
+		UpdatedAt:     p.UpdatedAt,
+	}
+	if p.DeletedAt.Valid {
+		resp.DeletedAt = &p.DeletedAt.Time
 	}
 
 	if p.Category != nil {
@@ -155,28 +333,68 @@ func mapProductToResponse(p models.Product, includeVariants bool) ProductRespons
 	if includeVariants {
 		resp.Variants = make([]VariantResponse, len(p.Variants))
 		for i, v := range p.Variants {
-			resp.Variants[i] = mapVariantToResponse(v, &p.Price)
+			resp.Variants[i] = mapVariantToResponse(v, p, pr)
 		}
 	}
 
 	return resp
 }
 
-func mapVariantToResponse(v models.Variant, productPrice *decimal.Decimal) VariantResponse {
+func mapProductToDetailResponse(p models.Product, pr pricer) ProductDetailResponse {
+	variantResponses := make([]VariantResponse, len(p.Variants))
+	for i, v := range p.Variants {
+		variantResponses[i] = mapVariantToResponse(v, p, pr)
+	}
+
+	var categoryResp CategoryResponse
+	if p.Category != nil {
+		categoryResp = CategoryResponse{
+			Code: p.Category.Code,
+			Name: p.Category.Name,
+		}
+	}
+
+	price := pr.price(p.Price, p, "")
+
+	return ProductDetailResponse{
+		Code:          p.Code,
+		Version:       p.Version,
+		Status:        string(p.EffectiveStatus(pr.at)),
+		PublishAt:     p.PublishAt,
+		UnpublishAt:   p.UnpublishAt,
+		OriginalPrice: price.Original.InexactFloat64(),
+		Price:         price.Final.InexactFloat64(),
+		Discount:      price.Discount.InexactFloat64(),
+		Category:      categoryResp,
+		Variants:      variantResponses,
+		CreatedAt:     p.CreatedAt,
+		UpdatedAt:     p.UpdatedAt,
+	}
+}
+
+func mapVariantToResponse(v models.Variant, p models.Product, pr pricer) VariantResponse {
 	resp := VariantResponse{
-		Name: v.Name,
-		SKU:  v.SKU,
+		Name:      v.Name,
+		SKU:       v.SKU,
+		Version:   v.Version,
+		CreatedAt: v.CreatedAt,
+		UpdatedAt: v.UpdatedAt,
 	}
 
 	// Use variant price if available, otherwise use product price
+	base := p.Price
 	if !v.Price.IsZero() {
-		price := v.Price.InexactFloat64()
-		resp.Price = &price
-	} else if productPrice != nil {
-		price := productPrice.InexactFloat64()
-		resp.Price = &price
+		base = v.Price
 	}
 
+	price := pr.price(base, p, v.SKU)
+	original := price.Original.InexactFloat64()
+	final := price.Final.InexactFloat64()
+	discount := price.Discount.InexactFloat64()
+	resp.OriginalPrice = &original
+	resp.Price = &final
+	resp.Discount = &discount
+
 	return resp
 }
 
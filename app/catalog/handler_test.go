@@ -1,14 +1,18 @@
 package catalog
 
 import (
+	"context"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/shopspring/decimal"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/mytheresa/go-hiring-challenge/app/auth"
 	"github.com/mytheresa/go-hiring-challenge/models"
+	"gorm.io/gorm"
 )
 
 // MockProductsRepository is a mock implementation of ProductsRepository
@@ -16,30 +20,135 @@ type MockProductsRepository struct {
 	mock.Mock
 }
 
-func (m *MockProductsRepository) GetAllProducts() ([]models.Product, error) {
-	args := m.Called()
+func (m *MockProductsRepository) GetAllProducts(ctx context.Context) ([]models.Product, error) {
+	args := m.Called(ctx)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
 	return args.Get(0).([]models.Product), args.Error(1)
 }
 
-func (m *MockProductsRepository) GetProductsByFilter(offset, limit int, categoryID *uint, priceLessThan *decimal.Decimal) ([]models.Product, int64, error) {
-	args := m.Called(offset, limit, categoryID, priceLessThan)
+func (m *MockProductsRepository) GetProductsByFilter(ctx context.Context, offset, limit int, filter models.ProductFilter) ([]models.Product, int64, error) {
+	args := m.Called(ctx, offset, limit, filter)
 	if args.Get(0) == nil {
 		return nil, 0, args.Error(2)
 	}
 	return args.Get(0).([]models.Product), args.Get(1).(int64), args.Error(2)
 }
 
-func (m *MockProductsRepository) GetProductByCode(code string) (*models.Product, error) {
-	args := m.Called(code)
+func (m *MockProductsRepository) GetProductByCode(ctx context.Context, code string, status models.ProductStatus) (*models.Product, error) {
+	args := m.Called(ctx, code, status)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
 	return args.Get(0).(*models.Product), args.Error(1)
 }
 
+func (m *MockProductsRepository) UpdateStatus(ctx context.Context, code string, update models.StatusUpdate) (*models.Product, error) {
+	args := m.Called(ctx, code, update)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Product), args.Error(1)
+}
+
+func (m *MockProductsRepository) Delete(ctx context.Context, code string, version uint) (*models.Product, error) {
+	args := m.Called(ctx, code, version)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Product), args.Error(1)
+}
+
+func (m *MockProductsRepository) Restore(ctx context.Context, code string) (*models.Product, error) {
+	args := m.Called(ctx, code)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Product), args.Error(1)
+}
+
+func (m *MockProductsRepository) DeleteVariant(ctx context.Context, code, sku string, version uint) (*models.Variant, error) {
+	args := m.Called(ctx, code, sku, version)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Variant), args.Error(1)
+}
+
+func (m *MockProductsRepository) RestoreVariant(ctx context.Context, code, sku string) (*models.Variant, error) {
+	args := m.Called(ctx, code, sku)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Variant), args.Error(1)
+}
+
+func (m *MockProductsRepository) UpdatePrices(ctx context.Context, code string, update models.PriceUpdate) (*models.Product, error) {
+	args := m.Called(ctx, code, update)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Product), args.Error(1)
+}
+
+func (m *MockProductsRepository) NextScheduled(ctx context.Context, after time.Time) (time.Time, error) {
+	args := m.Called(ctx, after)
+	return args.Get(0).(time.Time), args.Error(1)
+}
+
+func (m *MockProductsRepository) GetPriceHistory(ctx context.Context, code string, status models.ProductStatus, from, to *time.Time, offset, limit int) ([]models.PriceChange, int64, error) {
+	args := m.Called(ctx, code, status, from, to, offset, limit)
+	if args.Get(0) == nil {
+		return nil, 0, args.Error(2)
+	}
+	return args.Get(0).([]models.PriceChange), args.Get(1).(int64), args.Error(2)
+}
+
+// MockPromotionsRepository is a mock implementation of PromotionsRepository
+type MockPromotionsRepository struct {
+	mock.Mock
+}
+
+func (m *MockPromotionsRepository) LastEnded(ctx context.Context, at time.Time) (time.Time, error) {
+	args := m.Called(ctx, at)
+	return args.Get(0).(time.Time), args.Error(1)
+}
+
+func (m *MockPromotionsRepository) GetActive(ctx context.Context, at time.Time) ([]models.Promotion, error) {
+	args := m.Called(ctx, at)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.Promotion), args.Error(1)
+}
+
+// noPromotions returns a promotions repository without any running promotion
+func noPromotions() *MockPromotionsRepository {
+	m := new(MockPromotionsRepository)
+	m.On("GetActive", mock.Anything, mock.Anything).Return([]models.Promotion{}, nil)
+	m.On("LastEnded", mock.Anything, mock.Anything).Return(time.Time{}, nil)
+	return m
+}
+
+// withRole authenticates request as a client with role and every scope.
+func withRole(request *http.Request, role string) *http.Request {
+	principal := &auth.Principal{Subject: "apikey:mhc_test", Roles: []string{role}, Scopes: auth.Scopes}
+	return request.WithContext(auth.WithPrincipal(request.Context(), principal))
+}
+
+// withoutRole authenticates request as a client holding the catalog:read
+// scope but no role.
+func withoutRole(request *http.Request) *http.Request {
+	principal := &auth.Principal{Subject: "apikey:mhc_test", Scopes: []string{auth.ScopeCatalogRead}}
+	return request.WithContext(auth.WithPrincipal(request.Context(), principal))
+}
+
+// asAdmin authenticates request as a client allowed every operation.
+func asAdmin(request *http.Request) *http.Request {
+	return withRole(request, auth.RoleAdmin)
+}
+
 func TestCatalogHandleGet(t *testing.T) {
 	t.Run("returns paginated products with default pagination", func(t *testing.T) {
 		mockRepo := new(MockProductsRepository)
@@ -56,9 +165,9 @@ func TestCatalogHandleGet(t *testing.T) {
 			},
 		}
 
-		mockRepo.On("GetProductsByFilter", 0, 10, mock.MatchedBy(func(v *uint) bool { return v == nil }), mock.MatchedBy(func(d *decimal.Decimal) bool { return d == nil })).Return(products, int64(1), nil)
+		mockRepo.On("GetProductsByFilter", mock.Anything, 0, 10, models.ProductFilter{Status: models.StatusPublished}).Return(products, int64(1), nil)
 
-		handler := NewCatalogHandler(mockRepo)
+		handler := NewCatalogHandler(mockRepo, noPromotions())
 		recorder := httptest.NewRecorder()
 		request := httptest.NewRequest("GET", "/catalog", nil)
 
@@ -82,9 +191,9 @@ func TestCatalogHandleGet(t *testing.T) {
 			},
 		}
 
-		mockRepo.On("GetProductsByFilter", 1, 20, mock.MatchedBy(func(v *uint) bool { return v == nil }), mock.MatchedBy(func(d *decimal.Decimal) bool { return d == nil })).Return(products, int64(8), nil)
+		mockRepo.On("GetProductsByFilter", mock.Anything, 1, 20, models.ProductFilter{Status: models.StatusPublished}).Return(products, int64(8), nil)
 
-		handler := NewCatalogHandler(mockRepo)
+		handler := NewCatalogHandler(mockRepo, noPromotions())
 		recorder := httptest.NewRecorder()
 		request := httptest.NewRequest("GET", "/catalog?offset=1&limit=20", nil)
 
@@ -99,9 +208,9 @@ func TestCatalogHandleGet(t *testing.T) {
 	t.Run("returns empty products list", func(t *testing.T) {
 		mockRepo := new(MockProductsRepository)
 
-		mockRepo.On("GetProductsByFilter", 0, 10, mock.MatchedBy(func(v *uint) bool { return v == nil }), mock.MatchedBy(func(d *decimal.Decimal) bool { return d == nil })).Return([]models.Product{}, int64(0), nil)
+		mockRepo.On("GetProductsByFilter", mock.Anything, 0, 10, models.ProductFilter{Status: models.StatusPublished}).Return([]models.Product{}, int64(0), nil)
 
-		handler := NewCatalogHandler(mockRepo)
+		handler := NewCatalogHandler(mockRepo, noPromotions())
 		recorder := httptest.NewRecorder()
 		request := httptest.NewRequest("GET", "/catalog", nil)
 
@@ -112,6 +221,108 @@ func TestCatalogHandleGet(t *testing.T) {
 		assert.Contains(t, recorder.Body.String(), `"total":0`)
 		mockRepo.AssertExpectations(t)
 	})
+
+	t.Run("applies running category promotion to prices", func(t *testing.T) {
+		mockRepo := new(MockProductsRepository)
+		mockPromos := new(MockPromotionsRepository)
+		now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+
+		products := []models.Product{
+			{
+				ID:    1,
+				Code:  "PROD001",
+				Price: decimal.NewFromFloat(20),
+				Category: &models.Category{
+					Code: "clothing",
+					Name: "Clothing",
+				},
+			},
+		}
+
+		promos := []models.Promotion{
+			{
+				ID:       1,
+				Type:     models.PromotionPercentage,
+				Value:    decimal.NewFromInt(25),
+				Scope:    models.PromotionScopeCategory,
+				Target:   "clothing",
+				StartsAt: now.Add(-time.Hour),
+				EndsAt:   now.Add(time.Hour),
+			},
+		}
+
+		mockRepo.On("GetProductsByFilter", mock.Anything, 0, 10, models.ProductFilter{Status: models.StatusPublished}).Return(products, int64(1), nil)
+		mockPromos.On("GetActive", mock.Anything, now).Return(promos, nil)
+		mockPromos.On("LastEnded", mock.Anything, now).Return(time.Time{}, nil)
+
+		handler := NewCatalogHandler(mockRepo, mockPromos)
+		handler.now = func() time.Time { return now }
+		recorder := httptest.NewRecorder()
+		request := httptest.NewRequest("GET", "/catalog", nil)
+
+		handler.HandleGet(recorder, request)
+
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		assert.Contains(t, recorder.Body.String(), `"original_price":20`)
+		assert.Contains(t, recorder.Body.String(), `"price":15`)
+		assert.Contains(t, recorder.Body.String(), `"discount":5`)
+		mockRepo.AssertExpectations(t)
+		mockPromos.AssertExpectations(t)
+	})
+
+	t.Run("returns products updated since the given instant", func(t *testing.T) {
+		mockRepo := new(MockProductsRepository)
+		since := time.Date(2025, 5, 1, 8, 30, 0, 0, time.UTC)
+		updatedAt := since.Add(time.Hour)
+
+		products := []models.Product{
+			{ID: 3, Code: "PROD003", Price: decimal.NewFromFloat(8.75), CreatedAt: since.Add(-time.Hour), UpdatedAt: updatedAt},
+		}
+
+		mockRepo.On("GetProductsByFilter", mock.Anything, 0, 10, mock.MatchedBy(func(f models.ProductFilter) bool {
+			return f.Status == models.StatusPublished && f.UpdatedSince != nil && f.UpdatedSince.Equal(since) && !f.WithDeleted
+		})).Return(products, int64(1), nil)
+
+		handler := NewCatalogHandler(mockRepo, noPromotions())
+		recorder := httptest.NewRecorder()
+		request := httptest.NewRequest("GET", "/catalog?updated_since=2025-05-01T08:30:00Z", nil)
+
+		handler.HandleGet(recorder, request)
+
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		assert.Contains(t, recorder.Body.String(), `"created_at":"2025-05-01T07:30:00Z","updated_at":"2025-05-01T09:30:00Z"`)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("returns 400 for a malformed updated_since", func(t *testing.T) {
+		mockRepo := new(MockProductsRepository)
+
+		handler := NewCatalogHandler(mockRepo, noPromotions())
+		recorder := httptest.NewRecorder()
+		request := httptest.NewRequest("GET", "/catalog?updated_since=last-week", nil)
+
+		handler.HandleGet(recorder, request)
+
+		assert.Equal(t, http.StatusBadRequest, recorder.Code)
+		mockRepo.AssertNotCalled(t, "GetProductsByFilter", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("returns 500 when promotions cannot be loaded", func(t *testing.T) {
+		mockRepo := new(MockProductsRepository)
+		mockPromos := new(MockPromotionsRepository)
+
+		mockRepo.On("GetProductsByFilter", mock.Anything, 0, 10, models.ProductFilter{Status: models.StatusPublished}).Return([]models.Product{}, int64(0), nil)
+		mockPromos.On("GetActive", mock.Anything, mock.Anything).Return(nil, assert.AnError)
+
+		handler := NewCatalogHandler(mockRepo, mockPromos)
+		recorder := httptest.NewRecorder()
+		request := httptest.NewRequest("GET", "/catalog", nil)
+
+		handler.HandleGet(recorder, request)
+
+		assert.Equal(t, http.StatusInternalServerError, recorder.Code)
+		mockRepo.AssertExpectations(t)
+	})
 }
 
 func TestCatalogHandleGetByCode(t *testing.T) {
@@ -144,9 +355,9 @@ func TestCatalogHandleGetByCode(t *testing.T) {
 			},
 		}
 
-		mockRepo.On("GetProductByCode", "PROD001").Return(product, nil)
+		mockRepo.On("GetProductByCode", mock.Anything, "PROD001", models.StatusPublished).Return(product, nil)
 
-		handler := NewCatalogHandler(mockRepo)
+		handler := NewCatalogHandler(mockRepo, noPromotions())
 		recorder := httptest.NewRecorder()
 		request := httptest.NewRequest("GET", "/catalog/PROD001", nil)
 		request.SetPathValue("code", "PROD001")
@@ -163,9 +374,9 @@ func TestCatalogHandleGetByCode(t *testing.T) {
 
 	t.Run("returns 404 when product not found", func(t *testing.T) {
 		mockRepo := new(MockProductsRepository)
-		mockRepo.On("GetProductByCode", "INVALID").Return(nil, assert.AnError)
+		mockRepo.On("GetProductByCode", mock.Anything, "INVALID", models.StatusPublished).Return(nil, assert.AnError)
 
-		handler := NewCatalogHandler(mockRepo)
+		handler := NewCatalogHandler(mockRepo, noPromotions())
 		recorder := httptest.NewRecorder()
 		request := httptest.NewRequest("GET", "/catalog/INVALID", nil)
 		request.SetPathValue("code", "INVALID")
@@ -199,9 +410,9 @@ func TestCatalogHandleGetByCode(t *testing.T) {
 			},
 		}
 
-		mockRepo.On("GetProductByCode", "PROD001").Return(product, nil)
+		mockRepo.On("GetProductByCode", mock.Anything, "PROD001", models.StatusPublished).Return(product, nil)
 
-		handler := NewCatalogHandler(mockRepo)
+		handler := NewCatalogHandler(mockRepo, noPromotions())
 		recorder := httptest.NewRecorder()
 		request := httptest.NewRequest("GET", "/catalog/PROD001", nil)
 		request.SetPathValue("code", "PROD001")
@@ -213,4 +424,205 @@ func TestCatalogHandleGetByCode(t *testing.T) {
 		assert.Contains(t, recorder.Body.String(), "10.99")
 		mockRepo.AssertExpectations(t)
 	})
+	t.Run("applies sku promotion to the matching variant only", func(t *testing.T) {
+		mockRepo := new(MockProductsRepository)
+		mockPromos := new(MockPromotionsRepository)
+		now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+
+		product := &models.Product{
+			ID:    1,
+			Code:  "PROD001",
+			Price: decimal.NewFromFloat(10),
+			Variants: []models.Variant{
+				{ID: 1, ProductID: 1, Name: "Variant A", SKU: "SKU001A", Price: decimal.NewFromFloat(12)},
+				{ID: 2, ProductID: 1, Name: "Variant B", SKU: "SKU001B", Price: decimal.Zero},
+			},
+		}
+
+		promos := []models.Promotion{
+			{
+				ID:       1,
+				Type:     models.PromotionFixed,
+				Value:    decimal.NewFromInt(3),
+				Scope:    models.PromotionScopeSKU,
+				Target:   "SKU001A",
+				StartsAt: now.Add(-time.Hour),
+				EndsAt:   now.Add(time.Hour),
+			},
+		}
+
+		mockRepo.On("GetProductByCode", mock.Anything, "PROD001", models.StatusPublished).Return(product, nil)
+		mockPromos.On("GetActive", mock.Anything, now).Return(promos, nil)
+		mockPromos.On("LastEnded", mock.Anything, now).Return(time.Time{}, nil)
+
+		handler := NewCatalogHandler(mockRepo, mockPromos)
+		handler.now = func() time.Time { return now }
+		recorder := httptest.NewRecorder()
+		request := httptest.NewRequest("GET", "/catalog/PROD001", nil)
+		request.SetPathValue("code", "PROD001")
+
+		handler.HandleGetByCode(recorder, request)
+
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		assert.Contains(t, recorder.Body.String(), `"sku":"SKU001A","version":0,"original_price":12,"price":9,"discount":3`)
+		assert.Contains(t, recorder.Body.String(), `"sku":"SKU001B","version":0,"original_price":10,"price":10,"discount":0`)
+		mockRepo.AssertExpectations(t)
+		mockPromos.AssertExpectations(t)
+	})
+}
+
+func TestCatalogLastModified(t *testing.T) {
+	t.Run("uses the latest change of product, variants and promotions", func(t *testing.T) {
+		mockRepo := new(MockProductsRepository)
+		mockPromos := new(MockPromotionsRepository)
+		now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+
+		product := &models.Product{
+			ID:        1,
+			Code:      "PROD001",
+			Price:     decimal.NewFromFloat(10),
+			UpdatedAt: now.Add(-72 * time.Hour),
+			Variants: []models.Variant{
+				{ID: 1, ProductID: 1, Name: "Variant A", SKU: "SKU001A", UpdatedAt: now.Add(-48 * time.Hour)},
+			},
+		}
+		promos := []models.Promotion{
+			{ID: 1, Type: models.PromotionFixed, Value: decimal.NewFromInt(1), Scope: models.PromotionScopeCategory, Target: "shoes", StartsAt: now.Add(-24 * time.Hour), EndsAt: now.Add(time.Hour)},
+		}
+
+		mockRepo.On("GetProductByCode", mock.Anything, "PROD001", models.StatusPublished).Return(product, nil)
+		mockPromos.On("GetActive", mock.Anything, now).Return(promos, nil)
+		mockPromos.On("LastEnded", mock.Anything, now).Return(time.Time{}, nil)
+
+		handler := NewCatalogHandler(mockRepo, mockPromos)
+		handler.now = func() time.Time { return now }
+		recorder := httptest.NewRecorder()
+		request := httptest.NewRequest("GET", "/catalog/PROD001", nil)
+		request.SetPathValue("code", "PROD001")
+
+		handler.HandleGetByCode(recorder, request)
+
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		assert.Equal(t, "Sat, 31 May 2025 12:00:00 GMT", recorder.Header().Get("Last-Modified"))
+		assert.NotEmpty(t, recorder.Header().Get("ETag"))
+	})
+
+	t.Run("uses the end of the last promotion that ended", func(t *testing.T) {
+		mockRepo := new(MockProductsRepository)
+		mockPromos := new(MockPromotionsRepository)
+		now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+
+		product := &models.Product{ID: 1, Code: "PROD001", Price: decimal.NewFromFloat(10), UpdatedAt: now.Add(-72 * time.Hour)}
+
+		mockRepo.On("GetProductByCode", mock.Anything, "PROD001", models.StatusPublished).Return(product, nil)
+		mockPromos.On("GetActive", mock.Anything, now).Return([]models.Promotion{}, nil)
+		mockPromos.On("LastEnded", mock.Anything, now).Return(now.Add(-time.Hour), nil)
+
+		handler := NewCatalogHandler(mockRepo, mockPromos)
+		handler.now = func() time.Time { return now }
+		recorder := httptest.NewRecorder()
+		request := httptest.NewRequest("GET", "/catalog/PROD001", nil)
+		request.SetPathValue("code", "PROD001")
+
+		handler.HandleGetByCode(recorder, request)
+
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		assert.Equal(t, "Sun, 01 Jun 2025 11:00:00 GMT", recorder.Header().Get("Last-Modified"))
+	})
+}
+
+func TestCatalogHandleAdminGet(t *testing.T) {
+	t.Run("filters products by requested status", func(t *testing.T) {
+		mockRepo := new(MockProductsRepository)
+
+		products := []models.Product{
+			{ID: 9, Code: "PROD009", Price: decimal.NewFromFloat(30), Status: models.StatusDraft},
+		}
+
+		mockRepo.On("GetProductsByFilter", mock.Anything, 0, 10, models.ProductFilter{Status: models.StatusDraft}).Return(products, int64(1), nil)
+
+		handler := NewCatalogHandler(mockRepo, noPromotions())
+		recorder := httptest.NewRecorder()
+		request := httptest.NewRequest("GET", "/admin/catalog?status=draft", nil)
+
+		handler.HandleAdminGet(recorder, asAdmin(request))
+
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		assert.Contains(t, recorder.Body.String(), `"code":"PROD009","version":0,"status":"draft"`)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("includes products deleted since updated_since", func(t *testing.T) {
+		mockRepo := new(MockProductsRepository)
+		since := time.Date(2025, 5, 1, 8, 30, 0, 0, time.UTC)
+		deletedAt := since.Add(time.Hour)
+
+		products := []models.Product{
+			{ID: 4, Code: "PROD004", Price: decimal.NewFromFloat(20), Status: models.StatusPublished, DeletedAt: gorm.DeletedAt{Time: deletedAt, Valid: true}},
+		}
+
+		mockRepo.On("GetProductsByFilter", mock.Anything, 0, 10, mock.MatchedBy(func(f models.ProductFilter) bool {
+			return f.UpdatedSince != nil && f.UpdatedSince.Equal(since) && f.WithDeleted
+		})).Return(products, int64(1), nil)
+
+		handler := NewCatalogHandler(mockRepo, noPromotions())
+		recorder := httptest.NewRecorder()
+		request := httptest.NewRequest("GET", "/admin/catalog?updated_since=2025-05-01T08:30:00Z", nil)
+
+		handler.HandleAdminGet(recorder, asAdmin(request))
+
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		assert.Contains(t, recorder.Body.String(), `"deleted_at":"2025-05-01T09:30:00Z"`)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("returns products in any status without filter", func(t *testing.T) {
+		mockRepo := new(MockProductsRepository)
+		mockRepo.On("GetProductsByFilter", mock.Anything, 0, 10, models.ProductFilter{}).Return([]models.Product{}, int64(0), nil)
+
+		handler := NewCatalogHandler(mockRepo, noPromotions())
+		recorder := httptest.NewRecorder()
+		request := httptest.NewRequest("GET", "/admin/catalog", nil)
+
+		handler.HandleAdminGet(recorder, asAdmin(request))
+
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("returns 400 for an unknown status", func(t *testing.T) {
+		mockRepo := new(MockProductsRepository)
+
+		handler := NewCatalogHandler(mockRepo, noPromotions())
+		recorder := httptest.NewRecorder()
+		request := httptest.NewRequest("GET", "/admin/catalog?status=deleted", nil)
+
+		handler.HandleAdminGet(recorder, asAdmin(request))
+
+		assert.Equal(t, http.StatusBadRequest, recorder.Code)
+		mockRepo.AssertNotCalled(t, "GetProductsByFilter", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestCatalogHandleAdminGetByCode(t *testing.T) {
+	t.Run("returns a scheduled draft as published once its publish time passed", func(t *testing.T) {
+		mockRepo := new(MockProductsRepository)
+		now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+		publishAt := now.Add(-time.Minute)
+
+		product := &models.Product{ID: 9, Code: "PROD009", Price: decimal.NewFromFloat(30), Status: models.StatusDraft, PublishAt: &publishAt}
+		mockRepo.On("GetProductByCode", mock.Anything, "PROD009", models.ProductStatus("")).Return(product, nil)
+
+		handler := NewCatalogHandler(mockRepo, noPromotions())
+		handler.now = func() time.Time { return now }
+		recorder := httptest.NewRecorder()
+		request := httptest.NewRequest("GET", "/admin/catalog/PROD009", nil)
+		request.SetPathValue("code", "PROD009")
+
+		handler.HandleAdminGetByCode(recorder, asAdmin(request))
+
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		assert.Contains(t, recorder.Body.String(), `"status":"published"`)
+		mockRepo.AssertExpectations(t)
+	})
 }
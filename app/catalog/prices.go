@@ -0,0 +1,204 @@
+package catalog
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/mytheresa/go-hiring-challenge/app/api"
+	"github.com/mytheresa/go-hiring-challenge/app/auth"
+	"github.com/mytheresa/go-hiring-challenge/models"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// UpdatePricesRequest changes product and variant prices. Version is the product
+// version the caller last read, unless it is sent through If-Match.
+type UpdatePricesRequest struct {
+	Version  *uint                 `json:"version"`
+	Price    *decimal.Decimal      `json:"price"`
+	Variants []VariantPriceRequest `json:"variants"`
+}
+
+// VariantPriceRequest sets the price of a variant. A null price makes the
+// variant inherit the product price.
+type VariantPriceRequest struct {
+	SKU   string              `json:"sku"`
+	Price decimal.NullDecimal `json:"price"`
+}
+
+type PriceChangeResponse struct {
+	SKU       string    `json:"sku,omitempty"`
+	OldPrice  *float64  `json:"old_price"`
+	NewPrice  *float64  `json:"new_price"`
+	ChangedBy string    `json:"changed_by,omitempty"`
+	ChangedAt time.Time `json:"changed_at"`
+}
+
+type PriceHistoryResponse struct {
+	Changes []PriceChangeResponse `json:"changes"`
+	Total   int64                 `json:"total"`
+}
+
+// HandleUpdatePrices changes the price of a product and, optionally, of its variants.
+func (h *CatalogHandler) HandleUpdatePrices(w http.ResponseWriter, r *http.Request) {
+	if !auth.Authorize(w, r, auth.PermissionChangePrices) {
+		return
+	}
+
+	code := r.PathValue("code")
+
+	var req UpdatePricesRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		api.ErrorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	p, ok := api.RequireVersion(w, r, req.Version)
+	if !ok {
+		return
+	}
+
+	if req.Price == nil && len(req.Variants) == 0 {
+		api.ErrorResponse(w, http.StatusBadRequest, "Price or variants are required")
+		return
+	}
+
+	if req.Price != nil && !req.Price.IsPositive() {
+		api.ErrorResponse(w, http.StatusBadRequest, "Price must be greater than zero")
+		return
+	}
+
+	// Authorize has checked the principal, so the change is attributed to
+	// the credentials that made it.
+	principal, _ := auth.FromContext(r.Context())
+	update := models.PriceUpdate{
+		Version:       p.Version,
+		Price:         req.Price,
+		VariantPrices: make(map[string]decimal.NullDecimal, len(req.Variants)),
+		ChangedBy:     principal.Subject,
+	}
+
+	for _, v := range req.Variants {
+		if v.SKU == "" {
+			api.ErrorResponse(w, http.StatusBadRequest, "Variant sku is required")
+			return
+		}
+		if v.Price.Valid && !v.Price.Decimal.IsPositive() {
+			api.ErrorResponse(w, http.StatusBadRequest, "Variant price must be greater than zero")
+			return
+		}
+		update.VariantPrices[v.SKU] = v.Price
+	}
+
+	product, err := h.repo.UpdatePrices(r.Context(), code, update)
+	if err != nil {
+		if errors.Is(err, models.ErrVariantNotFound) {
+			api.ErrorResponse(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		writeRepositoryError(w, r, err, "Product not found", p)
+		return
+	}
+
+	pricer, err := h.newPricer(r.Context())
+	if err != nil {
+		api.InternalError(w, r, err)
+		return
+	}
+
+	api.VersionedResponse(w, product.Version, mapProductToDetailResponse(*product, pricer))
+}
+
+// HandleGetPriceHistory returns the recorded price changes of a published
+// product; other products are not found, as in the product read. The authors
+// of the changes are left out, since they name API keys and token subjects.
+func (h *CatalogHandler) HandleGetPriceHistory(w http.ResponseWriter, r *http.Request) {
+	h.priceHistory(w, r, models.StatusPublished, false)
+}
+
+// HandleAdminGetPriceHistory returns the recorded price changes of a product in
+// any status, along with their authors.
+func (h *CatalogHandler) HandleAdminGetPriceHistory(w http.ResponseWriter, r *http.Request) {
+	if !auth.Authorize(w, r, auth.PermissionViewCatalog) {
+		return
+	}
+
+	h.priceHistory(w, r, "", true)
+}
+
+func (h *CatalogHandler) priceHistory(w http.ResponseWriter, r *http.Request, status models.ProductStatus, withAuthors bool) {
+	code := r.PathValue("code")
+
+	offset, err := strconv.Atoi(r.URL.Query().Get("offset"))
+	if err != nil || offset < 0 {
+		offset = 0
+	}
+
+	limit, err := strconv.Atoi(r.URL.Query().Get("limit"))
+	if err != nil || limit <= 0 {
+		limit = 10
+	}
+
+	from, err := parseTimeParam(r, "from")
+	if err != nil {
+		api.ErrorResponse(w, http.StatusBadRequest, "Invalid from, expected RFC 3339 timestamp")
+		return
+	}
+
+	to, err := parseTimeParam(r, "to")
+	if err != nil {
+		api.ErrorResponse(w, http.StatusBadRequest, "Invalid to, expected RFC 3339 timestamp")
+		return
+	}
+
+	changes, total, err := h.repo.GetPriceHistory(r.Context(), code, status, from, to, offset, limit)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			api.ErrorResponse(w, http.StatusNotFound, "Product not found")
+			return
+		}
+		api.InternalError(w, r, err)
+		return
+	}
+
+	changeResponses := make([]PriceChangeResponse, len(changes))
+	for i, c := range changes {
+		changeResponses[i] = PriceChangeResponse{
+			SKU:       c.SKU,
+			OldPrice:  nullDecimalToFloat(c.OldPrice),
+			NewPrice:  nullDecimalToFloat(c.NewPrice),
+			ChangedAt: c.ChangedAt,
+		}
+		if withAuthors {
+			changeResponses[i].ChangedBy = c.ChangedBy
+		}
+	}
+
+	api.OKResponse(w, PriceHistoryResponse{
+		Changes: changeResponses,
+		Total:   total,
+	})
+}
+
+func parseTimeParam(r *http.Request, name string) (*time.Time, error) {
+	value := r.URL.Query().Get(name)
+	if value == "" {
+		return nil, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+func nullDecimalToFloat(d decimal.NullDecimal) *float64 {
+	if !d.Valid {
+		return nil
+	}
+	f := d.Decimal.InexactFloat64()
+	return &f
+}
@@ -0,0 +1,260 @@
+package catalog
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/mytheresa/go-hiring-challenge/app/auth"
+	"github.com/mytheresa/go-hiring-challenge/models"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"gorm.io/gorm"
+)
+
+func TestCatalogHandleUpdatePrices(t *testing.T) {
+	t.Run("updates product and variant prices", func(t *testing.T) {
+		mockRepo := new(MockProductsRepository)
+
+		updated := &models.Product{
+			ID:    1,
+			Code:  "PROD001",
+			Price: decimal.NewFromFloat(12.5),
+			Variants: []models.Variant{
+				{ID: 1, ProductID: 1, Name: "Variant A", SKU: "SKU001A", Price: decimal.NewFromFloat(13)},
+				{ID: 2, ProductID: 1, Name: "Variant B", SKU: "SKU001B"},
+			},
+		}
+
+		mockRepo.On("UpdatePrices", mock.Anything, "PROD001", mock.MatchedBy(func(u models.PriceUpdate) bool {
+			return u.Version == 2 &&
+				u.Price.Equal(decimal.NewFromFloat(12.5)) &&
+				u.VariantPrices["SKU001A"].Decimal.Equal(decimal.NewFromInt(13)) &&
+				u.VariantPrices["SKU001A"].Valid &&
+				!u.VariantPrices["SKU001B"].Valid &&
+				u.ChangedBy == "apikey:mhc_test"
+		})).Return(updated, nil)
+
+		handler := NewCatalogHandler(mockRepo, noPromotions())
+		recorder := httptest.NewRecorder()
+		body := `{"version":2,"price":12.5,"variants":[{"sku":"SKU001A","price":13},{"sku":"SKU001B","price":null}]}`
+		request := httptest.NewRequest("PATCH", "/admin/catalog/PROD001/prices", bytes.NewBufferString(body))
+		request.SetPathValue("code", "PROD001")
+		// A header naming someone else must not change the recorded author.
+		request.Header.Set("X-Actor", "jane")
+
+		handler.HandleUpdatePrices(recorder, asAdmin(request))
+
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		assert.Contains(t, recorder.Body.String(), `"price":12.5`)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("returns 404 when product not found", func(t *testing.T) {
+		mockRepo := new(MockProductsRepository)
+		mockRepo.On("UpdatePrices", mock.Anything, "INVALID", mock.Anything).Return(nil, gorm.ErrRecordNotFound)
+
+		handler := NewCatalogHandler(mockRepo, noPromotions())
+		recorder := httptest.NewRecorder()
+		request := httptest.NewRequest("PATCH", "/admin/catalog/INVALID/prices", bytes.NewBufferString(`{"version":1,"price":10}`))
+		request.SetPathValue("code", "INVALID")
+
+		handler.HandleUpdatePrices(recorder, asAdmin(request))
+
+		assert.Equal(t, http.StatusNotFound, recorder.Code)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("returns 400 for an unknown sku", func(t *testing.T) {
+		mockRepo := new(MockProductsRepository)
+		mockRepo.On("UpdatePrices", mock.Anything, "PROD001", mock.Anything).Return(nil, fmt.Errorf("%w: SKU999", models.ErrVariantNotFound))
+
+		handler := NewCatalogHandler(mockRepo, noPromotions())
+		recorder := httptest.NewRecorder()
+		request := httptest.NewRequest("PATCH", "/admin/catalog/PROD001/prices", bytes.NewBufferString(`{"version":1,"variants":[{"sku":"SKU999","price":3}]}`))
+		request.SetPathValue("code", "PROD001")
+
+		handler.HandleUpdatePrices(recorder, asAdmin(request))
+
+		assert.Equal(t, http.StatusBadRequest, recorder.Code)
+		assert.Contains(t, recorder.Body.String(), "SKU999")
+	})
+
+	t.Run("returns 409 when the body version is stale", func(t *testing.T) {
+		mockRepo := new(MockProductsRepository)
+		mockRepo.On("UpdatePrices", mock.Anything, "PROD001", mock.MatchedBy(func(u models.PriceUpdate) bool { return u.Version == 1 })).Return(nil, models.ErrVersionConflict)
+
+		handler := NewCatalogHandler(mockRepo, noPromotions())
+		recorder := httptest.NewRecorder()
+		request := httptest.NewRequest("PATCH", "/admin/catalog/PROD001/prices", bytes.NewBufferString(`{"version":1,"price":10}`))
+		request.SetPathValue("code", "PROD001")
+
+		handler.HandleUpdatePrices(recorder, asAdmin(request))
+
+		assert.Equal(t, http.StatusConflict, recorder.Code)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("prefers If-Match over the body version", func(t *testing.T) {
+		mockRepo := new(MockProductsRepository)
+		mockRepo.On("UpdatePrices", mock.Anything, "PROD001", mock.MatchedBy(func(u models.PriceUpdate) bool { return u.Version == 3 })).Return(nil, models.ErrVersionConflict)
+
+		handler := NewCatalogHandler(mockRepo, noPromotions())
+		recorder := httptest.NewRecorder()
+		request := httptest.NewRequest("PATCH", "/admin/catalog/PROD001/prices", bytes.NewBufferString(`{"version":1,"price":10}`))
+		request.SetPathValue("code", "PROD001")
+		request.Header.Set("If-Match", `"3"`)
+
+		handler.HandleUpdatePrices(recorder, asAdmin(request))
+
+		assert.Equal(t, http.StatusPreconditionFailed, recorder.Code)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("returns 428 without a version", func(t *testing.T) {
+		mockRepo := new(MockProductsRepository)
+
+		handler := NewCatalogHandler(mockRepo, noPromotions())
+		recorder := httptest.NewRecorder()
+		request := httptest.NewRequest("PATCH", "/admin/catalog/PROD001/prices", bytes.NewBufferString(`{"price":10}`))
+		request.SetPathValue("code", "PROD001")
+
+		handler.HandleUpdatePrices(recorder, asAdmin(request))
+
+		assert.Equal(t, http.StatusPreconditionRequired, recorder.Code)
+		mockRepo.AssertNotCalled(t, "UpdatePrices", mock.Anything, mock.Anything)
+	})
+
+	t.Run("returns 400 for a non positive price", func(t *testing.T) {
+		mockRepo := new(MockProductsRepository)
+
+		handler := NewCatalogHandler(mockRepo, noPromotions())
+		recorder := httptest.NewRecorder()
+		request := httptest.NewRequest("PATCH", "/admin/catalog/PROD001/prices", bytes.NewBufferString(`{"version":1,"price":0}`))
+		request.SetPathValue("code", "PROD001")
+
+		handler.HandleUpdatePrices(recorder, asAdmin(request))
+
+		assert.Equal(t, http.StatusBadRequest, recorder.Code)
+		mockRepo.AssertNotCalled(t, "UpdatePrices", mock.Anything, mock.Anything)
+	})
+
+	t.Run("lets editors but not viewers change prices", func(t *testing.T) {
+		mockRepo := new(MockProductsRepository)
+		mockRepo.On("UpdatePrices", mock.Anything, "PROD001", mock.Anything).
+			Return(&models.Product{ID: 1, Code: "PROD001", Price: decimal.NewFromFloat(12.5), Version: 3}, nil).Once()
+
+		handler := NewCatalogHandler(mockRepo, noPromotions())
+		for role, expected := range map[string]int{auth.RoleEditor: http.StatusOK, auth.RoleViewer: http.StatusForbidden} {
+			recorder := httptest.NewRecorder()
+			request := httptest.NewRequest("PATCH", "/admin/catalog/PROD001/prices", bytes.NewBufferString(`{"version":2,"price":12.5}`))
+			request.SetPathValue("code", "PROD001")
+
+			handler.HandleUpdatePrices(recorder, withRole(request, role))
+
+			assert.Equal(t, expected, recorder.Code, role)
+		}
+		mockRepo.AssertExpectations(t)
+	})
+}
+
+func TestCatalogHandleGetPriceHistory(t *testing.T) {
+	t.Run("returns paginated price history within range", func(t *testing.T) {
+		mockRepo := new(MockProductsRepository)
+		from := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+		changedAt := time.Date(2025, 2, 1, 10, 0, 0, 0, time.UTC)
+
+		changes := []models.PriceChange{
+			{
+				ID:        2,
+				ProductID: 1,
+				SKU:       "SKU001B",
+				OldPrice:  decimal.NullDecimal{},
+				NewPrice:  decimal.NewNullDecimal(decimal.NewFromInt(9)),
+				ChangedBy: "jane",
+				ChangedAt: changedAt,
+			},
+		}
+
+		mockRepo.On("GetPriceHistory", mock.Anything, "PROD001", models.StatusPublished, mock.MatchedBy(func(t *time.Time) bool { return t != nil && t.Equal(from) }), mock.MatchedBy(func(t *time.Time) bool { return t == nil }), 0, 5).Return(changes, int64(3), nil)
+
+		handler := NewCatalogHandler(mockRepo, noPromotions())
+		recorder := httptest.NewRecorder()
+		request := httptest.NewRequest("GET", "/catalog/PROD001/price-history?from=2025-01-01T00:00:00Z&limit=5", nil)
+		request.SetPathValue("code", "PROD001")
+
+		handler.HandleGetPriceHistory(recorder, request)
+
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		assert.Contains(t, recorder.Body.String(), `"sku":"SKU001B","old_price":null,"new_price":9,"changed_at"`)
+		assert.NotContains(t, recorder.Body.String(), "jane")
+		assert.Contains(t, recorder.Body.String(), `"total":3`)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("returns 400 for a malformed timestamp", func(t *testing.T) {
+		mockRepo := new(MockProductsRepository)
+
+		handler := NewCatalogHandler(mockRepo, noPromotions())
+		recorder := httptest.NewRecorder()
+		request := httptest.NewRequest("GET", "/catalog/PROD001/price-history?to=yesterday", nil)
+		request.SetPathValue("code", "PROD001")
+
+		handler.HandleGetPriceHistory(recorder, request)
+
+		assert.Equal(t, http.StatusBadRequest, recorder.Code)
+	})
+
+	t.Run("returns 404 when product not found", func(t *testing.T) {
+		mockRepo := new(MockProductsRepository)
+		mockRepo.On("GetPriceHistory", mock.Anything, "INVALID", models.StatusPublished, mock.Anything, mock.Anything, 0, 10).Return(nil, int64(0), gorm.ErrRecordNotFound)
+
+		handler := NewCatalogHandler(mockRepo, noPromotions())
+		recorder := httptest.NewRecorder()
+		request := httptest.NewRequest("GET", "/catalog/INVALID/price-history", nil)
+		request.SetPathValue("code", "INVALID")
+
+		handler.HandleGetPriceHistory(recorder, request)
+
+		assert.Equal(t, http.StatusNotFound, recorder.Code)
+	})
+}
+
+func TestCatalogHandleAdminGetPriceHistory(t *testing.T) {
+	t.Run("returns the authors of the changes to viewers", func(t *testing.T) {
+		mockRepo := new(MockProductsRepository)
+		changes := []models.PriceChange{
+			{ID: 1, ProductID: 1, NewPrice: decimal.NewNullDecimal(decimal.NewFromInt(9)), ChangedBy: "jane"},
+		}
+		mockRepo.On("GetPriceHistory", mock.Anything, "PROD001", models.ProductStatus(""), mock.Anything, mock.Anything, 0, 10).Return(changes, int64(1), nil)
+
+		handler := NewCatalogHandler(mockRepo, noPromotions())
+		recorder := httptest.NewRecorder()
+		request := withRole(httptest.NewRequest("GET", "/admin/catalog/PROD001/price-history", nil), auth.RoleViewer)
+		request.SetPathValue("code", "PROD001")
+
+		handler.HandleAdminGetPriceHistory(recorder, request)
+
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		assert.Contains(t, recorder.Body.String(), `"changed_by":"jane"`)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("returns 403 without a role", func(t *testing.T) {
+		mockRepo := new(MockProductsRepository)
+
+		handler := NewCatalogHandler(mockRepo, noPromotions())
+		recorder := httptest.NewRecorder()
+		request := withoutRole(httptest.NewRequest("GET", "/admin/catalog/PROD001/price-history", nil))
+		request.SetPathValue("code", "PROD001")
+
+		handler.HandleAdminGetPriceHistory(recorder, request)
+
+		assert.Equal(t, http.StatusForbidden, recorder.Code)
+		mockRepo.AssertNotCalled(t, "GetPriceHistory")
+	})
+}
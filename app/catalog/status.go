@@ -0,0 +1,85 @@
+package catalog
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/mytheresa/go-hiring-challenge/app/api"
+	"github.com/mytheresa/go-hiring-challenge/app/auth"
+	"github.com/mytheresa/go-hiring-challenge/models"
+)
+
+// UpdateStatusRequest changes the lifecycle status of a product and its
+// publishing schedule. Omitted timestamps clear the schedule. Version is the
+// product version the caller last read, unless it is sent through If-Match.
+type UpdateStatusRequest struct {
+	Version     *uint      `json:"version"`
+	Status      string     `json:"status"`
+	PublishAt   *time.Time `json:"publish_at"`
+	UnpublishAt *time.Time `json:"unpublish_at"`
+}
+
+// HandleUpdateStatus moves a product through its lifecycle.
+func (h *CatalogHandler) HandleUpdateStatus(w http.ResponseWriter, r *http.Request) {
+	if !auth.Authorize(w, r, auth.PermissionEditProducts) {
+		return
+	}
+
+	code := r.PathValue("code")
+
+	var req UpdateStatusRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		api.ErrorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	p, ok := api.RequireVersion(w, r, req.Version)
+	if !ok {
+		return
+	}
+
+	update := models.StatusUpdate{
+		Version:     p.Version,
+		Status:      models.ProductStatus(req.Status),
+		PublishAt:   utcOrNil(req.PublishAt),
+		UnpublishAt: utcOrNil(req.UnpublishAt),
+	}
+
+	if update.Status != "" && !update.Status.Valid() {
+		api.ErrorResponse(w, http.StatusBadRequest, "Invalid status")
+		return
+	}
+
+	if update.PublishAt != nil && update.UnpublishAt != nil && !update.UnpublishAt.After(*update.PublishAt) {
+		api.ErrorResponse(w, http.StatusBadRequest, "unpublish_at must be after publish_at")
+		return
+	}
+
+	product, err := h.repo.UpdateStatus(r.Context(), code, update)
+	if err != nil {
+		if errors.Is(err, models.ErrInvalidTransition) {
+			api.ErrorResponse(w, http.StatusConflict, err.Error())
+			return
+		}
+		writeRepositoryError(w, r, err, "Product not found", p)
+		return
+	}
+
+	pricer, err := h.newPricer(r.Context())
+	if err != nil {
+		api.InternalError(w, r, err)
+		return
+	}
+
+	api.VersionedResponse(w, product.Version, mapProductToDetailResponse(*product, pricer))
+}
+
+func utcOrNil(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	utc := t.UTC()
+	return &utc
+}
@@ -0,0 +1,127 @@
+package catalog
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/mytheresa/go-hiring-challenge/models"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func TestCatalogHandleUpdateStatus(t *testing.T) {
+	t.Run("publishes a product with an unpublish schedule", func(t *testing.T) {
+		mockRepo := new(MockProductsRepository)
+		unpublishAt := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+
+		updated := &models.Product{ID: 1, Code: "PROD001", Price: decimal.NewFromFloat(10), Status: models.StatusPublished, UnpublishAt: &unpublishAt}
+		mockRepo.On("UpdateStatus", mock.Anything, "PROD001", mock.MatchedBy(func(u models.StatusUpdate) bool {
+			return u.Version == 1 && u.Status == models.StatusPublished && u.PublishAt == nil && u.UnpublishAt.Equal(unpublishAt)
+		})).Return(updated, nil)
+
+		handler := NewCatalogHandler(mockRepo, noPromotions())
+		recorder := httptest.NewRecorder()
+		body := `{"version":1,"status":"published","unpublish_at":"2030-01-01T00:00:00Z"}`
+		request := httptest.NewRequest("PATCH", "/admin/catalog/PROD001/status", bytes.NewBufferString(body))
+		request.SetPathValue("code", "PROD001")
+
+		handler.HandleUpdateStatus(recorder, asAdmin(request))
+
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		assert.Contains(t, recorder.Body.String(), `"status":"published","unpublish_at":"2030-01-01T00:00:00Z"`)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("accepts the ETag of a product read as If-Match", func(t *testing.T) {
+		mockRepo := new(MockProductsRepository)
+		product := &models.Product{ID: 1, Code: "PROD001", Price: decimal.NewFromFloat(10), Status: models.StatusPublished, Version: 4}
+		mockRepo.On("GetProductByCode", mock.Anything, "PROD001", models.StatusPublished).Return(product, nil)
+		archived := *product
+		archived.Status, archived.Version = models.StatusArchived, 5
+		mockRepo.On("UpdateStatus", mock.Anything, "PROD001", mock.MatchedBy(func(u models.StatusUpdate) bool {
+			return u.Version == 4 && u.Status == models.StatusArchived
+		})).Return(&archived, nil)
+
+		handler := NewCatalogHandler(mockRepo, noPromotions())
+		read := httptest.NewRecorder()
+		request := httptest.NewRequest("GET", "/catalog/PROD001", nil)
+		request.SetPathValue("code", "PROD001")
+		handler.HandleGetByCode(read, request)
+		require.Equal(t, http.StatusOK, read.Code)
+
+		recorder := httptest.NewRecorder()
+		request = httptest.NewRequest("PATCH", "/admin/catalog/PROD001/status", bytes.NewBufferString(`{"status":"archived"}`))
+		request.SetPathValue("code", "PROD001")
+		request.Header.Set("If-Match", read.Header().Get("ETag"))
+
+		handler.HandleUpdateStatus(recorder, asAdmin(request))
+
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		assert.Regexp(t, `^"5-[0-9a-f]{32}"$`, recorder.Header().Get("ETag"))
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("returns 409 for a disallowed transition", func(t *testing.T) {
+		mockRepo := new(MockProductsRepository)
+		mockRepo.On("UpdateStatus", mock.Anything, "PROD001", mock.Anything).Return(nil, fmt.Errorf("%w: archived to published", models.ErrInvalidTransition))
+
+		handler := NewCatalogHandler(mockRepo, noPromotions())
+		recorder := httptest.NewRecorder()
+		request := httptest.NewRequest("PATCH", "/admin/catalog/PROD001/status", bytes.NewBufferString(`{"version":1,"status":"published"}`))
+		request.SetPathValue("code", "PROD001")
+
+		handler.HandleUpdateStatus(recorder, asAdmin(request))
+
+		assert.Equal(t, http.StatusConflict, recorder.Code)
+		assert.Contains(t, recorder.Body.String(), "invalid status transition")
+	})
+
+	t.Run("returns 404 when product not found", func(t *testing.T) {
+		mockRepo := new(MockProductsRepository)
+		mockRepo.On("UpdateStatus", mock.Anything, "INVALID", mock.Anything).Return(nil, gorm.ErrRecordNotFound)
+
+		handler := NewCatalogHandler(mockRepo, noPromotions())
+		recorder := httptest.NewRecorder()
+		request := httptest.NewRequest("PATCH", "/admin/catalog/INVALID/status", bytes.NewBufferString(`{"version":1,"status":"archived"}`))
+		request.SetPathValue("code", "INVALID")
+
+		handler.HandleUpdateStatus(recorder, asAdmin(request))
+
+		assert.Equal(t, http.StatusNotFound, recorder.Code)
+	})
+
+	t.Run("returns 400 when the schedule is inverted", func(t *testing.T) {
+		mockRepo := new(MockProductsRepository)
+
+		handler := NewCatalogHandler(mockRepo, noPromotions())
+		recorder := httptest.NewRecorder()
+		body := `{"version":1,"publish_at":"2030-01-02T00:00:00Z","unpublish_at":"2030-01-01T00:00:00Z"}`
+		request := httptest.NewRequest("PATCH", "/admin/catalog/PROD001/status", bytes.NewBufferString(body))
+		request.SetPathValue("code", "PROD001")
+
+		handler.HandleUpdateStatus(recorder, asAdmin(request))
+
+		assert.Equal(t, http.StatusBadRequest, recorder.Code)
+		mockRepo.AssertNotCalled(t, "UpdateStatus", mock.Anything, mock.Anything)
+	})
+
+	t.Run("returns 400 for an unknown status", func(t *testing.T) {
+		mockRepo := new(MockProductsRepository)
+
+		handler := NewCatalogHandler(mockRepo, noPromotions())
+		recorder := httptest.NewRecorder()
+		request := httptest.NewRequest("PATCH", "/admin/catalog/PROD001/status", bytes.NewBufferString(`{"version":1,"status":"live"}`))
+		request.SetPathValue("code", "PROD001")
+
+		handler.HandleUpdateStatus(recorder, asAdmin(request))
+
+		assert.Equal(t, http.StatusBadRequest, recorder.Code)
+	})
+}
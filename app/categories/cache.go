@@ -0,0 +1,72 @@
+package categories
+
+import (
+	"context"
+
+	"github.com/mytheresa/go-hiring-challenge/app/cache"
+	"github.com/mytheresa/go-hiring-challenge/models"
+)
+
+// CachedCategoriesRepository is a read-through cache in front of a CategoriesRepository.
+// Every write retires the cached categories along with the dependent caches,
+// such as the catalog, whose entries embed category data. Entries are filled
+// from the primary, so a lagging replica cannot cache rows the write replaced.
+type CachedCategoriesRepository struct {
+	next       CategoriesRepository
+	cache      *cache.ReadThrough
+	dependents []*cache.ReadThrough
+}
+
+var _ CategoriesRepository = (*CachedCategoriesRepository)(nil)
+
+func NewCachedCategoriesRepository(next CategoriesRepository, c *cache.ReadThrough, dependents ...*cache.ReadThrough) *CachedCategoriesRepository {
+	return &CachedCategoriesRepository{
+		next:       next,
+		cache:      c,
+		dependents: dependents,
+	}
+}
+
+func (c *CachedCategoriesRepository) GetAll(ctx context.Context) ([]models.Category, error) {
+	var categories []models.Category
+	err := c.cache.Load(ctx, "all", &categories, func(ctx context.Context) (any, error) {
+		return c.next.GetAll(models.WithPrimary(ctx))
+	})
+	if err != nil {
+		return nil, err
+	}
+	return categories, nil
+}
+
+func (c *CachedCategoriesRepository) FindByCode(ctx context.Context, code string) (*models.Category, error) {
+	var category *models.Category
+	err := c.cache.Load(ctx, "code:"+code, &category, func(ctx context.Context) (any, error) {
+		return c.next.FindByCode(models.WithPrimary(ctx), code)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return category, nil
+}
+
+func (c *CachedCategoriesRepository) Create(ctx context.Context, category *models.Category) error {
+	defer c.invalidate(ctx)
+	return c.next.Create(ctx, category)
+}
+
+func (c *CachedCategoriesRepository) Delete(ctx context.Context, code string, version uint) (*models.Category, error) {
+	defer c.invalidate(ctx)
+	return c.next.Delete(ctx, code, version)
+}
+
+func (c *CachedCategoriesRepository) Restore(ctx context.Context, code string) (*models.Category, error) {
+	defer c.invalidate(ctx)
+	return c.next.Restore(ctx, code)
+}
+
+func (c *CachedCategoriesRepository) invalidate(ctx context.Context) {
+	c.cache.Invalidate(ctx)
+	for _, d := range c.dependents {
+		d.Invalidate(ctx)
+	}
+}
@@ -0,0 +1,70 @@
+package categories
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/mytheresa/go-hiring-challenge/app/cache"
+	"github.com/mytheresa/go-hiring-challenge/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestCachedCategoriesRepository(t *testing.T) {
+	categories := []models.Category{
+		{ID: 1, Code: "clothing", Name: "Clothing", Version: 1},
+		{ID: 2, Code: "shoes", Name: "Shoes", Version: 3},
+	}
+
+	t.Run("serves repeated reads from the cache", func(t *testing.T) {
+		mockRepo := new(MockCategoriesRepository)
+		mockRepo.On("GetAll", mock.Anything, mock.Anything).Return(categories, nil).Once()
+		mockRepo.On("FindByCode", mock.Anything, "shoes").Return(&categories[1], nil).Once()
+
+		backend := cache.NewMemory(10)
+		cached := NewCachedCategoriesRepository(mockRepo, cache.NewReadThrough(backend, "categories", time.Minute, 0))
+
+		for range 2 {
+			got, err := cached.GetAll(context.Background())
+			assert.NoError(t, err)
+			assert.Equal(t, categories, got)
+
+			category, err := cached.FindByCode(context.Background(), "shoes")
+			assert.NoError(t, err)
+			assert.Equal(t, &categories[1], category)
+		}
+
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("invalidates itself and its dependents on writes", func(t *testing.T) {
+		mockRepo := new(MockCategoriesRepository)
+		mockRepo.On("GetAll", mock.Anything, mock.Anything).Return(categories, nil).Twice()
+		mockRepo.On("Create", mock.Anything, mock.Anything).Return(nil)
+
+		backend := cache.NewMemory(10)
+		catalog := cache.NewReadThrough(backend, "catalog", time.Minute, 0)
+		cached := NewCachedCategoriesRepository(mockRepo, cache.NewReadThrough(backend, "categories", time.Minute, 0), catalog)
+
+		catalogLoads := 0
+		loadCatalog := func() {
+			var v []string
+			_ = catalog.Load(context.Background(), "list", &v, func(context.Context) (any, error) {
+				catalogLoads++
+				return []string{"PROD001"}, nil
+			})
+		}
+
+		_, _ = cached.GetAll(context.Background())
+		loadCatalog()
+
+		assert.NoError(t, cached.Create(context.Background(), &models.Category{Code: "bags", Name: "Bags"}))
+
+		_, _ = cached.GetAll(context.Background())
+		loadCatalog()
+
+		assert.Equal(t, 2, catalogLoads)
+		mockRepo.AssertExpectations(t)
+	})
+}
@@ -1,20 +1,33 @@
 package categories
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
+	"net/url"
+	"time"
 
 	"github.com/mytheresa/go-hiring-challenge/app/api"
+	"github.com/mytheresa/go-hiring-challenge/app/auth"
 	"github.com/mytheresa/go-hiring-challenge/models"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"gorm.io/gorm"
 )
 
 // CategoriesRepository defines the interface for accessing category data
 type CategoriesRepository interface {
-	GetAll() ([]models.Category, error)
-	Create(category *models.Category) error
-	FindByCode(code string) (*models.Category, error)
+	GetAll(ctx context.Context) ([]models.Category, error)
+	Create(ctx context.Context, category *models.Category) error
+	FindByCode(ctx context.Context, code string) (*models.Category, error)
+	Delete(ctx context.Context, code string, version uint) (*models.Category, error)
+	Restore(ctx context.Context, code string) (*models.Category, error)
 }
 
+// tracer records loading the categories apart from encoding the response.
+var tracer = otel.Tracer("github.com/mytheresa/go-hiring-challenge/app/categories")
+
 type CategoriesHandler struct {
 	repo CategoriesRepository
 }
@@ -26,8 +39,11 @@ func NewCategoriesHandler(r CategoriesRepository) *CategoriesHandler {
 }
 
 type CategoryResponse struct {
-	Code string `json:"code"`
-	Name string `json:"name"`
+	Code      string    `json:"code"`
+	Name      string    `json:"name"`
+	Version   uint      `json:"version"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
 }
 
 type CategoriesListResponse struct {
@@ -41,17 +57,27 @@ type CreateCategoryRequest struct {
 
 // HandleList returns all categories.
 func (h *CategoriesHandler) HandleList(w http.ResponseWriter, r *http.Request) {
-	categories, err := h.repo.GetAll()
+	ctx, span := tracer.Start(r.Context(), "categories.load")
+	categories, err := h.repo.GetAll(ctx)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
 	if err != nil {
-		api.ErrorResponse(w, http.StatusInternalServerError, err.Error())
+		api.InternalError(w, r, err)
 		return
 	}
 
+	_, span = tracer.Start(r.Context(), "categories.encode")
+	defer span.End()
+
+	var lastModified time.Time
 	categoryResponses := make([]CategoryResponse, len(categories))
 	for i, c := range categories {
-		categoryResponses[i] = CategoryResponse{
-			Code: c.Code,
-			Name: c.Name,
+		categoryResponses[i] = mapCategoryToResponse(c)
+		if c.UpdatedAt.After(lastModified) {
+			lastModified = c.UpdatedAt
 		}
 	}
 
@@ -59,6 +85,7 @@ func (h *CategoriesHandler) HandleList(w http.ResponseWriter, r *http.Request) {
 		Categories: categoryResponses,
 	}
 
+	api.SetLastModified(w, lastModified)
 	api.OKResponse(w, response)
 }
 
@@ -69,6 +96,10 @@ func (h *CategoriesHandler) HandleCreate(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	if !auth.Authorize(w, r, auth.PermissionCreateCategories) {
+		return
+	}
+
 	var req CreateCategoryRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		api.ErrorResponse(w, http.StatusBadRequest, "Invalid request body")
@@ -85,15 +116,83 @@ func (h *CategoriesHandler) HandleCreate(w http.ResponseWriter, r *http.Request)
 		Name: req.Name,
 	}
 
-	if err := h.repo.Create(category); err != nil {
-		api.ErrorResponse(w, http.StatusInternalServerError, err.Error())
+	if err := h.repo.Create(r.Context(), category); err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			api.ErrorResponse(w, http.StatusConflict, "Category code already exists")
+			return
+		}
+		if errors.Is(err, models.ErrCategoryDeleted) {
+			api.ProblemResponse(w, http.StatusConflict, deletedCategoryProblem{
+				Problem: api.NewProblem(http.StatusConflict, "Category code belongs to a deleted category, which can be restored instead."),
+				Restore: "/categories/" + url.PathEscape(req.Code) + "/restore",
+			})
+			return
+		}
+		api.InternalError(w, r, err)
 		return
 	}
 
-	response := CategoryResponse{
-		Code: category.Code,
-		Name: category.Name,
+	api.VersionedResponse(w, category.Version, mapCategoryToResponse(*category))
+}
+
+// deletedCategoryProblem answers the creation of a category whose code
+// belongs to a deleted one, pointing at the endpoint restoring it.
+type deletedCategoryProblem struct {
+	api.Problem
+	Restore string `json:"restore"`
+}
+
+// HandleDelete soft-deletes a category.
+// The caller must send the category version it last read.
+func (h *CategoriesHandler) HandleDelete(w http.ResponseWriter, r *http.Request) {
+	if !auth.Authorize(w, r, auth.PermissionDeleteCategories) {
+		return
 	}
 
-	api.OKResponse(w, response)
+	p, ok := api.RequireVersion(w, r, nil)
+	if !ok {
+		return
+	}
+
+	writeCategory(w, r, "Category not found", p, func() (*models.Category, error) {
+		return h.repo.Delete(r.Context(), r.PathValue("code"), p.Version)
+	})
+}
+
+// HandleRestore restores a soft-deleted category.
+func (h *CategoriesHandler) HandleRestore(w http.ResponseWriter, r *http.Request) {
+	if !auth.Authorize(w, r, auth.PermissionDeleteCategories) {
+		return
+	}
+
+	writeCategory(w, r, "Deleted category not found", api.Precondition{}, func() (*models.Category, error) {
+		return h.repo.Restore(r.Context(), r.PathValue("code"))
+	})
+}
+
+func writeCategory(w http.ResponseWriter, r *http.Request, notFound string, p api.Precondition, op func() (*models.Category, error)) {
+	category, err := op()
+	if err != nil {
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			api.ErrorResponse(w, http.StatusNotFound, notFound)
+		case errors.Is(err, models.ErrVersionConflict):
+			api.VersionConflictResponse(w, p)
+		default:
+			api.InternalError(w, r, err)
+		}
+		return
+	}
+
+	api.VersionedResponse(w, category.Version, mapCategoryToResponse(*category))
+}
+
+func mapCategoryToResponse(c models.Category) CategoryResponse {
+	return CategoryResponse{
+		Code:      c.Code,
+		Name:      c.Name,
+		Version:   c.Version,
+		CreatedAt: c.CreatedAt,
+		UpdatedAt: c.UpdatedAt,
+	}
 }
@@ -1,15 +1,19 @@
 package categories
 
 import (
+	"context"
 	"bytes"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/mytheresa/go-hiring-challenge/app/auth"
 	"github.com/mytheresa/go-hiring-challenge/models"
+	"gorm.io/gorm"
 )
 
 // MockCategoriesRepository is a mock implementation of CategoriesRepository
@@ -17,27 +21,54 @@ type MockCategoriesRepository struct {
 	mock.Mock
 }
 
-func (m *MockCategoriesRepository) GetAll() ([]models.Category, error) {
-	args := m.Called()
+func (m *MockCategoriesRepository) GetAll(ctx context.Context) ([]models.Category, error) {
+	args := m.Called(ctx)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
 	return args.Get(0).([]models.Category), args.Error(1)
 }
 
-func (m *MockCategoriesRepository) Create(category *models.Category) error {
-	args := m.Called(category)
+func (m *MockCategoriesRepository) Create(ctx context.Context, category *models.Category) error {
+	args := m.Called(ctx, category)
 	return args.Error(0)
 }
 
-func (m *MockCategoriesRepository) FindByCode(code string) (*models.Category, error) {
-	args := m.Called(code)
+func (m *MockCategoriesRepository) FindByCode(ctx context.Context, code string) (*models.Category, error) {
+	args := m.Called(ctx, code)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
 	return args.Get(0).(*models.Category), args.Error(1)
 }
 
+func (m *MockCategoriesRepository) Delete(ctx context.Context, code string, version uint) (*models.Category, error) {
+	args := m.Called(ctx, code, version)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Category), args.Error(1)
+}
+
+func (m *MockCategoriesRepository) Restore(ctx context.Context, code string) (*models.Category, error) {
+	args := m.Called(ctx, code)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Category), args.Error(1)
+}
+
+// withRole authenticates request as a client with role and every scope.
+func withRole(request *http.Request, role string) *http.Request {
+	principal := &auth.Principal{Subject: "apikey:mhc_test", Roles: []string{role}, Scopes: auth.Scopes}
+	return request.WithContext(auth.WithPrincipal(request.Context(), principal))
+}
+
+// asAdmin authenticates request as a client allowed every operation.
+func asAdmin(request *http.Request) *http.Request {
+	return withRole(request, auth.RoleAdmin)
+}
+
 func TestCategoriesHandleList(t *testing.T) {
 	t.Run("returns all categories", func(t *testing.T) {
 		mockRepo := new(MockCategoriesRepository)
@@ -60,7 +91,7 @@ func TestCategoriesHandleList(t *testing.T) {
 			},
 		}
 
-		mockRepo.On("GetAll").Return(categories, nil)
+		mockRepo.On("GetAll", mock.Anything, mock.Anything).Return(categories, nil)
 
 		handler := NewCategoriesHandler(mockRepo)
 		recorder := httptest.NewRecorder()
@@ -79,9 +110,27 @@ func TestCategoriesHandleList(t *testing.T) {
 		mockRepo.AssertExpectations(t)
 	})
 
+	t.Run("returns category timestamps", func(t *testing.T) {
+		mockRepo := new(MockCategoriesRepository)
+		createdAt := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+		mockRepo.On("GetAll", mock.Anything, mock.Anything).Return([]models.Category{
+			{ID: 1, Code: "clothing", Name: "Clothing", CreatedAt: createdAt, UpdatedAt: createdAt.Add(time.Hour)},
+		}, nil)
+
+		handler := NewCategoriesHandler(mockRepo)
+		recorder := httptest.NewRecorder()
+		request := httptest.NewRequest("GET", "/categories", nil)
+
+		handler.HandleList(recorder, request)
+
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		assert.Contains(t, recorder.Body.String(), `"created_at":"2025-01-02T03:04:05Z","updated_at":"2025-01-02T04:04:05Z"`)
+		mockRepo.AssertExpectations(t)
+	})
+
 	t.Run("returns empty categories list", func(t *testing.T) {
 		mockRepo := new(MockCategoriesRepository)
-		mockRepo.On("GetAll").Return([]models.Category{}, nil)
+		mockRepo.On("GetAll", mock.Anything, mock.Anything).Return([]models.Category{}, nil)
 
 		handler := NewCategoriesHandler(mockRepo)
 		recorder := httptest.NewRecorder()
@@ -99,7 +148,7 @@ func TestCategoriesHandleCreate(t *testing.T) {
 	t.Run("creates a new category", func(t *testing.T) {
 		mockRepo := new(MockCategoriesRepository)
 
-		mockRepo.On("Create", mock.MatchedBy(func(c *models.Category) bool {
+		mockRepo.On("Create", mock.Anything, mock.MatchedBy(func(c *models.Category) bool {
 			return c.Code == "electronics" && c.Name == "Electronics"
 		})).Return(nil)
 
@@ -113,7 +162,7 @@ func TestCategoriesHandleCreate(t *testing.T) {
 		bodyBytes, _ := json.Marshal(reqBody)
 		request := httptest.NewRequest("POST", "/categories", bytes.NewReader(bodyBytes))
 
-		handler.HandleCreate(recorder, request)
+		handler.HandleCreate(recorder, asAdmin(request))
 
 		assert.Equal(t, http.StatusOK, recorder.Code)
 		assert.Equal(t, "application/json", recorder.Header().Get("Content-Type"))
@@ -134,7 +183,7 @@ func TestCategoriesHandleCreate(t *testing.T) {
 		bodyBytes, _ := json.Marshal(reqBody)
 		request := httptest.NewRequest("POST", "/categories", bytes.NewReader(bodyBytes))
 
-		handler.HandleCreate(recorder, request)
+		handler.HandleCreate(recorder, asAdmin(request))
 
 		assert.Equal(t, http.StatusBadRequest, recorder.Code)
 		assert.Contains(t, recorder.Body.String(), "Code and name are required")
@@ -152,7 +201,7 @@ func TestCategoriesHandleCreate(t *testing.T) {
 		bodyBytes, _ := json.Marshal(reqBody)
 		request := httptest.NewRequest("POST", "/categories", bytes.NewReader(bodyBytes))
 
-		handler.HandleCreate(recorder, request)
+		handler.HandleCreate(recorder, asAdmin(request))
 
 		assert.Equal(t, http.StatusBadRequest, recorder.Code)
 		assert.Contains(t, recorder.Body.String(), "Code and name are required")
@@ -166,9 +215,159 @@ func TestCategoriesHandleCreate(t *testing.T) {
 
 		request := httptest.NewRequest("POST", "/categories", bytes.NewReader([]byte("invalid json")))
 
-		handler.HandleCreate(recorder, request)
+		handler.HandleCreate(recorder, asAdmin(request))
 
 		assert.Equal(t, http.StatusBadRequest, recorder.Code)
 		assert.Contains(t, recorder.Body.String(), "Invalid request body")
 	})
+
+	t.Run("returns 409 when the code already exists", func(t *testing.T) {
+		mockRepo := new(MockCategoriesRepository)
+		mockRepo.On("Create", mock.Anything, mock.Anything).Return(gorm.ErrDuplicatedKey)
+
+		handler := NewCategoriesHandler(mockRepo)
+		recorder := httptest.NewRecorder()
+
+		request := httptest.NewRequest("POST", "/categories", bytes.NewReader([]byte(`{"code":"shoes","name":"Shoes"}`)))
+
+		handler.HandleCreate(recorder, asAdmin(request))
+
+		assert.Equal(t, http.StatusConflict, recorder.Code)
+		assert.Contains(t, recorder.Body.String(), "Category code already exists")
+	})
+
+	t.Run("points to the restore endpoint when the code belongs to a deleted category", func(t *testing.T) {
+		mockRepo := new(MockCategoriesRepository)
+		mockRepo.On("Create", mock.Anything, mock.Anything).Return(models.ErrCategoryDeleted)
+
+		handler := NewCategoriesHandler(mockRepo)
+		recorder := httptest.NewRecorder()
+
+		request := httptest.NewRequest("POST", "/categories", bytes.NewReader([]byte(`{"code":"shoes","name":"Shoes"}`)))
+
+		handler.HandleCreate(recorder, asAdmin(request))
+
+		assert.Equal(t, http.StatusConflict, recorder.Code)
+		assert.Equal(t, "application/problem+json", recorder.Header().Get("Content-Type"))
+		assert.JSONEq(t, `{
+			"type": "about:blank",
+			"title": "Conflict",
+			"status": 409,
+			"detail": "Category code belongs to a deleted category, which can be restored instead.",
+			"restore": "/categories/shoes/restore"
+		}`, recorder.Body.String())
+	})
+}
+
+func TestCategoriesHandleDelete(t *testing.T) {
+	t.Run("soft-deletes a category", func(t *testing.T) {
+		mockRepo := new(MockCategoriesRepository)
+		mockRepo.On("Delete", mock.Anything, "shoes", uint(2)).Return(&models.Category{ID: 2, Code: "shoes", Name: "Shoes", Version: 3}, nil)
+
+		handler := NewCategoriesHandler(mockRepo)
+		recorder := httptest.NewRecorder()
+		request := httptest.NewRequest("DELETE", "/categories/shoes", nil)
+		request.SetPathValue("code", "shoes")
+		request.Header.Set("If-Match", `"2"`)
+
+		handler.HandleDelete(recorder, asAdmin(request))
+
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		assert.Contains(t, recorder.Body.String(), "Shoes")
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("returns 404 when category not found", func(t *testing.T) {
+		mockRepo := new(MockCategoriesRepository)
+		mockRepo.On("Delete", mock.Anything, "unknown", uint(1)).Return(nil, gorm.ErrRecordNotFound)
+
+		handler := NewCategoriesHandler(mockRepo)
+		recorder := httptest.NewRecorder()
+		request := httptest.NewRequest("DELETE", "/categories/unknown", nil)
+		request.SetPathValue("code", "unknown")
+		request.Header.Set("If-Match", `"1"`)
+
+		handler.HandleDelete(recorder, asAdmin(request))
+
+		assert.Equal(t, http.StatusNotFound, recorder.Code)
+		assert.Contains(t, recorder.Body.String(), "Category not found")
+	})
+
+	t.Run("forbids editors to delete categories", func(t *testing.T) {
+		mockRepo := new(MockCategoriesRepository)
+
+		handler := NewCategoriesHandler(mockRepo)
+		recorder := httptest.NewRecorder()
+		request := httptest.NewRequest("DELETE", "/categories/shoes", nil)
+		request.SetPathValue("code", "shoes")
+		request.Header.Set("If-Match", `"2"`)
+
+		handler.HandleDelete(recorder, withRole(request, auth.RoleEditor))
+
+		assert.Equal(t, http.StatusForbidden, recorder.Code)
+		assert.Equal(t, "application/problem+json", recorder.Header().Get("Content-Type"))
+		assert.Contains(t, recorder.Body.String(), `"permission":"categories.delete"`)
+		mockRepo.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("returns 412 when the category changed since it was read", func(t *testing.T) {
+		mockRepo := new(MockCategoriesRepository)
+		mockRepo.On("Delete", mock.Anything, "shoes", uint(1)).Return(nil, models.ErrVersionConflict)
+
+		handler := NewCategoriesHandler(mockRepo)
+		recorder := httptest.NewRecorder()
+		request := httptest.NewRequest("DELETE", "/categories/shoes", nil)
+		request.SetPathValue("code", "shoes")
+		request.Header.Set("If-Match", `"1"`)
+
+		handler.HandleDelete(recorder, asAdmin(request))
+
+		assert.Equal(t, http.StatusPreconditionFailed, recorder.Code)
+	})
+
+	t.Run("returns 428 without If-Match", func(t *testing.T) {
+		mockRepo := new(MockCategoriesRepository)
+
+		handler := NewCategoriesHandler(mockRepo)
+		recorder := httptest.NewRecorder()
+		request := httptest.NewRequest("DELETE", "/categories/shoes", nil)
+		request.SetPathValue("code", "shoes")
+
+		handler.HandleDelete(recorder, asAdmin(request))
+
+		assert.Equal(t, http.StatusPreconditionRequired, recorder.Code)
+		mockRepo.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything)
+	})
+}
+
+func TestCategoriesHandleRestore(t *testing.T) {
+	t.Run("restores a deleted category", func(t *testing.T) {
+		mockRepo := new(MockCategoriesRepository)
+		mockRepo.On("Restore", mock.Anything, "shoes").Return(&models.Category{ID: 2, Code: "shoes", Name: "Shoes"}, nil)
+
+		handler := NewCategoriesHandler(mockRepo)
+		recorder := httptest.NewRecorder()
+		request := httptest.NewRequest("POST", "/categories/shoes/restore", nil)
+		request.SetPathValue("code", "shoes")
+
+		handler.HandleRestore(recorder, asAdmin(request))
+
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		assert.Contains(t, recorder.Body.String(), "shoes")
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("returns 500 on repository failure", func(t *testing.T) {
+		mockRepo := new(MockCategoriesRepository)
+		mockRepo.On("Restore", mock.Anything, "shoes").Return(nil, assert.AnError)
+
+		handler := NewCategoriesHandler(mockRepo)
+		recorder := httptest.NewRecorder()
+		request := httptest.NewRequest("POST", "/categories/shoes/restore", nil)
+		request.SetPathValue("code", "shoes")
+
+		handler.HandleRestore(recorder, asAdmin(request))
+
+		assert.Equal(t, http.StatusInternalServerError, recorder.Code)
+	})
 }
@@ -0,0 +1,319 @@
+// Package compression compresses response bodies with the best content coding
+// the client accepts.
+package compression
+
+import (
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/andybalholm/brotli"
+
+	"github.com/mytheresa/go-hiring-challenge/app/middleware"
+)
+
+// Content codings accepted in Options.Encodings.
+const (
+	EncodingBrotli = "br"
+	EncodingGzip   = "gzip"
+)
+
+// Options configures response compression.
+type Options struct {
+	// Encodings are the content codings offered, in order of preference when
+	// the client accepts several equally. No encodings disable compression.
+	Encodings []string
+	// MinSize is the smallest body compressed; smaller bodies gain too
+	// little to be worth it.
+	MinSize int
+}
+
+// Validate reports unknown encodings and a negative MinSize.
+func (o Options) Validate() error {
+	for _, encoding := range o.Encodings {
+		if _, ok := encoders[encoding]; !ok {
+			return fmt.Errorf("unknown compression encoding %q, expected br or gzip", encoding)
+		}
+	}
+	if o.MinSize < 0 {
+		return errors.New("compression minimum size must not be negative")
+	}
+	return nil
+}
+
+// encoder pools the compressors of a content coding.
+type encoder struct {
+	pool sync.Pool
+}
+
+func newEncoder(create func() compressor) *encoder {
+	return &encoder{pool: sync.Pool{New: func() any { return create() }}}
+}
+
+type compressor interface {
+	io.WriteCloser
+	Reset(io.Writer)
+}
+
+// Brotli is used at a low level, which compresses JSON nearly as well as
+// the higher ones at a fraction of their cost.
+var encoders = map[string]*encoder{
+	EncodingBrotli: newEncoder(func() compressor { return brotli.NewWriterLevel(nil, 4) }),
+	EncodingGzip:   newEncoder(func() compressor { return gzip.NewWriter(nil) }),
+}
+
+// compressible lists the media types worth compressing.
+var compressible = []string{
+	"application/json",
+	"application/problem+json",
+	"application/xml",
+	"image/svg+xml",
+}
+
+// New returns middleware compressing the responses of clients that accept
+// one of the encodings of opts. Only textual media types are compressed, and
+// only once the body reaches MinSize; HEAD requests, bodiless statuses,
+// responses already encoded and responses marked no-transform are left
+// alone. Every response carries Vary: Accept-Encoding, so shared caches keep
+// the encodings apart.
+//
+// A compressed response is a representation of its own, so its ETag gets
+// the encoding as suffix, e.g. "abc-gzip". The suffix is removed from
+// If-None-Match before the request is passed on, so api.Conditional compares
+// the tags it computed, and a 304 response repeats the tag the client sent.
+func New(opts Options) middleware.Middleware {
+	if len(opts.Encodings) == 0 {
+		return func(next http.Handler) http.Handler { return next }
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Add("Vary", "Accept-Encoding")
+			encoding := negotiate(r.Header.Get("Accept-Encoding"), opts.Encodings)
+			if encoding == "" || r.Method == http.MethodHead {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			cw := &compressWriter{
+				ResponseWriter: w,
+				encoding:       encoding,
+				minSize:        opts.MinSize,
+				sent:           stripETagSuffixes(r.Header),
+			}
+			defer cw.close()
+			next.ServeHTTP(cw, r)
+		})
+	}
+}
+
+// negotiate returns the encoding of offered the Accept-Encoding header
+// prefers, or "" when it accepts none of them. Offers accepted with equal
+// quality are chosen in the order offered.
+func negotiate(header string, offered []string) string {
+	if header == "" {
+		return ""
+	}
+	qualities := map[string]float64{}
+	for _, item := range strings.Split(header, ",") {
+		coding, params, _ := strings.Cut(item, ";")
+		q := 1.0
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+		qualities[strings.ToLower(strings.TrimSpace(coding))] = q
+	}
+
+	best, bestQ := "", 0.0
+	for _, encoding := range offered {
+		q, ok := qualities[encoding]
+		if !ok {
+			q = qualities["*"]
+		}
+		if q > bestQ {
+			best, bestQ = encoding, q
+		}
+	}
+	return best
+}
+
+// stripETagSuffixes removes the encoding suffixes from the entity tags of
+// If-None-Match. It returns the tags the client sent, keyed by the tag they
+// were stripped to.
+func stripETagSuffixes(h http.Header) map[string]string {
+	header := h.Get("If-None-Match")
+	if header == "" {
+		return nil
+	}
+	sent := map[string]string{}
+	tags := strings.Split(header, ",")
+	for i, tag := range tags {
+		tag = strings.TrimSpace(tag)
+		stripped := tag
+		for encoding := range encoders {
+			if s, ok := strings.CutSuffix(tag, "-"+encoding+`"`); ok {
+				stripped = s + `"`
+				sent[strings.TrimPrefix(stripped, "W/")] = tag
+				break
+			}
+		}
+		tags[i] = stripped
+	}
+	h.Set("If-None-Match", strings.Join(tags, ", "))
+	return sent
+}
+
+// compressWriter holds the body back until it reaches the minimum size, then
+// decides whether to compress it.
+type compressWriter struct {
+	http.ResponseWriter
+	encoding string
+	minSize  int
+	sent     map[string]string
+
+	status     int
+	buf        []byte
+	decided    bool
+	compressor compressor
+}
+
+func (cw *compressWriter) WriteHeader(status int) {
+	if cw.status != 0 {
+		return
+	}
+	if status < http.StatusOK {
+		cw.ResponseWriter.WriteHeader(status)
+		return
+	}
+	cw.status = status
+	if status == http.StatusNoContent || status == http.StatusNotModified {
+		if etag := cw.Header().Get("ETag"); etag != "" {
+			if tag, ok := cw.sent[strings.TrimPrefix(etag, "W/")]; ok {
+				cw.Header().Set("ETag", tag)
+			}
+		}
+		cw.decide(false)
+	}
+}
+
+func (cw *compressWriter) Write(b []byte) (int, error) {
+	if cw.status == 0 {
+		cw.WriteHeader(http.StatusOK)
+	}
+	if !cw.decided {
+		cw.buf = append(cw.buf, b...)
+		if len(cw.buf) < cw.minSize {
+			return len(b), nil
+		}
+		buf := cw.buf
+		cw.buf = nil
+		if err := cw.start(buf, cw.compressible()); err != nil {
+			return 0, err
+		}
+		return len(b), nil
+	}
+	if cw.compressor != nil {
+		return cw.compressor.Write(b)
+	}
+	return cw.ResponseWriter.Write(b)
+}
+
+// Flush sends what the handler wrote so far, compressed if it is large enough.
+func (cw *compressWriter) Flush() {
+	if cw.status == 0 {
+		cw.WriteHeader(http.StatusOK)
+	}
+	if !cw.decided {
+		buf := cw.buf
+		cw.buf = nil
+		cw.start(buf, len(buf) >= cw.minSize && cw.compressible())
+	}
+	if f, ok := cw.compressor.(interface{ Flush() error }); ok {
+		f.Flush()
+	}
+	http.NewResponseController(cw.ResponseWriter).Flush()
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer.
+func (cw *compressWriter) Unwrap() http.ResponseWriter {
+	return cw.ResponseWriter
+}
+
+// compressible reports whether the response may be compressed, judging by
+// its headers.
+func (cw *compressWriter) compressible() bool {
+	h := cw.Header()
+	if h.Get("Content-Encoding") != "" || strings.Contains(h.Get("Cache-Control"), "no-transform") {
+		return false
+	}
+	mediaType, _, err := mime.ParseMediaType(h.Get("Content-Type"))
+	if err != nil {
+		return false
+	}
+	return strings.HasPrefix(mediaType, "text/") || strings.HasSuffix(mediaType, "+json") ||
+		slices.Contains(compressible, mediaType)
+}
+
+// decide sends the headers, compressed or not, after which the body is
+// written straight through.
+func (cw *compressWriter) decide(compress bool) {
+	cw.decided = true
+	if compress {
+		h := cw.Header()
+		h.Set("Content-Encoding", cw.encoding)
+		h.Del("Content-Length")
+		if etag := h.Get("ETag"); strings.HasSuffix(etag, `"`) {
+			h.Set("ETag", strings.TrimSuffix(etag, `"`)+"-"+cw.encoding+`"`)
+		}
+		cw.compressor = encoders[cw.encoding].pool.Get().(compressor)
+		cw.compressor.Reset(cw.ResponseWriter)
+	}
+	cw.ResponseWriter.WriteHeader(cw.status)
+}
+
+// start decides and writes the body held back so far.
+func (cw *compressWriter) start(buf []byte, compress bool) error {
+	cw.decide(compress)
+	if len(buf) == 0 {
+		return nil
+	}
+	var err error
+	if cw.compressor != nil {
+		_, err = cw.compressor.Write(buf)
+	} else {
+		_, err = cw.ResponseWriter.Write(buf)
+	}
+	return err
+}
+
+// close writes a body that stayed below the minimum size as it is, or
+// finishes the compressed stream.
+func (cw *compressWriter) close() {
+	if !cw.decided {
+		if cw.status == 0 {
+			return
+		}
+		if len(cw.buf) > 0 {
+			cw.Header().Set("Content-Length", strconv.Itoa(len(cw.buf)))
+		}
+		cw.start(cw.buf, false)
+		return
+	}
+	if cw.compressor != nil {
+		cw.compressor.Close()
+		cw.compressor.Reset(nil)
+		encoders[cw.encoding].pool.Put(cw.compressor)
+		cw.compressor = nil
+	}
+}
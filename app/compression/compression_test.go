@@ -0,0 +1,208 @@
+package compression
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mytheresa/go-hiring-challenge/app/api"
+)
+
+var defaults = Options{Encodings: []string{EncodingBrotli, EncodingGzip}, MinSize: 1024}
+
+// catalogPage is a JSON body large enough to be compressed.
+var catalogPage = `{"products":[` + strings.Repeat(`{"code":"PROD001","price":10.99},`, 100) + `{}]}`
+
+func serve(opts Options, h http.HandlerFunc, r *http.Request) *httptest.ResponseRecorder {
+	res := httptest.NewRecorder()
+	New(opts)(h).ServeHTTP(res, r)
+	return res
+}
+
+func request(acceptEncoding string) *http.Request {
+	r := httptest.NewRequest(http.MethodGet, "/catalog", nil)
+	if acceptEncoding != "" {
+		r.Header.Set("Accept-Encoding", acceptEncoding)
+	}
+	return r
+}
+
+func catalog(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("ETag", `"abc"`)
+	io.WriteString(w, catalogPage)
+}
+
+func decode(t *testing.T, res *httptest.ResponseRecorder) string {
+	t.Helper()
+
+	var body io.Reader
+	switch res.Header().Get("Content-Encoding") {
+	case "gzip":
+		zr, err := gzip.NewReader(res.Body)
+		require.NoError(t, err)
+		body = zr
+	case "br":
+		body = brotli.NewReader(res.Body)
+	default:
+		body = res.Body
+	}
+	b, err := io.ReadAll(body)
+	require.NoError(t, err)
+	return string(b)
+}
+
+func TestNew(t *testing.T) {
+	t.Run("compresses with the preferred encoding", func(t *testing.T) {
+		tests := map[string]string{
+			"gzip, deflate, br":     "br",
+			"gzip":                  "gzip",
+			"br;q=0.5, gzip;q=0.8":  "gzip",
+			"GZIP":                  "gzip",
+			"*":                     "br",
+			"*;q=0.1, br;q=0":       "gzip",
+			"deflate, identity, zz": "",
+		}
+		for accept, encoding := range tests {
+			res := serve(defaults, catalog, request(accept))
+
+			assert.Equal(t, encoding, res.Header().Get("Content-Encoding"), accept)
+			assert.Equal(t, "Accept-Encoding", res.Header().Get("Vary"), accept)
+			assert.Equal(t, catalogPage, decode(t, res), accept)
+			if encoding != "" {
+				assert.Less(t, res.Body.Len(), len(catalogPage)/4, accept)
+			}
+		}
+	})
+
+	t.Run("follows the order of the encodings offered", func(t *testing.T) {
+		res := serve(Options{Encodings: []string{EncodingGzip, EncodingBrotli}}, catalog, request("br, gzip"))
+
+		assert.Equal(t, "gzip", res.Header().Get("Content-Encoding"))
+	})
+
+	t.Run("gives compressed representations their own ETag", func(t *testing.T) {
+		res := serve(defaults, catalog, request("gzip"))
+		assert.Equal(t, `"abc-gzip"`, res.Header().Get("ETag"))
+
+		res = serve(defaults, catalog, request(""))
+		assert.Equal(t, `"abc"`, res.Header().Get("ETag"))
+	})
+
+	t.Run("leaves small bodies alone", func(t *testing.T) {
+		res := serve(defaults, func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusCreated)
+			io.WriteString(w, `{"code":"shoes"}`)
+		}, request("gzip"))
+
+		assert.Equal(t, http.StatusCreated, res.Code)
+		assert.Empty(t, res.Header().Get("Content-Encoding"))
+		assert.Equal(t, "16", res.Header().Get("Content-Length"))
+		assert.Equal(t, `{"code":"shoes"}`, res.Body.String())
+		assert.Equal(t, "Accept-Encoding", res.Header().Get("Vary"))
+	})
+
+	t.Run("compresses bodies written in several parts", func(t *testing.T) {
+		res := serve(defaults, func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			for _, part := range strings.SplitAfter(catalogPage, "},") {
+				io.WriteString(w, part)
+			}
+		}, request("gzip"))
+
+		assert.Equal(t, "gzip", res.Header().Get("Content-Encoding"))
+		assert.Equal(t, catalogPage, decode(t, res))
+	})
+
+	skipped := map[string]func(w http.ResponseWriter){
+		"binary media types": func(w http.ResponseWriter) { w.Header().Set("Content-Type", "image/png") },
+		"already encoded":    func(w http.ResponseWriter) { w.Header().Set("Content-Encoding", "gzip") },
+		"no-transform":       func(w http.ResponseWriter) { w.Header().Set("Cache-Control", "private, no-transform") },
+	}
+	for name, setHeaders := range skipped {
+		t.Run("skips "+name, func(t *testing.T) {
+			res := serve(defaults, func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				setHeaders(w)
+				io.WriteString(w, catalogPage)
+			}, request("br, gzip"))
+
+			assert.NotEqual(t, "br", res.Header().Get("Content-Encoding"))
+			assert.Equal(t, catalogPage, res.Body.String())
+		})
+	}
+
+	t.Run("skips HEAD requests", func(t *testing.T) {
+		r := request("gzip")
+		r.Method = http.MethodHead
+
+		res := serve(defaults, catalog, r)
+
+		assert.Empty(t, res.Header().Get("Content-Encoding"))
+	})
+
+	t.Run("does nothing without encodings", func(t *testing.T) {
+		res := serve(Options{}, catalog, request("gzip"))
+
+		assert.Empty(t, res.Header().Get("Content-Encoding"))
+		assert.Empty(t, res.Header().Get("Vary"))
+	})
+}
+
+func TestNewConditional(t *testing.T) {
+	handler := api.Conditional(api.CachePolicy{}, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("ETag", api.ETag([]byte(catalogPage)))
+		io.WriteString(w, catalogPage)
+	})
+	etag := api.ETag([]byte(catalogPage))
+	gzipETag := strings.TrimSuffix(etag, `"`) + `-gzip"`
+
+	t.Run("revalidates compressed representations", func(t *testing.T) {
+		r := request("gzip")
+		r.Header.Set("If-None-Match", gzipETag)
+
+		res := serve(defaults, handler, r)
+
+		assert.Equal(t, http.StatusNotModified, res.Code)
+		assert.Equal(t, gzipETag, res.Header().Get("ETag"))
+		assert.Empty(t, res.Header().Get("Content-Encoding"))
+		assert.Empty(t, res.Body.String())
+	})
+
+	t.Run("revalidates a representation stored before the client accepted compression", func(t *testing.T) {
+		r := request("gzip")
+		r.Header.Set("If-None-Match", `"other", W/`+etag)
+
+		res := serve(defaults, handler, r)
+
+		assert.Equal(t, http.StatusNotModified, res.Code)
+		assert.Equal(t, etag, res.Header().Get("ETag"))
+	})
+
+	t.Run("sends changed representations compressed", func(t *testing.T) {
+		r := request("gzip")
+		r.Header.Set("If-None-Match", `"stale-gzip"`)
+
+		res := serve(defaults, handler, r)
+
+		assert.Equal(t, http.StatusOK, res.Code)
+		assert.Equal(t, gzipETag, res.Header().Get("ETag"))
+		assert.Equal(t, catalogPage, decode(t, res))
+	})
+}
+
+func TestOptionsValidate(t *testing.T) {
+	assert.NoError(t, defaults.Validate())
+	assert.NoError(t, Options{}.Validate())
+	assert.Error(t, Options{Encodings: []string{"deflate"}}.Validate())
+	assert.Error(t, Options{MinSize: -1}.Validate())
+}
@@ -0,0 +1,453 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"net"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/joho/godotenv"
+	"github.com/mytheresa/go-hiring-challenge/app/auth"
+	"github.com/mytheresa/go-hiring-challenge/app/cache"
+	"github.com/mytheresa/go-hiring-challenge/app/compression"
+	"github.com/mytheresa/go-hiring-challenge/app/cors"
+	"github.com/mytheresa/go-hiring-challenge/app/database"
+	"github.com/mytheresa/go-hiring-challenge/app/logging"
+	"github.com/mytheresa/go-hiring-challenge/app/ratelimit"
+	"github.com/mytheresa/go-hiring-challenge/app/tracing"
+)
+
+// Config is the configuration shared by the commands. Each command uses the
+// parts it needs.
+type Config struct {
+	HTTPHost        string
+	HTTPPort        int
+	RequestTimeout  time.Duration
+	MaxBodyBytes    int
+	ShutdownDelay   time.Duration
+	ShutdownTimeout time.Duration
+
+	Database     database.Config
+	QueryTimeout time.Duration
+
+	CacheBackend       string
+	CacheSize          int
+	CatalogCacheTTL    time.Duration
+	CategoriesCacheTTL time.Duration
+	Redis              cache.RedisOptions
+
+	Tracing tracing.Options
+	Logging logging.Options
+	JWT     auth.JWTOptions
+
+	RateLimit ratelimit.Options
+	CORS      cors.Options
+
+	Compression compression.Options
+
+	// IdempotencyKeyRetention is how long the response to a request sent
+	// with an Idempotency-Key is replayed to retries.
+	IdempotencyKeyRetention time.Duration
+
+	SQLDir         string
+	PurgeRetention time.Duration
+
+	// Args holds the arguments left after the flags, e.g. a subcommand.
+	Args []string
+
+	settings []setting
+}
+
+// Default returns the configuration used for every setting that is not provided.
+func Default() *Config {
+	return &Config{
+		HTTPHost:           "",
+		HTTPPort:           8484,
+		RequestTimeout:     10 * time.Second,
+		MaxBodyBytes:       1 << 20,
+		ShutdownDelay:      5 * time.Second,
+		ShutdownTimeout:    15 * time.Second,
+		Database:           database.DefaultConfig(),
+		QueryTimeout:       5 * time.Second,
+		CacheBackend:       "memory",
+		CacheSize:          1000,
+		CatalogCacheTTL:    30 * time.Second,
+		CategoriesCacheTTL: 5 * time.Minute,
+		Redis:              cache.RedisOptions{Addr: "localhost:6379"},
+		Tracing: tracing.Options{
+			Exporter:    tracing.ExporterNone,
+			SampleRatio: 1,
+			ServiceName: "go-hiring-challenge",
+		},
+		Logging: logging.Options{
+			Level:  "info",
+			Format: logging.FormatJSON,
+		},
+		JWT: auth.JWTOptions{RefreshInterval: 5 * time.Minute},
+		RateLimit: ratelimit.Options{
+			Store:  ratelimit.StoreMemory,
+			Reads:  ratelimit.Limit{Requests: 300, Period: time.Minute},
+			Writes: ratelimit.Limit{Requests: 60, Period: time.Minute},
+			// Generous enough for a few busy clients behind one NAT.
+			Addresses:  ratelimit.Limit{Requests: 1200, Period: time.Minute},
+			MaxBuckets: 100000,
+		},
+		CORS: cors.Options{
+			AllowedMethods: []string{"GET", "HEAD", "POST", "PATCH", "DELETE"},
+			AllowedHeaders: []string{"Authorization", "Content-Type", "Idempotency-Key", "If-Match", "If-None-Match", "X-API-Key", "X-Request-ID"},
+			ExposedHeaders: []string{"ETag", "Idempotent-Replayed", "Retry-After", "RateLimit-Limit", "RateLimit-Remaining", "RateLimit-Reset", "RateLimit-Policy", "WWW-Authenticate", "X-Request-ID"},
+			MaxAge:         10 * time.Minute,
+		},
+		Compression: compression.Options{
+			Encodings: []string{compression.EncodingBrotli, compression.EncodingGzip},
+			MinSize:   1024,
+		},
+		IdempotencyKeyRetention: 24 * time.Hour,
+		SQLDir:                  "./sql",
+		PurgeRetention:          30 * 24 * time.Hour,
+	}
+}
+
+// HTTPAddr returns the address the server listens on.
+func (c *Config) HTTPAddr() string {
+	return net.JoinHostPort(c.HTTPHost, strconv.Itoa(c.HTTPPort))
+}
+
+// Load builds the configuration from, in increasing order of precedence, the
+// defaults, the .env file (optional; ENV_FILE overrides its path), the
+// environment and the command-line flags. Empty environment variables count as unset.
+// The arguments following the flags are kept in Args.
+func Load(name string, args []string) (*Config, error) {
+	envFile := os.Getenv("ENV_FILE")
+	if envFile == "" {
+		envFile = ".env"
+	}
+	// godotenv never overrides variables already set in the environment.
+	if err := godotenv.Load(envFile); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("loading %s: %w", envFile, err)
+	}
+
+	cfg := Default()
+	cfg.register()
+
+	// Registering the flags first keeps -h showing the defaults rather than
+	// values taken from the environment, such as passwords.
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	for _, s := range cfg.settings {
+		flags.Var(s.value, s.flag(), s.usage+" (env "+s.env+")")
+	}
+
+	var errs []error
+	for _, s := range cfg.settings {
+		if v := os.Getenv(s.env); v != "" {
+			if err := s.value.Set(v); err != nil {
+				errs = append(errs, fmt.Errorf("invalid %s: %w", s.env, err))
+			}
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+
+	if err := flags.Parse(args); err != nil {
+		return nil, err
+	}
+	cfg.Args = flags.Args()
+
+	return cfg, cfg.Validate()
+}
+
+// Validate reports missing or inconsistent settings.
+func (c *Config) Validate() error {
+	var errs []error
+	if c.HTTPPort < 1 || c.HTTPPort > 65535 {
+		errs = append(errs, fmt.Errorf("HTTP_PORT must be between 1 and 65535, got %d", c.HTTPPort))
+	}
+	if c.IdempotencyKeyRetention <= 0 {
+		errs = append(errs, errors.New("IDEMPOTENCY_KEY_RETENTION must be positive"))
+	}
+	if c.MaxBodyBytes <= 0 {
+		errs = append(errs, errors.New("MAX_BODY_BYTES must be positive"))
+	}
+	if c.Database.DSN == "" && c.Database.User == "" {
+		errs = append(errs, errors.New("POSTGRES_USER is required"))
+	}
+	if err := c.Database.Validate(); err != nil {
+		errs = append(errs, err)
+	}
+	if err := c.Tracing.Validate(); err != nil {
+		errs = append(errs, err)
+	}
+	if err := c.Logging.Validate(); err != nil {
+		errs = append(errs, err)
+	}
+	if err := c.JWT.Validate(); err != nil {
+		errs = append(errs, err)
+	}
+	if err := c.RateLimit.Validate(); err != nil {
+		errs = append(errs, err)
+	}
+	if err := c.Compression.Validate(); err != nil {
+		errs = append(errs, err)
+	}
+	if err := c.CORS.Validate(); err != nil {
+		errs = append(errs, err)
+	}
+	if c.RateLimit.Store == ratelimit.StoreRedis && c.Redis.Addr == "" {
+		errs = append(errs, errors.New("REDIS_ADDR is required with RATE_LIMIT_STORE=redis"))
+	}
+	switch c.CacheBackend {
+	case "memory":
+		if c.CacheSize <= 0 {
+			errs = append(errs, errors.New("CACHE_SIZE must be positive"))
+		}
+	case "redis":
+		if c.Redis.Addr == "" {
+			errs = append(errs, errors.New("REDIS_ADDR is required with CACHE_BACKEND=redis"))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("CACHE_BACKEND must be memory or redis, got %q", c.CacheBackend))
+	}
+	for _, d := range []struct {
+		name  string
+		value time.Duration
+	}{
+		{"REQUEST_TIMEOUT", c.RequestTimeout},
+		{"SHUTDOWN_TIMEOUT", c.ShutdownTimeout},
+		{"SHUTDOWN_DELAY", c.ShutdownDelay},
+		{"QUERY_TIMEOUT", c.QueryTimeout},
+		{"CATALOG_CACHE_TTL", c.CatalogCacheTTL},
+		{"CATEGORIES_CACHE_TTL", c.CategoriesCacheTTL},
+		{"PURGE_RETENTION", c.PurgeRetention},
+	} {
+		if d.value < 0 {
+			errs = append(errs, fmt.Errorf("%s must not be negative", d.name))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// String lists the effective settings, one per line, with secrets redacted.
+func (c *Config) String() string {
+	var b strings.Builder
+	for _, attr := range c.LogValue().Group() {
+		fmt.Fprintf(&b, "%s=%s\n", attr.Key, attr.Value.String())
+	}
+	return b.String()
+}
+
+// LogValue lists the effective settings as log attributes, with secrets redacted.
+func (c *Config) LogValue() slog.Value {
+	if c.settings == nil {
+		c.register()
+	}
+
+	attrs := make([]slog.Attr, len(c.settings))
+	for i, s := range c.settings {
+		v := s.value.String()
+		if v != "" {
+			v = s.redact(v)
+		}
+		attrs[i] = slog.String(s.env, v)
+	}
+	return slog.GroupValue(attrs...)
+}
+
+func (c *Config) register() {
+	c.settings = nil
+	add := func(env string, value flag.Value, usage string, redact func(string) string) {
+		c.settings = append(c.settings, setting{env: env, value: value, usage: usage, redact: redact})
+	}
+	plain := func(v string) string { return v }
+
+	add("HTTP_HOST", (*stringValue)(&c.HTTPHost), "interface the server listens on; empty for all interfaces", plain)
+	add("HTTP_PORT", (*intValue)(&c.HTTPPort), "port the server listens on", plain)
+	add("REQUEST_TIMEOUT", (*durationValue)(&c.RequestTimeout), "bound of each API request, 0 for none", plain)
+	add("MAX_BODY_BYTES", (*intValue)(&c.MaxBodyBytes), "maximum size of a request body", plain)
+	add("SHUTDOWN_DELAY", (*durationValue)(&c.ShutdownDelay), "how long readiness fails before the server stops accepting connections", plain)
+	add("SHUTDOWN_TIMEOUT", (*durationValue)(&c.ShutdownTimeout), "how long in-flight requests may drain on shutdown", plain)
+
+	db := &c.Database
+	add("POSTGRES_HOST", (*stringValue)(&db.Host), "database host", plain)
+	add("POSTGRES_PORT", (*stringValue)(&db.Port), "database port", plain)
+	add("POSTGRES_USER", (*stringValue)(&db.User), "database user", plain)
+	add("POSTGRES_PASSWORD", (*stringValue)(&db.Password), "database password", redactAll)
+	add("POSTGRES_DB", (*stringValue)(&db.Name), "database name", plain)
+	add("POSTGRES_SSLMODE", (*stringValue)(&db.SSLMode), "database TLS mode", plain)
+	add("POSTGRES_SSLROOTCERT", (*stringValue)(&db.SSLRootCert), "CA certificate file of the database", plain)
+	add("POSTGRES_SSLCERT", (*stringValue)(&db.SSLCert), "client certificate file for the database", plain)
+	add("POSTGRES_SSLKEY", (*stringValue)(&db.SSLKey), "client key file for the database", plain)
+	add("DATABASE_URL", (*stringValue)(&db.DSN), "full database DSN, replacing the connection settings", redactDSN)
+	add("POSTGRES_REPLICAS", (*listValue)(&db.Replicas), "comma-separated DSNs of read replicas", redactDSN)
+	add("POSTGRES_REPLICA_CHECK_INTERVAL", (*durationValue)(&db.ReplicaCheckInterval), "how often replicas are health checked", plain)
+	add("POSTGRES_MAX_OPEN_CONNS", (*intValue)(&db.MaxOpenConns), "maximum open database connections", plain)
+	add("POSTGRES_MAX_IDLE_CONNS", (*intValue)(&db.MaxIdleConns), "maximum idle database connections", plain)
+	add("POSTGRES_CONN_MAX_LIFETIME", (*durationValue)(&db.ConnMaxLifetime), "maximum lifetime of a database connection", plain)
+	add("POSTGRES_CONN_MAX_IDLE_TIME", (*durationValue)(&db.ConnMaxIdleTime), "maximum idle time of a database connection", plain)
+	add("POSTGRES_STATEMENT_TIMEOUT", (*durationValue)(&db.StatementTimeout), "server-side statement timeout", plain)
+	add("POSTGRES_CONNECT_TIMEOUT", (*durationValue)(&db.ConnectTimeout), "timeout of each connection attempt", plain)
+	add("POSTGRES_CONNECT_RETRIES", (*intValue)(&db.ConnectRetries), "connection retries on startup", plain)
+	add("POSTGRES_CONNECT_BACKOFF", (*durationValue)(&db.RetryBackoff), "wait before the first connection retry", plain)
+	add("POSTGRES_CONNECT_MAX_BACKOFF", (*durationValue)(&db.MaxRetryBackoff), "longest wait between connection retries; 0 for no limit", plain)
+	add("POSTGRES_SLOW_QUERY_THRESHOLD", (*durationValue)(&db.SlowQueryThreshold), "duration above which statements are logged as slow", plain)
+	add("QUERY_TIMEOUT", (*durationValue)(&c.QueryTimeout), "bound of each repository call", plain)
+
+	add("CACHE_BACKEND", (*stringValue)(&c.CacheBackend), "cache backend: memory or redis", plain)
+	add("CACHE_SIZE", (*intValue)(&c.CacheSize), "entries kept by the memory cache", plain)
+	add("CATALOG_CACHE_TTL", (*durationValue)(&c.CatalogCacheTTL), "lifetime of cached products", plain)
+	add("CATEGORIES_CACHE_TTL", (*durationValue)(&c.CategoriesCacheTTL), "lifetime of cached categories", plain)
+	add("REDIS_ADDR", (*stringValue)(&c.Redis.Addr), "address of the Redis-protocol cache server", plain)
+	add("REDIS_PASSWORD", (*stringValue)(&c.Redis.Password), "password of the cache server", redactAll)
+	add("REDIS_DB", (*intValue)(&c.Redis.DB), "database number on the cache server", plain)
+
+	add("LOG_LEVEL", (*stringValue)(&c.Logging.Level), "minimum log level: debug, info, warn or error", plain)
+	add("LOG_FORMAT", (*stringValue)(&c.Logging.Format), "log format: json or text", plain)
+
+	add("TRACING_EXPORTER", (*stringValue)(&c.Tracing.Exporter), "span exporter: none, otlp, stdout or file", plain)
+	add("TRACING_ENDPOINT", (*stringValue)(&c.Tracing.Endpoint), "OTLP/HTTP collector URL", plain)
+	add("TRACING_FILE", (*stringValue)(&c.Tracing.File), "file the file exporter appends spans to", plain)
+	add("TRACING_SAMPLE_RATIO", (*floatValue)(&c.Tracing.SampleRatio), "fraction of new traces recorded", plain)
+	add("TRACING_SERVICE_NAME", (*stringValue)(&c.Tracing.ServiceName), "service name attached to the spans", plain)
+
+	add("JWT_JWKS", (*stringValue)(&c.JWT.JWKS), "file or URL of the key set bearer tokens are verified with; empty disables them", plain)
+	add("JWT_JWKS_REFRESH_INTERVAL", (*durationValue)(&c.JWT.RefreshInterval), "how often the key set is reloaded, 0 for never", plain)
+	add("JWT_AUDIENCE", (*stringValue)(&c.JWT.Audience), "audience bearer tokens must be issued for", plain)
+	add("JWT_ISSUER", (*stringValue)(&c.JWT.Issuer), "issuer bearer tokens must come from, any when empty", plain)
+
+	add("RATE_LIMIT_STORE", (*stringValue)(&c.RateLimit.Store), "where rate limit buckets are kept: memory or redis (shared by instances)", plain)
+	add("RATE_LIMIT_READS", &c.RateLimit.Reads, "requests per period each client may send to each read route, e.g. 300/1m; 0 for no limit", plain)
+	add("RATE_LIMIT_WRITES", &c.RateLimit.Writes, "requests per period each client may send to each write route; 0 for no limit", plain)
+	add("RATE_LIMIT_ADDRESSES", &c.RateLimit.Addresses, "requests per period each address may send to all routes, checked before credentials; 0 for no limit", plain)
+	add("RATE_LIMIT_MAX_BUCKETS", (*intValue)(&c.RateLimit.MaxBuckets), "buckets kept by the memory store before the least recently used are dropped", plain)
+	add("RATE_LIMIT_ROUTES", &c.RateLimit.Routes, "per-route limits, e.g. GET /catalog=60/1m,POST /categories=10/1m", plain)
+	add("TRUSTED_PROXIES", &c.RateLimit.TrustedProxies, "comma-separated addresses or CIDRs of proxies whose X-Forwarded-For is trusted", plain)
+
+	add("CORS_ALLOWED_ORIGINS", (*listValue)(&c.CORS.AllowedOrigins), "comma-separated origins browsers may call the API from, e.g. https://*.example.com; empty disables CORS", plain)
+	add("CORS_ALLOWED_METHODS", (*listValue)(&c.CORS.AllowedMethods), "methods cross-origin requests may use", plain)
+	add("CORS_ALLOWED_HEADERS", (*listValue)(&c.CORS.AllowedHeaders), "request headers cross-origin requests may send", plain)
+	add("CORS_EXPOSED_HEADERS", (*listValue)(&c.CORS.ExposedHeaders), "response headers browser scripts may read", plain)
+	add("CORS_ALLOW_CREDENTIALS", (*boolValue)(&c.CORS.AllowCredentials), "whether cross-origin requests may carry cookies and HTTP authentication", plain)
+	add("CORS_MAX_AGE", (*durationValue)(&c.CORS.MaxAge), "how long browsers may cache preflight responses", plain)
+
+	add("COMPRESSION_ENCODINGS", (*listValue)(&c.Compression.Encodings), "response encodings offered, by preference: br, gzip; empty disables compression", plain)
+	add("COMPRESSION_MIN_SIZE", (*intValue)(&c.Compression.MinSize), "smallest response body compressed, in bytes", plain)
+
+	add("IDEMPOTENCY_KEY_RETENTION", (*durationValue)(&c.IdempotencyKeyRetention), "how long responses to requests with an Idempotency-Key are replayed to retries", plain)
+
+	add("POSTGRES_SQL_DIR", (*stringValue)(&c.SQLDir), "directory of the SQL files run by seed", plain)
+	add("PURGE_RETENTION", (*durationValue)(&c.PurgeRetention), "how long soft-deleted rows are kept before purge removes them", plain)
+}
+
+// setting binds a configuration field to its environment variable and flag.
+type setting struct {
+	env    string
+	value  flag.Value
+	usage  string
+	redact func(string) string
+}
+
+// flag derives the flag name from the environment variable: HTTP_PORT becomes -http-port.
+func (s setting) flag() string {
+	return strings.ReplaceAll(strings.ToLower(s.env), "_", "-")
+}
+
+func redactAll(string) string {
+	return "[redacted]"
+}
+
+// redactDSN hides the passwords in URL and key/value DSNs, or in a list of them.
+func redactDSN(v string) string {
+	dsns := strings.Split(v, ",")
+	for i, dsn := range dsns {
+		dsns[i] = redactOneDSN(dsn)
+	}
+	return strings.Join(dsns, ",")
+}
+
+func redactOneDSN(dsn string) string {
+	if strings.Contains(dsn, "://") {
+		u, err := url.Parse(dsn)
+		if err != nil {
+			return "[redacted]"
+		}
+		if _, ok := u.User.Password(); ok {
+			u.User = url.UserPassword(u.User.Username(), "redacted")
+		}
+		if q := u.Query(); q.Has("password") {
+			q.Set("password", "redacted")
+			u.RawQuery = q.Encode()
+		}
+		return u.String()
+	}
+	return redactKeyValueDSN(dsn)
+}
+
+// redactKeyValueDSN hides the password of a key/value DSN, which may be
+// quoted, contain spaces and escaped quotes, and be set apart by spaces
+// around the "=", e.g. password = 'it\'s secret'.
+func redactKeyValueDSN(dsn string) string {
+	var b strings.Builder
+	isSpace := func(c byte) bool { return c == ' ' || c == '\t' || c == '\n' || c == '\r' }
+	for i := 0; i < len(dsn); {
+		if isSpace(dsn[i]) {
+			b.WriteByte(dsn[i])
+			i++
+			continue
+		}
+
+		start := i
+		for i < len(dsn) && dsn[i] != '=' && !isSpace(dsn[i]) {
+			i++
+		}
+		key := dsn[start:i]
+		b.WriteString(key)
+
+		start = i
+		for i < len(dsn) && isSpace(dsn[i]) {
+			i++
+		}
+		if i == len(dsn) || dsn[i] != '=' {
+			b.WriteString(dsn[start:i])
+			continue
+		}
+		i++
+		for i < len(dsn) && isSpace(dsn[i]) {
+			i++
+		}
+		b.WriteString(dsn[start:i])
+
+		start = i
+		quoted := i < len(dsn) && dsn[i] == '\''
+		if quoted {
+			i++
+		}
+		for i < len(dsn) {
+			if dsn[i] == '\\' {
+				i += 2
+				continue
+			}
+			if quoted && dsn[i] == '\'' {
+				i++
+				break
+			}
+			if !quoted && isSpace(dsn[i]) {
+				break
+			}
+			i++
+		}
+		i = min(i, len(dsn))
+		if key == "password" {
+			b.WriteString("[redacted]")
+		} else {
+			b.WriteString(dsn[start:i])
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,203 @@
+// Package cors lets browsers call the API from the storefront origins,
+// answering preflight requests and adding the CORS headers to responses.
+package cors
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mytheresa/go-hiring-challenge/app/middleware"
+)
+
+// Options configures cross-origin requests.
+type Options struct {
+	// AllowedOrigins are the origins browsers may call the API from, e.g.
+	// "https://shop.example.com". "https://*.example.com" allows every
+	// subdomain of example.com and "*" every origin. No origins disable CORS.
+	AllowedOrigins []string
+	// AllowedMethods and AllowedHeaders are the methods and request headers
+	// preflight requests may ask for.
+	AllowedMethods []string
+	AllowedHeaders []string
+	// ExposedHeaders are the response headers scripts may read besides the
+	// CORS-safelisted ones.
+	ExposedHeaders []string
+	// AllowCredentials lets browsers send cookies and HTTP authentication.
+	AllowCredentials bool
+	// MaxAge is how long browsers may cache a preflight response.
+	MaxAge time.Duration
+}
+
+// Validate reports malformed origins, a wildcard origin with credentials,
+// which browsers refuse, and a negative MaxAge.
+func (o Options) Validate() error {
+	var errs []error
+	for _, origin := range o.AllowedOrigins {
+		if origin == "*" {
+			if o.AllowCredentials {
+				errs = append(errs, errors.New("the * origin cannot be allowed with credentials"))
+			}
+			continue
+		}
+		if err := validateOrigin(origin); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if o.MaxAge < 0 {
+		errs = append(errs, errors.New("CORS max age must not be negative"))
+	}
+	return errors.Join(errs...)
+}
+
+// validateOrigin accepts a scheme and host, optionally with a port, where
+// the host may start with a "*." wildcard label.
+func validateOrigin(origin string) error {
+	u, err := url.Parse(strings.Replace(origin, "://*.", "://wildcard.", 1))
+	if err != nil || u.Scheme == "" || u.Host == "" || u.User != nil ||
+		(u.Path != "" && u.Path != "/") || u.RawQuery != "" || u.Fragment != "" || strings.Contains(u.Host, "*") {
+		return fmt.Errorf("invalid CORS origin %q, expected e.g. https://shop.example.com or https://*.example.com", origin)
+	}
+	return nil
+}
+
+// policy is the compiled form of Options.
+type policy struct {
+	anyOrigin   bool
+	origins     []string
+	subdomains  [][2]string // scheme prefix and domain suffix
+	methods     []string
+	headers     []string
+	exposed     string
+	credentials bool
+	maxAge      string
+}
+
+// New returns middleware applying opts to every request carrying an Origin
+// header. It answers preflight requests itself with 204 No Content, since
+// the routes only match their own methods; a preflight for a disallowed
+// origin, method or header gets no CORS headers, so the browser blocks the
+// request. Other requests from allowed origins get the CORS headers and are
+// passed on, so errors reach scripts as well.
+func New(opts Options) middleware.Middleware {
+	if len(opts.AllowedOrigins) == 0 {
+		return func(next http.Handler) http.Handler { return next }
+	}
+
+	p := &policy{
+		methods:     opts.AllowedMethods,
+		exposed:     strings.Join(opts.ExposedHeaders, ", "),
+		credentials: opts.AllowCredentials,
+	}
+	for _, header := range opts.AllowedHeaders {
+		p.headers = append(p.headers, http.CanonicalHeaderKey(header))
+	}
+	if opts.MaxAge > 0 {
+		p.maxAge = strconv.Itoa(int(opts.MaxAge.Seconds()))
+	}
+	for _, origin := range opts.AllowedOrigins {
+		origin = strings.ToLower(strings.TrimSuffix(origin, "/"))
+		switch {
+		case origin == "*":
+			p.anyOrigin = true
+		case strings.Contains(origin, "://*."):
+			scheme, domain, _ := strings.Cut(origin, "*")
+			p.subdomains = append(p.subdomains, [2]string{scheme, domain})
+		default:
+			p.origins = append(p.origins, origin)
+		}
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			h := w.Header()
+			h.Add("Vary", "Origin")
+			origin := r.Header.Get("Origin")
+
+			if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
+				h.Add("Vary", "Access-Control-Request-Method")
+				h.Add("Vary", "Access-Control-Request-Headers")
+				if p.allowOrigin(origin) && p.allowPreflight(r) {
+					p.setOrigin(h, origin)
+					h.Set("Access-Control-Allow-Methods", r.Header.Get("Access-Control-Request-Method"))
+					if headers := r.Header.Values("Access-Control-Request-Headers"); len(headers) > 0 {
+						h.Set("Access-Control-Allow-Headers", strings.Join(headers, ", "))
+					}
+					if p.maxAge != "" {
+						h.Set("Access-Control-Max-Age", p.maxAge)
+					}
+				}
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+
+			if origin != "" && p.allowOrigin(origin) {
+				p.setOrigin(h, origin)
+				if p.exposed != "" {
+					h.Set("Access-Control-Expose-Headers", p.exposed)
+				}
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// allowOrigin reports whether origin matches an allowed origin. Origins are
+// compared case-insensitively; a subdomain pattern matches any depth of
+// subdomains but not the domain itself.
+func (p *policy) allowOrigin(origin string) bool {
+	if origin == "" {
+		return false
+	}
+	if p.anyOrigin {
+		return true
+	}
+	origin = strings.ToLower(origin)
+	if slices.Contains(p.origins, origin) {
+		return true
+	}
+	for _, sub := range p.subdomains {
+		scheme, domain := sub[0], sub[1]
+		if !strings.HasPrefix(origin, scheme) || !strings.HasSuffix(origin, domain) {
+			continue
+		}
+		label := origin[len(scheme) : len(origin)-len(domain)]
+		if len(origin) > len(scheme)+len(domain) && !strings.ContainsAny(label, "/:@") {
+			return true
+		}
+	}
+	return false
+}
+
+// allowPreflight reports whether the method and every header a preflight
+// request asks for are allowed.
+func (p *policy) allowPreflight(r *http.Request) bool {
+	if !slices.Contains(p.methods, r.Header.Get("Access-Control-Request-Method")) {
+		return false
+	}
+	for _, value := range r.Header.Values("Access-Control-Request-Headers") {
+		for _, header := range strings.Split(value, ",") {
+			header = strings.TrimSpace(header)
+			if header != "" && !slices.Contains(p.headers, http.CanonicalHeaderKey(header)) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func (p *policy) setOrigin(h http.Header, origin string) {
+	if p.anyOrigin {
+		h.Set("Access-Control-Allow-Origin", "*")
+	} else {
+		h.Set("Access-Control-Allow-Origin", origin)
+	}
+	if p.credentials {
+		h.Set("Access-Control-Allow-Credentials", "true")
+	}
+}
@@ -0,0 +1,151 @@
+package cors
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var storefront = Options{
+	AllowedOrigins: []string{"https://shop.example.com", "https://*.preview.example.com"},
+	AllowedMethods: []string{"GET", "POST", "PATCH"},
+	AllowedHeaders: []string{"Content-Type", "X-API-Key"},
+	ExposedHeaders: []string{"ETag", "X-Request-ID"},
+	MaxAge:         10 * time.Minute,
+}
+
+func serve(opts Options, r *http.Request) *httptest.ResponseRecorder {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /catalog", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	res := httptest.NewRecorder()
+	New(opts)(mux).ServeHTTP(res, r)
+	return res
+}
+
+func preflight(origin, method, headers string) *http.Request {
+	r := httptest.NewRequest(http.MethodOptions, "/catalog", nil)
+	r.Header.Set("Origin", origin)
+	r.Header.Set("Access-Control-Request-Method", method)
+	if headers != "" {
+		r.Header.Set("Access-Control-Request-Headers", headers)
+	}
+	return r
+}
+
+func TestPreflight(t *testing.T) {
+	t.Run("answers allowed requests without reaching the routes", func(t *testing.T) {
+		res := serve(storefront, preflight("https://shop.example.com", "PATCH", "content-type,x-api-key"))
+
+		assert.Equal(t, http.StatusNoContent, res.Code)
+		assert.Equal(t, "https://shop.example.com", res.Header().Get("Access-Control-Allow-Origin"))
+		assert.Equal(t, "PATCH", res.Header().Get("Access-Control-Allow-Methods"))
+		assert.Equal(t, "content-type,x-api-key", res.Header().Get("Access-Control-Allow-Headers"))
+		assert.Equal(t, "600", res.Header().Get("Access-Control-Max-Age"))
+		assert.Empty(t, res.Header().Get("Access-Control-Allow-Credentials"))
+		assert.Equal(t, []string{"Origin", "Access-Control-Request-Method", "Access-Control-Request-Headers"}, res.Header().Values("Vary"))
+	})
+
+	denied := map[string]*http.Request{
+		"unknown origin":       preflight("https://evil.example.org", "GET", ""),
+		"method not allowed":   preflight("https://shop.example.com", "DELETE", ""),
+		"header not allowed":   preflight("https://shop.example.com", "GET", "X-API-Key, X-Debug"),
+		"different scheme":     preflight("http://shop.example.com", "GET", ""),
+		"bare wildcard domain": preflight("https://preview.example.com", "GET", ""),
+	}
+	for name, r := range denied {
+		t.Run("omits the CORS headers for "+name, func(t *testing.T) {
+			res := serve(storefront, r)
+
+			assert.Equal(t, http.StatusNoContent, res.Code)
+			assert.Empty(t, res.Header().Get("Access-Control-Allow-Origin"))
+			assert.Empty(t, res.Header().Get("Access-Control-Allow-Methods"))
+		})
+	}
+
+	t.Run("leaves plain OPTIONS requests to the routes", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodOptions, "/catalog", nil)
+		r.Header.Set("Origin", "https://shop.example.com")
+
+		res := serve(storefront, r)
+
+		assert.Equal(t, http.StatusMethodNotAllowed, res.Code)
+	})
+}
+
+func TestActualRequests(t *testing.T) {
+	request := func(origin string) *http.Request {
+		r := httptest.NewRequest(http.MethodGet, "/catalog", nil)
+		if origin != "" {
+			r.Header.Set("Origin", origin)
+		}
+		return r
+	}
+
+	t.Run("adds the CORS headers for allowed origins", func(t *testing.T) {
+		for _, origin := range []string{"https://shop.example.com", "https://pr-42.preview.example.com", "https://a.b.Preview.example.com"} {
+			res := serve(storefront, request(origin))
+
+			assert.Equal(t, http.StatusOK, res.Code, origin)
+			assert.Equal(t, origin, res.Header().Get("Access-Control-Allow-Origin"), origin)
+			assert.Equal(t, "ETag, X-Request-ID", res.Header().Get("Access-Control-Expose-Headers"), origin)
+			assert.Equal(t, "Origin", res.Header().Get("Vary"), origin)
+		}
+	})
+
+	t.Run("serves other origins without CORS headers", func(t *testing.T) {
+		for _, origin := range []string{"", "https://shop.example.com.evil.org", "https://evil.org/.preview.example.com", "null"} {
+			res := serve(storefront, request(origin))
+
+			assert.Equal(t, http.StatusOK, res.Code, origin)
+			assert.Empty(t, res.Header().Get("Access-Control-Allow-Origin"), origin)
+		}
+	})
+
+	t.Run("allows credentials", func(t *testing.T) {
+		opts := storefront
+		opts.AllowCredentials = true
+
+		res := serve(opts, request("https://shop.example.com"))
+
+		assert.Equal(t, "true", res.Header().Get("Access-Control-Allow-Credentials"))
+	})
+
+	t.Run("allows every origin with the wildcard", func(t *testing.T) {
+		opts := storefront
+		opts.AllowedOrigins = []string{"*"}
+
+		res := serve(opts, request("https://anywhere.example.net"))
+
+		assert.Equal(t, "*", res.Header().Get("Access-Control-Allow-Origin"))
+	})
+
+	t.Run("does nothing without allowed origins", func(t *testing.T) {
+		res := serve(Options{}, preflight("https://shop.example.com", "GET", ""))
+
+		assert.Equal(t, http.StatusMethodNotAllowed, res.Code)
+		assert.Empty(t, res.Header().Values("Vary"))
+	})
+}
+
+func TestOptionsValidate(t *testing.T) {
+	assert.NoError(t, storefront.Validate())
+	assert.NoError(t, Options{AllowedOrigins: []string{"*", "http://localhost:3000"}}.Validate())
+
+	tests := map[string]Options{
+		"wildcard with credentials": {AllowedOrigins: []string{"*"}, AllowCredentials: true},
+		"missing scheme":            {AllowedOrigins: []string{"shop.example.com"}},
+		"path":                      {AllowedOrigins: []string{"https://shop.example.com/app"}},
+		"wildcard inside the host":  {AllowedOrigins: []string{"https://shop.*.example.com"}},
+		"negative max age":          {MaxAge: -time.Second},
+	}
+	for name, opts := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Error(t, opts.Validate())
+		})
+	}
+}
@@ -0,0 +1,165 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Cluster routes queries between the primary and its read replicas.
+// Reads are spread round-robin over the replicas that passed their last health
+// check and fall back to the primary when none did.
+type Cluster struct {
+	primary      *gorm.DB
+	closePrimary func() error
+	replicas     []*replica
+	next         atomic.Uint64
+	checkTimeout time.Duration
+	logger       *slog.Logger
+
+	stop context.CancelFunc
+	done sync.WaitGroup
+}
+
+type replica struct {
+	name    string
+	db      *gorm.DB
+	healthy atomic.Bool
+}
+
+// NewCluster connects to the primary as New does and prepares a pool for each
+// replica. Replicas are not required to be up: an unavailable replica only
+// receives reads once a health check succeeds. Changes of replica health are
+// logged to logger.
+func NewCluster(ctx context.Context, cfg Config, logger *slog.Logger) (*Cluster, error) {
+	primary, closePrimary, err := New(ctx, cfg, logger)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &Cluster{
+		primary:      primary,
+		closePrimary: closePrimary,
+		checkTimeout: cfg.ConnectTimeout,
+		logger:       logger,
+		stop:         func() {},
+	}
+	for i, dsn := range cfg.Replicas {
+		db, err := openPool(cfg.ReplicaConnString(dsn), cfg)
+		if err != nil {
+			c.Close()
+			return nil, fmt.Errorf("opening replica %d: %w", i+1, err)
+		}
+		r := &replica{name: fmt.Sprintf("replica %d", i+1), db: db}
+		// Assume healthy so that the first check logs a replica that is down.
+		r.healthy.Store(true)
+		c.replicas = append(c.replicas, r)
+	}
+
+	if len(c.replicas) > 0 {
+		c.CheckReplicas(ctx)
+		if cfg.ReplicaCheckInterval > 0 {
+			checkCtx, stop := context.WithCancel(context.Background())
+			c.stop = stop
+			c.done.Add(1)
+			go c.checkLoop(checkCtx, cfg.ReplicaCheckInterval)
+		}
+	}
+	return c, nil
+}
+
+// Primary returns the primary connection.
+func (c *Cluster) Primary() *gorm.DB {
+	return c.primary
+}
+
+// Replica returns a healthy replica, or the primary when there is none.
+func (c *Cluster) Replica() *gorm.DB {
+	healthy := make([]*gorm.DB, 0, len(c.replicas))
+	for _, r := range c.replicas {
+		if r.healthy.Load() {
+			healthy = append(healthy, r.db)
+		}
+	}
+	if len(healthy) == 0 {
+		return c.primary
+	}
+	return healthy[c.next.Add(1)%uint64(len(healthy))]
+}
+
+// Pool is one of the connection pools of a cluster.
+type Pool struct {
+	Name string
+	DB   *gorm.DB
+}
+
+// Pools returns the primary pool followed by the replica pools.
+func (c *Cluster) Pools() []Pool {
+	pools := []Pool{{Name: "primary", DB: c.primary}}
+	for i, r := range c.replicas {
+		pools = append(pools, Pool{Name: fmt.Sprintf("replica-%d", i+1), DB: r.db})
+	}
+	return pools
+}
+
+// Ping checks that the primary answers. Replicas are left out: reads fall back
+// to the primary when they are down.
+func (c *Cluster) Ping(ctx context.Context) error {
+	return ping(ctx, c.primary, c.checkTimeout)
+}
+
+// CheckReplicas pings every replica and records whether it may serve reads.
+func (c *Cluster) CheckReplicas(ctx context.Context) {
+	var wg sync.WaitGroup
+	for _, r := range c.replicas {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := ping(ctx, r.db, c.checkTimeout)
+			if was := r.healthy.Swap(err == nil); was != (err == nil) {
+				if err != nil {
+					c.logger.WarnContext(ctx, "Database replica is unavailable, routing its reads to the primary", "replica", r.name, "error", err)
+				} else {
+					c.logger.InfoContext(ctx, "Database replica is available", "replica", r.name)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func (c *Cluster) checkLoop(ctx context.Context, interval time.Duration) {
+	defer c.done.Done()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			c.CheckReplicas(ctx)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// Close stops the health checks and closes every connection pool.
+func (c *Cluster) Close() error {
+	c.stop()
+	c.done.Wait()
+
+	var errs []error
+	for _, r := range c.replicas {
+		if sqlDB, err := r.db.DB(); err == nil {
+			errs = append(errs, sqlDB.Close())
+		}
+	}
+	errs = append(errs, c.closePrimary())
+	return errors.Join(errs...)
+}
@@ -0,0 +1,106 @@
+package database
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// unreachableDSN returns a DSN pointing at a port nothing listens on.
+func unreachableDSN(t *testing.T) string {
+	t.Helper()
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	_, port, _ := net.SplitHostPort(l.Addr().String())
+	l.Close()
+
+	cfg := DefaultConfig()
+	cfg.Host = "127.0.0.1"
+	cfg.Port = port
+	cfg.Name = "challenge"
+	return cfg.ConnString()
+}
+
+// newTestCluster builds a cluster whose pools are never connected.
+func newTestCluster(t *testing.T, replicas int) *Cluster {
+	t.Helper()
+
+	cfg := DefaultConfig()
+	cfg.ConnectTimeout = time.Second
+
+	primary, err := openPool(unreachableDSN(t), cfg)
+	require.NoError(t, err)
+	c := &Cluster{
+		primary:      primary,
+		closePrimary: func() error { return nil },
+		checkTimeout: cfg.ConnectTimeout,
+		logger:       slog.New(slog.DiscardHandler),
+		stop:         func() {},
+	}
+	for range replicas {
+		db, err := openPool(unreachableDSN(t), cfg)
+		require.NoError(t, err)
+		r := &replica{name: "replica", db: db}
+		r.healthy.Store(true)
+		c.replicas = append(c.replicas, r)
+	}
+	t.Cleanup(func() { c.Close() })
+	return c
+}
+
+func TestClusterReplica(t *testing.T) {
+	t.Run("uses the primary without replicas", func(t *testing.T) {
+		c := newTestCluster(t, 0)
+
+		assert.Same(t, c.Primary(), c.Replica())
+	})
+
+	t.Run("spreads reads over the healthy replicas", func(t *testing.T) {
+		c := newTestCluster(t, 3)
+		c.replicas[1].healthy.Store(false)
+
+		seen := map[*gorm.DB]int{}
+		for range 6 {
+			seen[c.Replica()]++
+		}
+
+		assert.Equal(t, map[*gorm.DB]int{c.replicas[0].db: 3, c.replicas[2].db: 3}, seen)
+	})
+
+	t.Run("falls back to the primary when every replica is down", func(t *testing.T) {
+		c := newTestCluster(t, 2)
+		var logs bytes.Buffer
+		c.logger = slog.New(slog.NewTextHandler(&logs, nil))
+
+		c.CheckReplicas(context.Background())
+
+		assert.False(t, c.replicas[0].healthy.Load())
+		assert.False(t, c.replicas[1].healthy.Load())
+		assert.Same(t, c.Primary(), c.Replica())
+		assert.Contains(t, logs.String(), "level=WARN msg=\"Database replica is unavailable, routing its reads to the primary\" replica=replica")
+	})
+}
+
+func TestClusterPing(t *testing.T) {
+	c := newTestCluster(t, 0)
+
+	assert.Error(t, c.Ping(context.Background()))
+}
+
+func TestClusterPools(t *testing.T) {
+	c := newTestCluster(t, 2)
+
+	pools := c.Pools()
+
+	require.Len(t, pools, 3)
+	assert.Equal(t, Pool{Name: "primary", DB: c.primary}, pools[0])
+	assert.Equal(t, Pool{Name: "replica-2", DB: c.replicas[1].db}, pools[2])
+}
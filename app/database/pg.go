@@ -1,26 +1,262 @@
 package database
 
 import (
+	"context"
+	"errors"
 	"fmt"
-	"log"
+	"log/slog"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
 
 	_ "github.com/lib/pq"
+	"github.com/mytheresa/go-hiring-challenge/app/logging"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 )
 
-func New(user, password, dbname, port string) (db *gorm.DB, close func() error) {
-	dsn := fmt.Sprintf("postgres://%s:%s@localhost:%s/%s?sslmode=disable", user, password, port, dbname)
+// Config describes how to reach Postgres and how to size the connection pool.
+// When DSN is set it replaces the host, credential, SSL and statement timeout
+// settings, which then belong in the DSN itself; the pool and retry settings still apply.
+type Config struct {
+	Host     string
+	Port     string
+	User     string
+	Password string
+	Name     string
 
-	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
+	// SSLMode is one of disable, allow, prefer, require, verify-ca or verify-full.
+	SSLMode     string
+	SSLRootCert string
+	SSLCert     string
+	SSLKey      string
+
+	DSN string
+
+	// Replicas are the DSNs of read replicas. Pool settings apply to each of
+	// them, and so does StatementTimeout unless the DSN sets its own.
+	Replicas []string
+	// ReplicaCheckInterval is how often replicas are pinged to route reads
+	// away from unavailable ones.
+	ReplicaCheckInterval time.Duration
+
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration
+	ConnMaxIdleTime time.Duration
+
+	// StatementTimeout makes the server abort any statement running longer. Zero disables it.
+	StatementTimeout time.Duration
+	// SlowQueryThreshold is the duration above which statements are logged as slow.
+	SlowQueryThreshold time.Duration
+
+	// ConnectTimeout bounds each connection attempt.
+	ConnectTimeout time.Duration
+	// ConnectRetries is how many times a failed startup connection is retried,
+	// waiting RetryBackoff before the first retry and doubling the wait up to
+	// MaxRetryBackoff, or indefinitely when it is zero.
+	ConnectRetries  int
+	RetryBackoff    time.Duration
+	MaxRetryBackoff time.Duration
+}
+
+var sslModes = map[string]bool{
+	"disable": true, "allow": true, "prefer": true,
+	"require": true, "verify-ca": true, "verify-full": true,
+}
+
+// DefaultConfig returns a configuration for a local development database.
+func DefaultConfig() Config {
+	return Config{
+		Host:            "localhost",
+		Port:            "5432",
+		SSLMode:         "disable",
+		MaxOpenConns:    25,
+		MaxIdleConns:    5,
+		ConnMaxLifetime: 30 * time.Minute,
+		ConnMaxIdleTime: 5 * time.Minute,
+		ConnectTimeout:  5 * time.Second,
+		ConnectRetries:  5,
+		RetryBackoff:    500 * time.Millisecond,
+		MaxRetryBackoff: 10 * time.Second,
+
+		SlowQueryThreshold: 200 * time.Millisecond,
+
+		ReplicaCheckInterval: 10 * time.Second,
+	}
+}
+
+// Validate reports settings that can never produce a working connection.
+func (c Config) Validate() error {
+	var errs []error
+	if c.DSN == "" {
+		if c.Host == "" {
+			errs = append(errs, errors.New("database host is required"))
+		}
+		if c.Name == "" {
+			errs = append(errs, errors.New("database name is required"))
+		}
+		if !sslModes[c.SSLMode] {
+			errs = append(errs, fmt.Errorf("invalid database sslmode %q", c.SSLMode))
+		}
+		if (c.SSLCert == "") != (c.SSLKey == "") {
+			errs = append(errs, errors.New("database client certificate and key must be set together"))
+		}
+	}
+	if c.MaxOpenConns < 0 || c.MaxIdleConns < 0 {
+		errs = append(errs, errors.New("database pool sizes must not be negative"))
+	}
+	if c.MaxOpenConns > 0 && c.MaxIdleConns > c.MaxOpenConns {
+		errs = append(errs, errors.New("database max idle connections must not exceed max open connections"))
+	}
+	if c.ConnectRetries < 0 {
+		errs = append(errs, errors.New("database connect retries must not be negative"))
+	}
+	if c.RetryBackoff < 0 || c.MaxRetryBackoff < 0 {
+		errs = append(errs, errors.New("database connect backoffs must not be negative"))
+	}
+	return errors.Join(errs...)
+}
+
+// ConnString returns the connection string built from the configuration.
+func (c Config) ConnString() string {
+	if c.DSN != "" {
+		return c.DSN
+	}
+
+	query := url.Values{}
+	query.Set("sslmode", c.SSLMode)
+	if c.SSLRootCert != "" {
+		query.Set("sslrootcert", c.SSLRootCert)
+	}
+	if c.SSLCert != "" {
+		query.Set("sslcert", c.SSLCert)
+		query.Set("sslkey", c.SSLKey)
+	}
+	if c.StatementTimeout > 0 {
+		query.Set("statement_timeout", strconv.FormatInt(c.StatementTimeout.Milliseconds(), 10))
+	}
+
+	u := url.URL{
+		Scheme:   "postgres",
+		User:     url.UserPassword(c.User, c.Password),
+		Host:     net.JoinHostPort(c.Host, c.Port),
+		Path:     "/" + c.Name,
+		RawQuery: query.Encode(),
+	}
+	return u.String()
+}
+
+// ReplicaConnString returns dsn, a URL or key/value connection string of a
+// replica, with the statement timeout of the configuration added unless dsn
+// already sets one.
+func (c Config) ReplicaConnString(dsn string) string {
+	if c.StatementTimeout <= 0 || strings.Contains(dsn, "statement_timeout") {
+		return dsn
+	}
+	timeout := strconv.FormatInt(c.StatementTimeout.Milliseconds(), 10)
+
+	if strings.HasPrefix(dsn, "postgres://") || strings.HasPrefix(dsn, "postgresql://") {
+		u, err := url.Parse(dsn)
+		if err != nil {
+			// Left for the driver to report.
+			return dsn
+		}
+		query := u.Query()
+		query.Set("statement_timeout", timeout)
+		u.RawQuery = query.Encode()
+		return u.String()
+	}
+	return strings.TrimSpace(dsn + " statement_timeout=" + timeout)
+}
+
+// New connects to Postgres, retrying with exponential backoff until the database
+// answers, the retries are exhausted or ctx is cancelled. Failed attempts are
+// logged to logger.
+func New(ctx context.Context, cfg Config, logger *slog.Logger) (db *gorm.DB, close func() error, err error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, nil, err
+	}
+
+	backoff := cfg.RetryBackoff
+	for attempt := 0; ; attempt++ {
+		db, err = open(ctx, cfg)
+		if err == nil {
+			break
+		}
+		if attempt >= cfg.ConnectRetries {
+			return nil, nil, fmt.Errorf("connecting to database after %d attempts: %w", attempt+1, err)
+		}
+
+		logger.WarnContext(ctx, "Connecting to database failed, retrying", "backoff", backoff, "error", err)
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return nil, nil, fmt.Errorf("connecting to database: %w", ctx.Err())
+		}
+		backoff *= 2
+		if cfg.MaxRetryBackoff > 0 && backoff > cfg.MaxRetryBackoff {
+			backoff = cfg.MaxRetryBackoff
+		}
+	}
+
+	sqlDB, err := db.DB()
 	if err != nil {
-		log.Fatalf("failed to connect database: %s", err)
+		return nil, nil, err
+	}
+	return db, sqlDB.Close, nil
+}
+
+func open(ctx context.Context, cfg Config) (*gorm.DB, error) {
+	db, err := openPool(cfg.ConnString(), cfg)
+	if err != nil {
+		return nil, err
+	}
+	if err := ping(ctx, db, cfg.ConnectTimeout); err != nil {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+		return nil, err
+	}
+	return db, nil
+}
+
+// openPool prepares a connection pool sized by cfg without connecting yet.
+func openPool(dsn string, cfg Config) (*gorm.DB, error) {
+	// gorm's own ping would leave the pool open on failure, so callers ping instead.
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{
+		DisableAutomaticPing: true,
+		// Constraint violations surface as gorm errors, e.g. gorm.ErrDuplicatedKey.
+		TranslateError: true,
+		Logger:         logging.GormLogger{SlowThreshold: cfg.SlowQueryThreshold},
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	sqlDB, err := db.DB()
 	if err != nil {
-		log.Fatalf("Failed to get database connection: %s", err)
+		return nil, err
 	}
+	sqlDB.SetMaxOpenConns(cfg.MaxOpenConns)
+	sqlDB.SetMaxIdleConns(cfg.MaxIdleConns)
+	sqlDB.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+	sqlDB.SetConnMaxIdleTime(cfg.ConnMaxIdleTime)
+	return db, nil
+}
 
-	return db, sqlDB.Close
+// ping checks that the database answers within timeout, when positive.
+func ping(ctx context.Context, db *gorm.DB, timeout time.Duration) error {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return err
+	}
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	return sqlDB.PingContext(ctx)
 }
@@ -0,0 +1,105 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/mytheresa/go-hiring-challenge/app/logging"
+)
+
+// Check reports whether a dependency can serve requests.
+type Check func(ctx context.Context) error
+
+// Report is the body of the health endpoints. Checks maps each check name to
+// "ok" or "unavailable"; the probes are open, so the errors, which may tell
+// hosts and schema details, are only logged.
+type Report struct {
+	Status string            `json:"status"`
+	Checks map[string]string `json:"checks,omitempty"`
+}
+
+// Checker serves the liveness and readiness probes.
+//
+// Liveness only tells that the process answers, so the orchestrator does not
+// restart an instance because a dependency is down. Readiness runs every check
+// and fails once Drain is called, so load balancers stop routing to an
+// instance that is shutting down while it still serves in-flight requests.
+type Checker struct {
+	timeout  time.Duration
+	names    []string
+	checks   []Check
+	draining atomic.Bool
+}
+
+// NewChecker returns a Checker whose checks are each bounded by timeout, when positive.
+func NewChecker(timeout time.Duration) *Checker {
+	return &Checker{timeout: timeout}
+}
+
+// Add registers a readiness check. It must be called before serving requests.
+func (c *Checker) Add(name string, check Check) {
+	c.names = append(c.names, name)
+	c.checks = append(c.checks, check)
+}
+
+// Drain makes readiness fail from now on.
+func (c *Checker) Drain() {
+	c.draining.Store(true)
+}
+
+// HandleLive answers the liveness probe.
+func (c *Checker) HandleLive(w http.ResponseWriter, r *http.Request) {
+	writeReport(w, http.StatusOK, Report{Status: "ok"})
+}
+
+// HandleReady answers the readiness probe, running the checks concurrently.
+func (c *Checker) HandleReady(w http.ResponseWriter, r *http.Request) {
+	if c.draining.Load() {
+		writeReport(w, http.StatusServiceUnavailable, Report{Status: "draining"})
+		return
+	}
+
+	ctx := r.Context()
+	if c.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.timeout)
+		defer cancel()
+	}
+
+	errs := make([]error, len(c.checks))
+	var wg sync.WaitGroup
+	for i, check := range c.checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = check(ctx)
+		}()
+	}
+	wg.Wait()
+
+	report := Report{Status: "ok", Checks: make(map[string]string, len(c.checks))}
+	status := http.StatusOK
+	for i, name := range c.names {
+		if errs[i] != nil {
+			logging.FromContext(ctx).WarnContext(ctx, "readiness check failed", "check", name, "error", errs[i])
+			report.Checks[name] = "unavailable"
+			report.Status = "unavailable"
+			status = http.StatusServiceUnavailable
+			continue
+		}
+		report.Checks[name] = "ok"
+	}
+	writeReport(w, status, report)
+}
+
+// writeReport writes the report uncached, as it only describes this instance right now.
+func writeReport(w http.ResponseWriter, status int, report Report) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(report)
+}
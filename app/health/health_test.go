@@ -0,0 +1,89 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func ready(t *testing.T, c *Checker) (int, Report) {
+	t.Helper()
+
+	recorder := httptest.NewRecorder()
+	c.HandleReady(recorder, httptest.NewRequest("GET", "/readyz", nil))
+
+	var report Report
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &report))
+	return recorder.Code, report
+}
+
+func TestCheckerHandleLive(t *testing.T) {
+	c := NewChecker(time.Second)
+	c.Add("database", func(context.Context) error { return errors.New("connection refused") })
+	c.Drain()
+
+	recorder := httptest.NewRecorder()
+	c.HandleLive(recorder, httptest.NewRequest("GET", "/healthz", nil))
+
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.JSONEq(t, `{"status":"ok"}`, recorder.Body.String())
+}
+
+func TestCheckerHandleReady(t *testing.T) {
+	t.Run("reports ready when every check passes", func(t *testing.T) {
+		c := NewChecker(time.Second)
+		c.Add("database", func(context.Context) error { return nil })
+		c.Add("cache", func(context.Context) error { return nil })
+
+		code, report := ready(t, c)
+
+		assert.Equal(t, http.StatusOK, code)
+		assert.Equal(t, Report{Status: "ok", Checks: map[string]string{"database": "ok", "cache": "ok"}}, report)
+	})
+
+	t.Run("returns 503 naming the failed check", func(t *testing.T) {
+		c := NewChecker(time.Second)
+		c.Add("database", func(context.Context) error { return nil })
+		c.Add("migrations", func(context.Context) error { return errors.New("column products.version is missing") })
+
+		code, report := ready(t, c)
+
+		assert.Equal(t, http.StatusServiceUnavailable, code)
+		assert.Equal(t, "unavailable", report.Status)
+		assert.Equal(t, map[string]string{"database": "ok", "migrations": "unavailable"}, report.Checks)
+	})
+
+	t.Run("bounds slow checks by the timeout", func(t *testing.T) {
+		c := NewChecker(10 * time.Millisecond)
+		c.Add("cache", func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		})
+
+		code, report := ready(t, c)
+
+		assert.Equal(t, http.StatusServiceUnavailable, code)
+		assert.Equal(t, "unavailable", report.Checks["cache"])
+	})
+
+	t.Run("fails without running the checks once draining", func(t *testing.T) {
+		c := NewChecker(time.Second)
+		c.Add("database", func(context.Context) error {
+			t.Error("check ran while draining")
+			return nil
+		})
+		c.Drain()
+
+		code, report := ready(t, c)
+
+		assert.Equal(t, http.StatusServiceUnavailable, code)
+		assert.Equal(t, "draining", report.Status)
+	})
+}
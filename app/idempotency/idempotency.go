@@ -0,0 +1,207 @@
+// Package idempotency makes POST requests safe to retry: a request sent with
+// an Idempotency-Key header is processed once, and retries with the same key
+// get the stored response.
+package idempotency
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/mytheresa/go-hiring-challenge/app/api"
+	"github.com/mytheresa/go-hiring-challenge/app/auth"
+	"github.com/mytheresa/go-hiring-challenge/app/logging"
+	"github.com/mytheresa/go-hiring-challenge/app/middleware"
+	"github.com/mytheresa/go-hiring-challenge/models"
+)
+
+const (
+	// Header carries the key a client chose for a request.
+	Header = "Idempotency-Key"
+	// ReplayedHeader marks responses replayed from an earlier request.
+	ReplayedHeader = "Idempotent-Replayed"
+
+	// maxKeyLength is the longest key accepted.
+	maxKeyLength = 255
+)
+
+// Store keeps the requests sent with a key and their responses.
+type Store interface {
+	// Reserve records req as being processed and returns nil, or returns the
+	// request recorded earlier under the same principal and key.
+	Reserve(ctx context.Context, req *models.IdempotentRequest) (*models.IdempotentRequest, error)
+	// Complete stores the response of a reserved request.
+	Complete(ctx context.Context, req *models.IdempotentRequest) error
+	// Release forgets a reserved request, so its key can be used again.
+	Release(ctx context.Context, req *models.IdempotentRequest) error
+}
+
+// New returns middleware processing each POST request sent with an
+// Idempotency-Key once per key and client, remembering its response for
+// retention. A retry with the same key gets the stored response, marked with
+// Idempotent-Replayed. Reusing a key for a different request, i.e. another
+// path or body, is rejected with 422, and a retry arriving while the first
+// request is still processed gets 409. Server errors are not stored, so such
+// requests can be retried with the same key.
+//
+// Keys belong to the authenticated principal, so the middleware must run
+// after the request is authenticated. Requests without the header, and other
+// methods, are passed on as they are.
+func New(store Store, retention time.Duration) middleware.Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := r.Header.Get(Header)
+			if r.Method != http.MethodPost || key == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if len(key) > maxKeyLength {
+				api.ErrorResponse(w, http.StatusBadRequest, "Idempotency-Key must not be longer than 255 characters")
+				return
+			}
+
+			body, err := io.ReadAll(r.Body)
+			if err != nil {
+				var tooLarge *http.MaxBytesError
+				if errors.As(err, &tooLarge) {
+					api.ErrorResponse(w, http.StatusRequestEntityTooLarge, "Request body too large")
+				} else {
+					api.ErrorResponse(w, http.StatusBadRequest, "Invalid request body")
+				}
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+
+			ctx := r.Context()
+			req := &models.IdempotentRequest{
+				Key:         key,
+				Fingerprint: fingerprint(r, body),
+				ExpiresAt:   time.Now().Add(retention),
+			}
+			if p, ok := auth.FromContext(ctx); ok {
+				req.Principal = p.Subject
+			}
+
+			existing, err := store.Reserve(ctx, req)
+			if err != nil {
+				api.InternalError(w, r, err)
+				return
+			}
+			if existing != nil {
+				replay(w, existing, req.Fingerprint)
+				return
+			}
+
+			rec := &recorder{StatusRecorder: api.NewStatusRecorder(w)}
+			before := w.Header().Clone()
+			// The reservation is released unless the response is stored,
+			// also when the handler panics, and even if the request was
+			// cancelled.
+			stored := false
+			defer func() {
+				if stored {
+					return
+				}
+				ctx := context.WithoutCancel(ctx)
+				if err := store.Release(ctx, req); err != nil {
+					logging.FromContext(ctx).ErrorContext(ctx, "releasing idempotency key failed", "error", err)
+				}
+			}()
+
+			next.ServeHTTP(rec, r)
+
+			if rec.Status >= http.StatusInternalServerError {
+				return
+			}
+			headers, _ := json.Marshal(changedHeaders(before, w.Header()))
+			req.StatusCode = &rec.Status
+			req.Headers = string(headers)
+			req.Body = rec.body.Bytes()
+
+			ctx = context.WithoutCancel(ctx)
+			if err := store.Complete(ctx, req); err != nil {
+				logging.FromContext(ctx).ErrorContext(ctx, "storing idempotent response failed", "error", err)
+				return
+			}
+			stored = true
+		})
+	}
+}
+
+// fingerprint identifies a request by its method, path and body.
+func fingerprint(r *http.Request, body []byte) string {
+	h := sha256.New()
+	io.WriteString(h, r.Method+" "+r.URL.Path+"\n")
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// replay answers a request whose key was used before with the stored response,
+// unless the key was used for a different request or that request is still
+// being processed.
+func replay(w http.ResponseWriter, existing *models.IdempotentRequest, fingerprint string) {
+	if existing.Fingerprint != fingerprint {
+		api.ProblemResponse(w, http.StatusUnprocessableEntity, api.NewProblem(http.StatusUnprocessableEntity,
+			"This Idempotency-Key was already used for a different request."))
+		return
+	}
+	if existing.StatusCode == nil {
+		w.Header().Set("Retry-After", "1")
+		api.ProblemResponse(w, http.StatusConflict, api.NewProblem(http.StatusConflict,
+			"A request with this Idempotency-Key is still being processed."))
+		return
+	}
+
+	var headers http.Header
+	json.Unmarshal([]byte(existing.Headers), &headers)
+	for name, values := range headers {
+		w.Header()[name] = values
+	}
+	w.Header().Set(ReplayedHeader, "true")
+	w.WriteHeader(*existing.StatusCode)
+	w.Write(existing.Body)
+}
+
+// transportHeaders describe how a response is sent rather than the response
+// itself. Compression sets them around the middleware, on the stored body's
+// encoded form, so a replay, which is compressed afresh, must not repeat them.
+var transportHeaders = []string{"Content-Encoding", "Content-Length", "Vary"}
+
+// changedHeaders returns the headers of after that differ from before, i.e.
+// the ones set by the handler rather than by the middleware around it. The
+// transport headers are left out, and the ETag loses the suffix compression
+// gives it.
+func changedHeaders(before, after http.Header) http.Header {
+	changed := http.Header{}
+	for name, values := range after {
+		if !slices.Equal(before[name], values) && !slices.Contains(transportHeaders, name) {
+			changed[name] = slices.Clone(values)
+		}
+	}
+	if encoding := after.Get("Content-Encoding"); encoding != "" {
+		if etag, ok := strings.CutSuffix(changed.Get("ETag"), "-"+encoding+`"`); ok {
+			changed.Set("ETag", etag+`"`)
+		}
+	}
+	return changed
+}
+
+// recorder captures the body written through it as well.
+type recorder struct {
+	*api.StatusRecorder
+	body bytes.Buffer
+}
+
+func (rec *recorder) Write(b []byte) (int, error) {
+	n, err := rec.StatusRecorder.Write(b)
+	rec.body.Write(b[:n])
+	return n, err
+}
@@ -0,0 +1,260 @@
+package idempotency
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mytheresa/go-hiring-challenge/app/auth"
+	"github.com/mytheresa/go-hiring-challenge/app/compression"
+	"github.com/mytheresa/go-hiring-challenge/models"
+)
+
+// memoryStore keeps the requests in a map, keyed by principal and key.
+type memoryStore struct {
+	requests map[string]*models.IdempotentRequest
+	err      error
+}
+
+func newMemoryStore() *memoryStore {
+	return &memoryStore{requests: map[string]*models.IdempotentRequest{}}
+}
+
+func (s *memoryStore) Reserve(_ context.Context, req *models.IdempotentRequest) (*models.IdempotentRequest, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	if existing, ok := s.requests[req.Principal+" "+req.Key]; ok {
+		return existing, nil
+	}
+	stored := *req
+	s.requests[req.Principal+" "+req.Key] = &stored
+	return nil, nil
+}
+
+func (s *memoryStore) Complete(_ context.Context, req *models.IdempotentRequest) error {
+	stored := *req
+	s.requests[req.Principal+" "+req.Key] = &stored
+	return nil
+}
+
+func (s *memoryStore) Release(_ context.Context, req *models.IdempotentRequest) error {
+	delete(s.requests, req.Principal+" "+req.Key)
+	return nil
+}
+
+// countingHandler creates a resource per call, answering with its number.
+type countingHandler struct {
+	calls  int
+	status int
+}
+
+func (h *countingHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.calls++
+	body, _ := io.ReadAll(r.Body)
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("ETag", strconv.Quote(strconv.Itoa(h.calls)))
+	w.WriteHeader(h.status)
+	w.Write([]byte(`{"call":` + strconv.Itoa(h.calls) + `,"body":` + string(body) + `}`))
+}
+
+func post(subject, key, body string) *http.Request {
+	r := httptest.NewRequest(http.MethodPost, "/categories", strings.NewReader(body))
+	if key != "" {
+		r.Header.Set(Header, key)
+	}
+	return r.WithContext(auth.WithPrincipal(r.Context(), &auth.Principal{Subject: subject}))
+}
+
+func TestNew(t *testing.T) {
+	setup := func(status int) (*memoryStore, *countingHandler, http.Handler) {
+		store := newMemoryStore()
+		next := &countingHandler{status: status}
+		return store, next, New(store, time.Hour)(next)
+	}
+	serve := func(h http.Handler, r *http.Request) *httptest.ResponseRecorder {
+		res := httptest.NewRecorder()
+		res.Header().Set("X-Request-ID", "set-by-outer-middleware")
+		h.ServeHTTP(res, r)
+		return res
+	}
+
+	t.Run("replays the response of a retry", func(t *testing.T) {
+		store, next, h := setup(http.StatusOK)
+
+		first := serve(h, post("apikey:a", "import-1", `{"code":"shoes"}`))
+		retry := serve(h, post("apikey:a", "import-1", `{"code":"shoes"}`))
+
+		assert.Equal(t, 1, next.calls)
+		assert.Equal(t, http.StatusOK, retry.Code)
+		assert.Equal(t, first.Body.String(), retry.Body.String())
+		assert.Equal(t, `"1"`, retry.Header().Get("ETag"))
+		assert.Equal(t, "application/json", retry.Header().Get("Content-Type"))
+		assert.Equal(t, "true", retry.Header().Get(ReplayedHeader))
+		assert.Empty(t, first.Header().Get(ReplayedHeader))
+
+		stored := store.requests["apikey:a import-1"]
+		require.NotNil(t, stored.StatusCode)
+		assert.NotContains(t, stored.Headers, "X-Request-Id")
+		assert.WithinDuration(t, time.Now().Add(time.Hour), stored.ExpiresAt, time.Minute)
+	})
+
+	t.Run("stores client errors as well", func(t *testing.T) {
+		_, next, h := setup(http.StatusConflict)
+
+		serve(h, post("apikey:a", "import-1", `{}`))
+		retry := serve(h, post("apikey:a", "import-1", `{}`))
+
+		assert.Equal(t, 1, next.calls)
+		assert.Equal(t, http.StatusConflict, retry.Code)
+	})
+
+	t.Run("keeps the keys of each client apart", func(t *testing.T) {
+		_, next, h := setup(http.StatusOK)
+
+		serve(h, post("apikey:a", "import-1", `{}`))
+		res := serve(h, post("apikey:b", "import-1", `{}`))
+
+		assert.Equal(t, 2, next.calls)
+		assert.Empty(t, res.Header().Get(ReplayedHeader))
+	})
+
+	t.Run("rejects a key reused for another request", func(t *testing.T) {
+		_, next, h := setup(http.StatusOK)
+
+		serve(h, post("apikey:a", "import-1", `{"code":"shoes"}`))
+		res := serve(h, post("apikey:a", "import-1", `{"code":"bags"}`))
+
+		assert.Equal(t, 1, next.calls)
+		assert.Equal(t, http.StatusUnprocessableEntity, res.Code)
+		assert.Equal(t, "application/problem+json", res.Header().Get("Content-Type"))
+		assert.Contains(t, res.Body.String(), "already used for a different request")
+	})
+
+	t.Run("rejects a retry while the request is processed", func(t *testing.T) {
+		store, next, h := setup(http.StatusOK)
+		store.requests["apikey:a import-1"] = &models.IdempotentRequest{
+			Principal:   "apikey:a",
+			Key:         "import-1",
+			Fingerprint: fingerprint(post("", "", ""), []byte(`{}`)),
+		}
+
+		res := serve(h, post("apikey:a", "import-1", `{}`))
+
+		assert.Equal(t, 0, next.calls)
+		assert.Equal(t, http.StatusConflict, res.Code)
+		assert.Equal(t, "1", res.Header().Get("Retry-After"))
+	})
+
+	t.Run("lets server errors be retried", func(t *testing.T) {
+		store, next, h := setup(http.StatusInternalServerError)
+
+		serve(h, post("apikey:a", "import-1", `{}`))
+		serve(h, post("apikey:a", "import-1", `{}`))
+
+		assert.Equal(t, 2, next.calls)
+		assert.Empty(t, store.requests)
+	})
+
+	t.Run("releases the key when the handler panics", func(t *testing.T) {
+		store := newMemoryStore()
+		h := New(store, time.Hour)(http.HandlerFunc(func(http.ResponseWriter, *http.Request) { panic("boom") }))
+
+		assert.Panics(t, func() { serve(h, post("apikey:a", "import-1", `{}`)) })
+		assert.Empty(t, store.requests)
+	})
+
+	t.Run("passes requests without a key and other methods", func(t *testing.T) {
+		store, next, h := setup(http.StatusOK)
+
+		serve(h, post("apikey:a", "", `{}`))
+		serve(h, post("apikey:a", "", `{}`))
+		patch := post("apikey:a", "import-1", `{}`)
+		patch.Method = http.MethodPatch
+		serve(h, patch)
+
+		assert.Equal(t, 3, next.calls)
+		assert.Empty(t, store.requests)
+	})
+
+	t.Run("rejects overlong keys", func(t *testing.T) {
+		_, next, h := setup(http.StatusOK)
+
+		res := serve(h, post("apikey:a", strings.Repeat("k", 256), `{}`))
+
+		assert.Equal(t, http.StatusBadRequest, res.Code)
+		assert.Equal(t, 0, next.calls)
+	})
+
+	t.Run("rejects bodies over the size limit", func(t *testing.T) {
+		_, next, h := setup(http.StatusOK)
+		r := post("apikey:a", "import-1", `{"code":"shoes"}`)
+		r.Body = http.MaxBytesReader(httptest.NewRecorder(), r.Body, 4)
+
+		res := serve(h, r)
+
+		assert.Equal(t, http.StatusRequestEntityTooLarge, res.Code)
+		assert.Equal(t, 0, next.calls)
+	})
+
+	t.Run("fails when the store does", func(t *testing.T) {
+		store, next, h := setup(http.StatusOK)
+		store.err = errors.New("connection refused")
+
+		res := serve(h, post("apikey:a", "import-1", `{}`))
+
+		assert.Equal(t, http.StatusInternalServerError, res.Code)
+		assert.Equal(t, 0, next.calls)
+	})
+}
+
+func TestNewWithinCompression(t *testing.T) {
+	store := newMemoryStore()
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("ETag", `"1"`)
+		w.Write([]byte(`{"name":"` + strings.Repeat("a", 2048) + `"}`))
+	})
+	h := compression.New(compression.Options{Encodings: []string{compression.EncodingGzip}, MinSize: 1024})(New(store, time.Hour)(next))
+
+	first := post("apikey:a", "import-1", `{}`)
+	first.Header.Set("Accept-Encoding", "gzip")
+	res := httptest.NewRecorder()
+	h.ServeHTTP(res, first)
+	require.Equal(t, "gzip", res.Header().Get("Content-Encoding"))
+	assert.Equal(t, `"1-gzip"`, res.Header().Get("ETag"))
+
+	stored := store.requests["apikey:a import-1"]
+	require.NotNil(t, stored.StatusCode)
+	assert.NotContains(t, stored.Headers, "Content-Encoding")
+	assert.NotContains(t, stored.Headers, "Vary")
+	assert.Contains(t, stored.Headers, `\"1\"`)
+
+	retry := post("apikey:a", "import-1", `{}`)
+	retry.Header.Set("Accept-Encoding", "identity")
+	res = httptest.NewRecorder()
+	h.ServeHTTP(res, retry)
+
+	assert.Equal(t, "true", res.Header().Get(ReplayedHeader))
+	assert.Empty(t, res.Header().Get("Content-Encoding"))
+	assert.Equal(t, `"1"`, res.Header().Get("ETag"))
+	assert.JSONEq(t, `{"name":"`+strings.Repeat("a", 2048)+`"}`, res.Body.String())
+}
+
+func TestFingerprint(t *testing.T) {
+	shoes := fingerprint(post("", "", ""), []byte(`{"code":"shoes"}`))
+
+	assert.Len(t, shoes, 64)
+	assert.Equal(t, shoes, fingerprint(post("", "", ""), []byte(`{"code":"shoes"}`)))
+	assert.NotEqual(t, shoes, fingerprint(post("", "", ""), []byte(`{"code":"bags"}`)))
+	assert.NotEqual(t, shoes, fingerprint(httptest.NewRequest(http.MethodPost, "/admin/promotions", nil), []byte(`{"code":"shoes"}`)))
+}
@@ -0,0 +1,76 @@
+package logging
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+// GormLogger logs the statements run by the repositories through the logger of
+// their context, so they share the request ID of the request that issued them.
+// Failed statements are logged at error level, statements slower than
+// SlowThreshold at warn level and all others at debug level.
+type GormLogger struct {
+	SlowThreshold time.Duration
+}
+
+var (
+	_ gormlogger.Interface = GormLogger{}
+	_ gorm.ParamsFilter    = GormLogger{}
+)
+
+// LogMode is a no-op: the level of the context logger applies instead.
+func (l GormLogger) LogMode(gormlogger.LogLevel) gormlogger.Interface {
+	return l
+}
+
+func (l GormLogger) Info(ctx context.Context, msg string, args ...any) {
+	FromContext(ctx).InfoContext(ctx, msg, "args", args)
+}
+
+func (l GormLogger) Warn(ctx context.Context, msg string, args ...any) {
+	FromContext(ctx).WarnContext(ctx, msg, "args", args)
+}
+
+func (l GormLogger) Error(ctx context.Context, msg string, args ...any) {
+	FromContext(ctx).ErrorContext(ctx, msg, "args", args)
+}
+
+// ParamsFilter drops the statement parameters, so logged SQL keeps its
+// placeholders and no customer data ends up in the logs.
+func (l GormLogger) ParamsFilter(ctx context.Context, sql string, params ...any) (string, []any) {
+	return sql, nil
+}
+
+// Trace logs a statement once it ran.
+func (l GormLogger) Trace(ctx context.Context, begin time.Time, fc func() (sql string, rowsAffected int64), err error) {
+	logger := FromContext(ctx)
+	elapsed := time.Since(begin)
+
+	level := slog.LevelDebug
+	msg := "query"
+	switch {
+	case err != nil && !errors.Is(err, gorm.ErrRecordNotFound) && !errors.Is(err, context.Canceled):
+		level, msg = slog.LevelError, "query failed"
+	case l.SlowThreshold > 0 && elapsed > l.SlowThreshold:
+		level, msg = slog.LevelWarn, "slow query"
+	}
+	if !logger.Enabled(ctx, level) {
+		return
+	}
+
+	sql, rows := fc()
+	attrs := []slog.Attr{
+		slog.String("sql", sql),
+		slog.Int64("rows", rows),
+		slog.Float64("duration_ms", float64(elapsed.Microseconds())/1000),
+	}
+	if err != nil {
+		attrs = append(attrs, slog.String("error", err.Error()))
+	}
+	logger.LogAttrs(ctx, level, msg, attrs...)
+}
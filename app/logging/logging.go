@@ -0,0 +1,97 @@
+package logging
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Formats accepted in Options.Format.
+const (
+	FormatJSON = "json"
+	FormatText = "text"
+)
+
+// Options configures the logger.
+type Options struct {
+	// Level is debug, info, warn or error.
+	Level string
+	// Format is json, for log collectors, or text, for reading locally.
+	Format string
+}
+
+// Validate reports an unknown level or format.
+func (o Options) Validate() error {
+	var errs []error
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(o.Level)); err != nil {
+		errs = append(errs, fmt.Errorf("invalid log level %q", o.Level))
+	}
+	if o.Format != FormatJSON && o.Format != FormatText {
+		errs = append(errs, fmt.Errorf("log format must be json or text, got %q", o.Format))
+	}
+	return errors.Join(errs...)
+}
+
+// New returns a logger writing to w. Records logged with a context carrying a
+// sampled span include its trace and span IDs, so logs and traces can be joined.
+func New(w io.Writer, opts Options) (*slog.Logger, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+
+	var level slog.Level
+	level.UnmarshalText([]byte(opts.Level))
+	handlerOpts := &slog.HandlerOptions{Level: level}
+
+	var h slog.Handler
+	if opts.Format == FormatText {
+		h = slog.NewTextHandler(w, handlerOpts)
+	} else {
+		h = slog.NewJSONHandler(w, handlerOpts)
+	}
+	return slog.New(traceHandler{h}), nil
+}
+
+// traceHandler adds the IDs of the span found in the record's context.
+type traceHandler struct {
+	slog.Handler
+}
+
+func (h traceHandler) Handle(ctx context.Context, r slog.Record) error {
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+		r.AddAttrs(
+			slog.String("trace_id", sc.TraceID().String()),
+			slog.String("span_id", sc.SpanID().String()),
+		)
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h traceHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return traceHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h traceHandler) WithGroup(name string) slog.Handler {
+	return traceHandler{h.Handler.WithGroup(name)}
+}
+
+type loggerKey struct{}
+
+// WithLogger returns a copy of ctx carrying logger.
+func WithLogger(ctx context.Context, logger *slog.Logger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, logger)
+}
+
+// FromContext returns the logger carried by ctx, or the default logger.
+// Pass ctx to its methods too, so records include the trace IDs.
+func FromContext(ctx context.Context) *slog.Logger {
+	if logger, ok := ctx.Value(loggerKey{}).(*slog.Logger); ok {
+		return logger
+	}
+	return slog.Default()
+}
@@ -0,0 +1,120 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+)
+
+// newTestLogger returns a JSON logger at the given level and a function decoding what it wrote.
+func newTestLogger(t *testing.T, level string) (*slog.Logger, func() []map[string]any) {
+	t.Helper()
+
+	var buf bytes.Buffer
+	logger, err := New(&buf, Options{Level: level, Format: FormatJSON})
+	require.NoError(t, err)
+	return logger, func() []map[string]any {
+		var records []map[string]any
+		for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+			if line == "" {
+				continue
+			}
+			var record map[string]any
+			require.NoError(t, json.Unmarshal([]byte(line), &record))
+			records = append(records, record)
+		}
+		return records
+	}
+}
+
+func TestOptionsValidate(t *testing.T) {
+	assert.NoError(t, Options{Level: "debug", Format: FormatText}.Validate())
+	assert.Error(t, Options{Level: "verbose", Format: FormatJSON}.Validate())
+	assert.Error(t, Options{Level: "info", Format: "logfmt"}.Validate())
+}
+
+func TestNew(t *testing.T) {
+	t.Run("adds the IDs of the span in the context", func(t *testing.T) {
+		logger, records := newTestLogger(t, "info")
+		sc := trace.NewSpanContext(trace.SpanContextConfig{
+			TraceID:    trace.TraceID{0x4b, 0xf9},
+			SpanID:     trace.SpanID{0x00, 0xf0},
+			TraceFlags: trace.FlagsSampled,
+		})
+		ctx := trace.ContextWithSpanContext(context.Background(), sc)
+
+		logger.With("request_id", "abc").InfoContext(ctx, "hello")
+
+		got := records()
+		require.Len(t, got, 1)
+		assert.Equal(t, "abc", got[0]["request_id"])
+		assert.Equal(t, sc.TraceID().String(), got[0]["trace_id"])
+		assert.Equal(t, sc.SpanID().String(), got[0]["span_id"])
+	})
+
+	t.Run("drops records below the level", func(t *testing.T) {
+		logger, records := newTestLogger(t, "warn")
+
+		logger.Info("ignored")
+		logger.Warn("kept")
+
+		got := records()
+		require.Len(t, got, 1)
+		assert.Equal(t, "kept", got[0]["msg"])
+	})
+}
+
+func TestFromContext(t *testing.T) {
+	logger, _ := newTestLogger(t, "info")
+
+	assert.Same(t, logger, FromContext(WithLogger(context.Background(), logger)))
+	assert.Same(t, slog.Default(), FromContext(context.Background()))
+}
+
+func TestGormLoggerTrace(t *testing.T) {
+	query := func() (string, int64) { return `SELECT * FROM "products" WHERE code = $1`, 1 }
+
+	t.Run("logs failures with the request logger", func(t *testing.T) {
+		logger, records := newTestLogger(t, "info")
+		ctx := WithLogger(context.Background(), logger.With("request_id", "abc"))
+
+		GormLogger{SlowThreshold: time.Second}.Trace(ctx, time.Now(), query, errors.New("deadlock detected"))
+
+		got := records()
+		require.Len(t, got, 1)
+		assert.Equal(t, "ERROR", got[0]["level"])
+		assert.Equal(t, "abc", got[0]["request_id"])
+		assert.Equal(t, "deadlock detected", got[0]["error"])
+		assert.Equal(t, `SELECT * FROM "products" WHERE code = $1`, got[0]["sql"])
+	})
+
+	t.Run("logs slow statements as warnings", func(t *testing.T) {
+		logger, records := newTestLogger(t, "info")
+		ctx := WithLogger(context.Background(), logger)
+
+		GormLogger{SlowThreshold: time.Millisecond}.Trace(ctx, time.Now().Add(-time.Second), query, nil)
+
+		got := records()
+		require.Len(t, got, 1)
+		assert.Equal(t, "slow query", got[0]["msg"])
+	})
+
+	t.Run("logs missing records only at debug level", func(t *testing.T) {
+		logger, records := newTestLogger(t, "info")
+		ctx := WithLogger(context.Background(), logger)
+
+		GormLogger{SlowThreshold: time.Second}.Trace(ctx, time.Now(), query, gorm.ErrRecordNotFound)
+
+		assert.Empty(t, records())
+	})
+}
@@ -0,0 +1,37 @@
+package metrics
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+func TestDBInstrument(t *testing.T) {
+	// A dry run executes the callbacks without reaching a database.
+	db, err := gorm.Open(postgres.Open("postgres://app@127.0.0.1:1/challenge"), &gorm.Config{
+		DryRun:               true,
+		DisableAutomaticPing: true,
+	})
+	require.NoError(t, err)
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	t.Cleanup(func() { sqlDB.Close() })
+	sqlDB.SetMaxOpenConns(7)
+
+	reg := NewRegistry()
+	require.NoError(t, NewDB(reg).Instrument("primary", db))
+
+	var rows []map[string]any
+	db.Table("products").Find(&rows)
+	db.Table("products").Where("code = ?", "PROD001").Find(&rows)
+	db.Table("categories").Where("code = ?", "shoes").Delete(nil)
+
+	out := scrape(reg)
+	assert.Contains(t, out, `db_query_duration_seconds_count{operation="query",pool="primary",table="products"} 2`)
+	assert.Contains(t, out, `db_query_duration_seconds_count{operation="delete",pool="primary",table="categories"} 1`)
+	assert.Contains(t, out, `go_sql_max_open_connections{db_name="primary"} 7`)
+	assert.Contains(t, out, `go_sql_wait_count_total{db_name="primary"} 0`)
+}
@@ -0,0 +1,56 @@
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+
+	"github.com/mytheresa/go-hiring-challenge/app/api"
+)
+
+// InstrumentHandler records the count, status and latency of the requests
+// served by mux, labelled by the ServeMux pattern that matched them so that
+// path parameters do not multiply the series. Requests no pattern matched are
+// labelled "unmatched", and methods outside the standard ones "_OTHER", since
+// clients may send any method token.
+func InstrumentHandler(reg *Registry, mux http.Handler) http.Handler {
+	factory := promauto.With(reg)
+	requests := factory.NewCounterVec(prometheus.CounterOpts{
+		Name: "http_requests_total",
+		Help: "HTTP requests served, by route and status code.",
+	}, []string{"method", "route", "code"})
+	duration := factory.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "http_request_duration_seconds",
+		Help:    "Time to serve HTTP requests, by route.",
+		Buckets: DefaultBuckets,
+	}, []string{"method", "route"})
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := api.NewStatusRecorder(w)
+		mux.ServeHTTP(rec, r)
+
+		// ServeMux sets the pattern on the request it was given.
+		route := r.Pattern
+		if route == "" {
+			route = "unmatched"
+		}
+		method := methodLabel(r.Method)
+		requests.WithLabelValues(method, route, strconv.Itoa(rec.Status)).Inc()
+		duration.WithLabelValues(method, route).Observe(time.Since(start).Seconds())
+	})
+}
+
+// methodLabel returns method if it is a standard HTTP method, and "_OTHER"
+// otherwise.
+func methodLabel(method string) string {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch,
+		http.MethodDelete, http.MethodConnect, http.MethodOptions, http.MethodTrace:
+		return method
+	}
+	return "_OTHER"
+}
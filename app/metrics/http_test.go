@@ -0,0 +1,38 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInstrumentHandler(t *testing.T) {
+	reg := NewRegistry()
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /catalog/{code}", func(w http.ResponseWriter, r *http.Request) {
+		if r.PathValue("code") == "missing" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte("ok"))
+	})
+	handler := InstrumentHandler(reg, mux)
+
+	for _, path := range []string{"/catalog/PROD001", "/catalog/PROD002", "/catalog/missing", "/unknown"} {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", path, nil))
+	}
+	for _, method := range []string{"FOO1", "FOO2"} {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(method, "/catalog/PROD001", nil))
+	}
+
+	out := scrape(reg)
+	assert.Contains(t, out, `http_requests_total{code="200",method="GET",route="GET /catalog/{code}"} 2`)
+	assert.Contains(t, out, `http_requests_total{code="404",method="GET",route="GET /catalog/{code}"} 1`)
+	assert.Contains(t, out, `http_requests_total{code="404",method="GET",route="unmatched"} 1`)
+	assert.Contains(t, out, `http_requests_total{code="405",method="_OTHER",route="unmatched"} 2`)
+	assert.Contains(t, out, `http_request_duration_seconds_count{method="GET",route="GET /catalog/{code}"} 3`)
+	assert.NotContains(t, out, "PROD001")
+	assert.NotContains(t, out, "FOO")
+}
@@ -0,0 +1,32 @@
+package metrics
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// DefaultBuckets are the latency buckets, in seconds, used by the HTTP and query histograms.
+var DefaultBuckets = prometheus.DefBuckets
+
+// Registry holds the metrics served in the Prometheus exposition formats,
+// along with the runtime and process metrics of the Go collectors.
+type Registry struct {
+	*prometheus.Registry
+}
+
+func NewRegistry() *Registry {
+	reg := prometheus.NewRegistry()
+	reg.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+	return &Registry{Registry: reg}
+}
+
+// Handler serves the current value of every registered metric.
+func (r *Registry) Handler() http.HandlerFunc {
+	return promhttp.HandlerFor(r.Registry, promhttp.HandlerOpts{}).ServeHTTP
+}
@@ -0,0 +1,22 @@
+package metrics
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func scrape(reg *Registry) string {
+	recorder := httptest.NewRecorder()
+	reg.Handler()(recorder, httptest.NewRequest("GET", "/metrics", nil))
+	return recorder.Body.String()
+}
+
+func TestRegistryHandler(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	NewRegistry().Handler()(recorder, httptest.NewRequest("GET", "/metrics", nil))
+
+	assert.Contains(t, recorder.Header().Get("Content-Type"), "text/plain; version=0.0.4")
+	assert.Contains(t, recorder.Body.String(), "# TYPE go_goroutines gauge")
+}
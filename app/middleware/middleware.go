@@ -0,0 +1,107 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"runtime/debug"
+	"time"
+
+	"github.com/mytheresa/go-hiring-challenge/app/api"
+	"github.com/mytheresa/go-hiring-challenge/app/logging"
+)
+
+// Middleware wraps a handler with behaviour shared by several routes.
+type Middleware func(http.Handler) http.Handler
+
+// Chain is an ordered list of middleware. The first one is the outermost: it
+// sees the request first and the response last.
+//
+// Middleware chained in front of the ServeMux must pass the request they were
+// given on to the next handler rather than a copy from r.WithContext, unless
+// nothing before them reads the matched route from r.Pattern.
+type Chain []Middleware
+
+// NewChain returns a chain applying mws in order.
+func NewChain(mws ...Middleware) Chain {
+	return Chain(mws)
+}
+
+// Append returns a new chain running mws after the middleware of c. c is not
+// modified, so route groups can extend a shared base chain.
+func (c Chain) Append(mws ...Middleware) Chain {
+	chain := make(Chain, 0, len(c)+len(mws))
+	chain = append(chain, c...)
+	return append(chain, mws...)
+}
+
+// Then wraps h with the middleware of the chain.
+func (c Chain) Then(h http.Handler) http.Handler {
+	for i := len(c) - 1; i >= 0; i-- {
+		h = c[i](h)
+	}
+	return h
+}
+
+// ThenFunc wraps the handler function h with the middleware of the chain.
+func (c Chain) ThenFunc(h http.HandlerFunc) http.Handler {
+	return c.Then(h)
+}
+
+// Recover turns a panicking handler into a JSON 500 response and logs the
+// panic with its stack. A response already under way cannot be replaced, so
+// its connection is aborted instead.
+func Recover(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rec := api.NewStatusRecorder(w)
+		defer func() {
+			v := recover()
+			if v == nil {
+				return
+			}
+			if v == http.ErrAbortHandler {
+				panic(v)
+			}
+
+			logging.FromContext(r.Context()).ErrorContext(r.Context(), "handler panicked",
+				"panic", fmt.Sprint(v), "stack", string(debug.Stack()))
+			if rec.WroteHeader() {
+				panic(http.ErrAbortHandler)
+			}
+			api.ErrorResponse(rec, http.StatusInternalServerError, "Internal server error")
+		}()
+		next.ServeHTTP(rec, r)
+	})
+}
+
+// MaxBodySize limits request bodies to limit bytes. Requests declaring a
+// larger Content-Length are rejected with 413 up front; a body streamed past
+// the limit fails to read, which handlers report as an invalid body.
+func MaxBodySize(limit int64) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.ContentLength > limit {
+				api.ErrorResponse(w, http.StatusRequestEntityTooLarge, "Request body too large")
+				return
+			}
+			r.Body = http.MaxBytesReader(w, r.Body, limit)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// Timeout bounds the request context by d, cancelling the queries of a
+// request still running after it. Handlers answer such requests with 503.
+// A non-positive d leaves requests unbounded.
+func Timeout(d time.Duration) Middleware {
+	return func(next http.Handler) http.Handler {
+		if d <= 0 {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx, cancel := context.WithTimeout(r.Context(), d)
+			defer cancel()
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
@@ -0,0 +1,146 @@
+package middleware
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// tag returns middleware appending name to the trace before and after the next handler.
+func tag(trace *[]string, name string) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			*trace = append(*trace, name)
+			next.ServeHTTP(w, r)
+			*trace = append(*trace, "/"+name)
+		})
+	}
+}
+
+func TestChain(t *testing.T) {
+	t.Run("runs the first middleware outermost", func(t *testing.T) {
+		var trace []string
+		handler := NewChain(tag(&trace, "a"), tag(&trace, "b")).ThenFunc(func(w http.ResponseWriter, r *http.Request) {
+			trace = append(trace, "handler")
+		})
+
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+
+		assert.Equal(t, []string{"a", "b", "handler", "/b", "/a"}, trace)
+	})
+
+	t.Run("extends a base chain without changing it", func(t *testing.T) {
+		var trace []string
+		base := make(Chain, 1, 4)
+		base[0] = tag(&trace, "base")
+		first := base.Append(tag(&trace, "first"))
+		second := base.Append(tag(&trace, "second"))
+		noop := http.HandlerFunc(func(http.ResponseWriter, *http.Request) {})
+
+		first.Then(noop).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+
+		assert.Len(t, base, 1)
+		assert.Len(t, second, 2)
+		assert.Equal(t, []string{"base", "first", "/first", "/base"}, trace)
+	})
+}
+
+func TestRecover(t *testing.T) {
+	t.Run("answers a panic with a JSON 500", func(t *testing.T) {
+		handler := Recover(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var m map[string]int
+			m["boom"]++
+		}))
+		recorder := httptest.NewRecorder()
+
+		handler.ServeHTTP(recorder, httptest.NewRequest("GET", "/catalog", nil))
+
+		assert.Equal(t, http.StatusInternalServerError, recorder.Code)
+		assert.Equal(t, "application/json", recorder.Header().Get("Content-Type"))
+		assert.JSONEq(t, `{"error":"Internal server error"}`, recorder.Body.String())
+	})
+
+	t.Run("aborts a response already under way", func(t *testing.T) {
+		handler := Recover(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(`{"products":[`))
+			panic("encoder failed")
+		}))
+
+		assert.PanicsWithValue(t, http.ErrAbortHandler, func() {
+			handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/catalog", nil))
+		})
+	})
+
+	t.Run("lets deliberate aborts through", func(t *testing.T) {
+		handler := Recover(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			panic(http.ErrAbortHandler)
+		}))
+
+		assert.PanicsWithValue(t, http.ErrAbortHandler, func() {
+			handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/catalog", nil))
+		})
+	})
+}
+
+func TestMaxBodySize(t *testing.T) {
+	var readErr error
+	handler := MaxBodySize(8)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, readErr = io.ReadAll(r.Body)
+	}))
+
+	t.Run("rejects a declared oversized body", func(t *testing.T) {
+		recorder := httptest.NewRecorder()
+
+		handler.ServeHTTP(recorder, httptest.NewRequest("POST", "/categories", strings.NewReader(`{"code":"toolong"}`)))
+
+		assert.Equal(t, http.StatusRequestEntityTooLarge, recorder.Code)
+	})
+
+	t.Run("stops reading a streamed body at the limit", func(t *testing.T) {
+		request := httptest.NewRequest("POST", "/categories", io.NopCloser(strings.NewReader(`{"code":"toolong"}`)))
+		request.ContentLength = -1
+
+		handler.ServeHTTP(httptest.NewRecorder(), request)
+
+		var maxBytesErr *http.MaxBytesError
+		assert.ErrorAs(t, readErr, &maxBytesErr)
+	})
+
+	t.Run("accepts a body within the limit", func(t *testing.T) {
+		recorder := httptest.NewRecorder()
+
+		handler.ServeHTTP(recorder, httptest.NewRequest("POST", "/categories", strings.NewReader(`{}`)))
+
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		assert.NoError(t, readErr)
+	})
+}
+
+func TestTimeout(t *testing.T) {
+	t.Run("bounds the request context", func(t *testing.T) {
+		var deadline time.Time
+		handler := Timeout(time.Second)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			deadline, _ = r.Context().Deadline()
+		}))
+
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/catalog", nil))
+
+		assert.WithinDuration(t, time.Now().Add(time.Second), deadline, 100*time.Millisecond)
+	})
+
+	t.Run("leaves requests unbounded without a duration", func(t *testing.T) {
+		var hasDeadline bool
+		handler := Timeout(0)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, hasDeadline = r.Context().Deadline()
+		}))
+
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/catalog", nil))
+
+		assert.False(t, hasDeadline)
+	})
+}
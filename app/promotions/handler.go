@@ -0,0 +1,197 @@
+package promotions
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/mytheresa/go-hiring-challenge/app/api"
+	"github.com/mytheresa/go-hiring-challenge/app/auth"
+	"github.com/mytheresa/go-hiring-challenge/models"
+	"github.com/shopspring/decimal"
+)
+
+// PromotionsRepository defines the interface for accessing promotion data
+type PromotionsRepository interface {
+	GetAll(ctx context.Context) ([]models.Promotion, error)
+	Create(ctx context.Context, promotion *models.Promotion) error
+	FindByID(ctx context.Context, id uint) (*models.Promotion, error)
+	Delete(ctx context.Context, id uint, version uint) error
+}
+
+type PromotionsHandler struct {
+	repo PromotionsRepository
+}
+
+func NewPromotionsHandler(r PromotionsRepository) *PromotionsHandler {
+	return &PromotionsHandler{
+		repo: r,
+	}
+}
+
+type PromotionResponse struct {
+	ID       uint      `json:"id"`
+	Name     string    `json:"name"`
+	Type     string    `json:"type"`
+	Value    float64   `json:"value"`
+	Scope    string    `json:"scope"`
+	Target   string    `json:"target"`
+	StartsAt time.Time `json:"starts_at"`
+	EndsAt   time.Time `json:"ends_at"`
+	Version  uint      `json:"version"`
+}
+
+type PromotionsListResponse struct {
+	Promotions []PromotionResponse `json:"promotions"`
+}
+
+type CreatePromotionRequest struct {
+	Name     string          `json:"name"`
+	Type     string          `json:"type"`
+	Value    decimal.Decimal `json:"value"`
+	Scope    string          `json:"scope"`
+	Target   string          `json:"target"`
+	StartsAt time.Time       `json:"starts_at"`
+	EndsAt   time.Time       `json:"ends_at"`
+}
+
+// HandleList returns all promotions.
+func (h *PromotionsHandler) HandleList(w http.ResponseWriter, r *http.Request) {
+	if !auth.Authorize(w, r, auth.PermissionViewCatalog) {
+		return
+	}
+
+	promotions, err := h.repo.GetAll(r.Context())
+	if err != nil {
+		api.InternalError(w, r, err)
+		return
+	}
+
+	promotionResponses := make([]PromotionResponse, len(promotions))
+	for i, p := range promotions {
+		promotionResponses[i] = mapPromotionToResponse(p)
+	}
+
+	api.OKResponse(w, PromotionsListResponse{Promotions: promotionResponses})
+}
+
+// HandleGet returns a single promotion by its ID.
+func (h *PromotionsHandler) HandleGet(w http.ResponseWriter, r *http.Request) {
+	if !auth.Authorize(w, r, auth.PermissionViewCatalog) {
+		return
+	}
+
+	id, err := parseID(r)
+	if err != nil {
+		api.ErrorResponse(w, http.StatusBadRequest, "Invalid promotion id")
+		return
+	}
+
+	promotion, err := h.repo.FindByID(r.Context(), id)
+	if err != nil {
+		api.ErrorResponse(w, http.StatusNotFound, "Promotion not found")
+		return
+	}
+
+	api.VersionedResponse(w, promotion.Version, mapPromotionToResponse(*promotion))
+}
+
+// HandleCreate creates a new promotion.
+func (h *PromotionsHandler) HandleCreate(w http.ResponseWriter, r *http.Request) {
+	if !auth.Authorize(w, r, auth.PermissionManagePromotions) {
+		return
+	}
+
+	var req CreatePromotionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		api.ErrorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if req.Name == "" {
+		api.ErrorResponse(w, http.StatusBadRequest, "Name is required")
+		return
+	}
+
+	promotion := &models.Promotion{
+		Name:     req.Name,
+		Type:     models.PromotionType(req.Type),
+		Value:    req.Value,
+		Scope:    models.PromotionScope(req.Scope),
+		Target:   req.Target,
+		StartsAt: req.StartsAt.UTC(),
+		EndsAt:   req.EndsAt.UTC(),
+	}
+
+	if err := Validate(*promotion); err != nil {
+		api.ErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if err := h.repo.Create(r.Context(), promotion); err != nil {
+		api.InternalError(w, r, err)
+		return
+	}
+
+	api.VersionedResponse(w, promotion.Version, mapPromotionToResponse(*promotion))
+}
+
+// HandleDelete removes a promotion by its ID.
+// The caller must send the promotion version it last read.
+func (h *PromotionsHandler) HandleDelete(w http.ResponseWriter, r *http.Request) {
+	if !auth.Authorize(w, r, auth.PermissionManagePromotions) {
+		return
+	}
+
+	id, err := parseID(r)
+	if err != nil {
+		api.ErrorResponse(w, http.StatusBadRequest, "Invalid promotion id")
+		return
+	}
+
+	p, ok := api.RequireVersion(w, r, nil)
+	if !ok {
+		return
+	}
+
+	// Read from the primary: a lagging replica could miss a just-created promotion.
+	ctx := models.WithPrimary(r.Context())
+	promotion, err := h.repo.FindByID(ctx, id)
+	if err != nil {
+		api.ErrorResponse(w, http.StatusNotFound, "Promotion not found")
+		return
+	}
+
+	if err := h.repo.Delete(ctx, promotion.ID, p.Version); err != nil {
+		if errors.Is(err, models.ErrVersionConflict) {
+			api.VersionConflictResponse(w, p)
+			return
+		}
+		api.InternalError(w, r, err)
+		return
+	}
+
+	api.VersionedResponse(w, promotion.Version, mapPromotionToResponse(*promotion))
+}
+
+func mapPromotionToResponse(p models.Promotion) PromotionResponse {
+	return PromotionResponse{
+		ID:       p.ID,
+		Name:     p.Name,
+		Type:     string(p.Type),
+		Value:    p.Value.InexactFloat64(),
+		Scope:    string(p.Scope),
+		Target:   p.Target,
+		StartsAt: p.StartsAt,
+		EndsAt:   p.EndsAt,
+		Version:  p.Version,
+	}
+}
+
+func parseID(r *http.Request) (uint, error) {
+	val, err := strconv.ParseUint(r.PathValue("id"), 10, 32)
+	return uint(val), err
+}
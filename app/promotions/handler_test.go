@@ -0,0 +1,278 @@
+package promotions
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/mytheresa/go-hiring-challenge/app/auth"
+	"github.com/mytheresa/go-hiring-challenge/models"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockPromotionsRepository is a mock implementation of PromotionsRepository
+type MockPromotionsRepository struct {
+	mock.Mock
+}
+
+func (m *MockPromotionsRepository) GetAll(ctx context.Context) ([]models.Promotion, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.Promotion), args.Error(1)
+}
+
+func (m *MockPromotionsRepository) Create(ctx context.Context, promotion *models.Promotion) error {
+	args := m.Called(ctx, promotion)
+	return args.Error(0)
+}
+
+func (m *MockPromotionsRepository) FindByID(ctx context.Context, id uint) (*models.Promotion, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Promotion), args.Error(1)
+}
+
+func (m *MockPromotionsRepository) Delete(ctx context.Context, id uint, version uint) error {
+	args := m.Called(ctx, id, version)
+	return args.Error(0)
+}
+
+func TestPromotionsHandleList(t *testing.T) {
+	t.Run("returns all promotions", func(t *testing.T) {
+		mockRepo := new(MockPromotionsRepository)
+		mockRepo.On("GetAll", mock.Anything, mock.Anything).Return([]models.Promotion{
+			{
+				ID:       1,
+				Name:     "Summer sale",
+				Type:     models.PromotionPercentage,
+				Value:    decimal.NewFromInt(20),
+				Scope:    models.PromotionScopeCategory,
+				Target:   "clothing",
+				StartsAt: time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC),
+				EndsAt:   time.Date(2025, 7, 1, 0, 0, 0, 0, time.UTC),
+			},
+		}, nil)
+
+		handler := NewPromotionsHandler(mockRepo)
+		recorder := httptest.NewRecorder()
+		request := withRole(httptest.NewRequest("GET", "/admin/promotions", nil), auth.RoleViewer)
+
+		handler.HandleList(recorder, request)
+
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		assert.Contains(t, recorder.Body.String(), "Summer sale")
+		assert.Contains(t, recorder.Body.String(), `"starts_at":"2025-06-01T00:00:00Z"`)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("returns 403 without a role", func(t *testing.T) {
+		mockRepo := new(MockPromotionsRepository)
+
+		handler := NewPromotionsHandler(mockRepo)
+		recorder := httptest.NewRecorder()
+		request := withoutRole(httptest.NewRequest("GET", "/admin/promotions", nil))
+
+		handler.HandleList(recorder, request)
+
+		assert.Equal(t, http.StatusForbidden, recorder.Code)
+		mockRepo.AssertNotCalled(t, "GetAll", mock.Anything)
+	})
+}
+
+func TestPromotionsHandleGet(t *testing.T) {
+	t.Run("returns the promotion", func(t *testing.T) {
+		mockRepo := new(MockPromotionsRepository)
+		mockRepo.On("FindByID", mock.Anything, uint(1)).Return(&models.Promotion{ID: 1, Name: "Summer sale", Version: 2}, nil)
+
+		handler := NewPromotionsHandler(mockRepo)
+		recorder := httptest.NewRecorder()
+		request := withRole(httptest.NewRequest("GET", "/admin/promotions/1", nil), auth.RoleViewer)
+		request.SetPathValue("id", "1")
+
+		handler.HandleGet(recorder, request)
+
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		assert.Contains(t, recorder.Body.String(), "Summer sale")
+	})
+
+	t.Run("returns 403 without a role", func(t *testing.T) {
+		mockRepo := new(MockPromotionsRepository)
+
+		handler := NewPromotionsHandler(mockRepo)
+		recorder := httptest.NewRecorder()
+		request := withoutRole(httptest.NewRequest("GET", "/admin/promotions/1", nil))
+		request.SetPathValue("id", "1")
+
+		handler.HandleGet(recorder, request)
+
+		assert.Equal(t, http.StatusForbidden, recorder.Code)
+		mockRepo.AssertNotCalled(t, "FindByID", mock.Anything, mock.Anything)
+	})
+}
+
+// asEditor authenticates request as a client allowed to manage promotions.
+func asEditor(request *http.Request) *http.Request {
+	return withRole(request, auth.RoleEditor)
+}
+
+// withRole authenticates request as a client with role and every scope.
+func withRole(request *http.Request, role string) *http.Request {
+	principal := &auth.Principal{Subject: "apikey:mhc_test", Roles: []string{role}, Scopes: auth.Scopes}
+	return request.WithContext(auth.WithPrincipal(request.Context(), principal))
+}
+
+// withoutRole authenticates request as a client holding the catalog:read
+// scope but no role.
+func withoutRole(request *http.Request) *http.Request {
+	principal := &auth.Principal{Subject: "apikey:mhc_test", Scopes: []string{auth.ScopeCatalogRead}}
+	return request.WithContext(auth.WithPrincipal(request.Context(), principal))
+}
+
+func TestPromotionsHandleCreate(t *testing.T) {
+	t.Run("creates a new promotion", func(t *testing.T) {
+		mockRepo := new(MockPromotionsRepository)
+		mockRepo.On("Create", mock.Anything, mock.MatchedBy(func(p *models.Promotion) bool {
+			return p.Name == "Shoes week" && p.Type == models.PromotionFixed && p.Value.Equal(decimal.NewFromInt(5)) && p.Target == "shoes"
+		})).Return(nil)
+
+		handler := NewPromotionsHandler(mockRepo)
+		recorder := httptest.NewRecorder()
+		body := `{"name":"Shoes week","type":"fixed","value":5,"scope":"category","target":"shoes","starts_at":"2025-06-01T00:00:00Z","ends_at":"2025-06-08T00:00:00Z"}`
+		request := httptest.NewRequest("POST", "/admin/promotions", bytes.NewBufferString(body))
+
+		handler.HandleCreate(recorder, asEditor(request))
+
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		assert.Contains(t, recorder.Body.String(), "Shoes week")
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("returns 400 when the rule is invalid", func(t *testing.T) {
+		mockRepo := new(MockPromotionsRepository)
+
+		handler := NewPromotionsHandler(mockRepo)
+		recorder := httptest.NewRecorder()
+		body := `{"name":"Broken","type":"percentage","value":150,"scope":"category","target":"shoes","starts_at":"2025-06-01T00:00:00Z","ends_at":"2025-06-08T00:00:00Z"}`
+		request := httptest.NewRequest("POST", "/admin/promotions", bytes.NewBufferString(body))
+
+		handler.HandleCreate(recorder, asEditor(request))
+
+		assert.Equal(t, http.StatusBadRequest, recorder.Code)
+		assert.Contains(t, recorder.Body.String(), "percentage value must not exceed 100")
+		mockRepo.AssertNotCalled(t, "Create", mock.Anything)
+	})
+
+	t.Run("returns 403 for viewers", func(t *testing.T) {
+		mockRepo := new(MockPromotionsRepository)
+
+		handler := NewPromotionsHandler(mockRepo)
+		recorder := httptest.NewRecorder()
+		body := `{"name":"Everything free","type":"percentage","value":100,"scope":"category","target":"shoes","starts_at":"2025-06-01T00:00:00Z","ends_at":"2025-06-08T00:00:00Z"}`
+		request := httptest.NewRequest("POST", "/admin/promotions", bytes.NewBufferString(body))
+
+		handler.HandleCreate(recorder, withRole(request, auth.RoleViewer))
+
+		assert.Equal(t, http.StatusForbidden, recorder.Code)
+		assert.Contains(t, recorder.Body.String(), "promotions.manage")
+		mockRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	})
+
+	t.Run("returns 400 when request body is invalid", func(t *testing.T) {
+		mockRepo := new(MockPromotionsRepository)
+
+		handler := NewPromotionsHandler(mockRepo)
+		recorder := httptest.NewRecorder()
+		request := httptest.NewRequest("POST", "/admin/promotions", bytes.NewBufferString("invalid json"))
+
+		handler.HandleCreate(recorder, asEditor(request))
+
+		assert.Equal(t, http.StatusBadRequest, recorder.Code)
+		assert.Contains(t, recorder.Body.String(), "Invalid request body")
+	})
+}
+
+func TestPromotionsHandleDelete(t *testing.T) {
+	t.Run("deletes an existing promotion", func(t *testing.T) {
+		mockRepo := new(MockPromotionsRepository)
+		mockRepo.On("FindByID", mock.Anything, uint(3)).Return(&models.Promotion{ID: 3, Name: "Old", Version: 2}, nil)
+		mockRepo.On("Delete", mock.Anything, uint(3), uint(2)).Return(nil)
+
+		handler := NewPromotionsHandler(mockRepo)
+		recorder := httptest.NewRecorder()
+		request := httptest.NewRequest("DELETE", "/admin/promotions/3", nil)
+		request.SetPathValue("id", "3")
+		request.Header.Set("If-Match", `"2"`)
+
+		handler.HandleDelete(recorder, asEditor(request))
+
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("returns 403 for viewers", func(t *testing.T) {
+		mockRepo := new(MockPromotionsRepository)
+
+		handler := NewPromotionsHandler(mockRepo)
+		recorder := httptest.NewRecorder()
+		request := httptest.NewRequest("DELETE", "/admin/promotions/3", nil)
+		request.SetPathValue("id", "3")
+		request.Header.Set("If-Match", `"2"`)
+
+		handler.HandleDelete(recorder, withRole(request, auth.RoleViewer))
+
+		assert.Equal(t, http.StatusForbidden, recorder.Code)
+		mockRepo.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("returns 404 when promotion not found", func(t *testing.T) {
+		mockRepo := new(MockPromotionsRepository)
+		mockRepo.On("FindByID", mock.Anything, uint(9)).Return(nil, assert.AnError)
+
+		handler := NewPromotionsHandler(mockRepo)
+		recorder := httptest.NewRecorder()
+		request := httptest.NewRequest("DELETE", "/admin/promotions/9", nil)
+		request.SetPathValue("id", "9")
+		request.Header.Set("If-Match", `"1"`)
+
+		handler.HandleDelete(recorder, asEditor(request))
+
+		assert.Equal(t, http.StatusNotFound, recorder.Code)
+		mockRepo.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything)
+	})
+
+	t.Run("returns 412 when the promotion changed since it was read", func(t *testing.T) {
+		mockRepo := new(MockPromotionsRepository)
+		mockRepo.On("FindByID", mock.Anything, uint(3)).Return(&models.Promotion{ID: 3, Name: "Old", Version: 2}, nil)
+		mockRepo.On("Delete", mock.Anything, uint(3), uint(1)).Return(models.ErrVersionConflict)
+
+		handler := NewPromotionsHandler(mockRepo)
+		recorder := httptest.NewRecorder()
+		request := httptest.NewRequest("DELETE", "/admin/promotions/3", nil)
+		request.SetPathValue("id", "3")
+		request.Header.Set("If-Match", `"1"`)
+
+		handler.HandleDelete(recorder, asEditor(request))
+
+		assert.Equal(t, http.StatusPreconditionFailed, recorder.Code)
+	})
+
+	t.Run("returns 400 for a malformed id", func(t *testing.T) {
+		handler := NewPromotionsHandler(new(MockPromotionsRepository))
+		recorder := httptest.NewRecorder()
+		request := httptest.NewRequest("DELETE", "/admin/promotions/abc", nil)
+		request.SetPathValue("id", "abc")
+
+		handler.HandleDelete(recorder, asEditor(request))
+
+		assert.Equal(t, http.StatusBadRequest, recorder.Code)
+	})
+}
@@ -0,0 +1,147 @@
+package promotions
+
+import (
+	"errors"
+	"time"
+
+	"github.com/mytheresa/go-hiring-challenge/models"
+	"github.com/shopspring/decimal"
+)
+
+var hundred = decimal.NewFromInt(100)
+
+// Target identifies the catalog entry being priced.
+// SKU is empty when pricing a product rather than one of its variants.
+type Target struct {
+	CategoryCode string
+	ProductCode  string
+	SKU          string
+}
+
+// Price is the outcome of evaluating promotions against a base price.
+type Price struct {
+	Original decimal.Decimal
+	Final    decimal.Decimal
+	Discount decimal.Decimal
+}
+
+// Evaluate applies the best matching promotion running at the given instant to the base price.
+//
+// Evaluation is deterministic: the most specific scope wins (sku, then product, then category),
+// then the largest discount, then the lowest promotion ID. Discounts never exceed the base price.
+func Evaluate(base decimal.Decimal, target Target, promos []models.Promotion, at time.Time) Price {
+	price := Price{
+		Original: base,
+		Final:    base,
+		Discount: decimal.Zero,
+	}
+
+	var best *models.Promotion
+	var bestDiscount decimal.Decimal
+	for i := range promos {
+		p := &promos[i]
+		if !IsActive(*p, at) || !matches(*p, target) {
+			continue
+		}
+
+		discount := discountFor(*p, base)
+		if best == nil || better(*p, discount, *best, bestDiscount) {
+			best = p
+			bestDiscount = discount
+		}
+	}
+
+	if best != nil {
+		price.Discount = bestDiscount
+		price.Final = base.Sub(bestDiscount)
+	}
+
+	return price
+}
+
+// IsActive reports whether the promotion is running at the given instant.
+func IsActive(p models.Promotion, at time.Time) bool {
+	return !at.Before(p.StartsAt) && at.Before(p.EndsAt)
+}
+
+// Validate checks that a promotion is well formed before it is stored.
+func Validate(p models.Promotion) error {
+	switch p.Type {
+	case models.PromotionPercentage:
+		if p.Value.GreaterThan(hundred) {
+			return errors.New("percentage value must not exceed 100")
+		}
+	case models.PromotionFixed:
+	default:
+		return errors.New("type must be one of: percentage, fixed")
+	}
+
+	if !p.Value.IsPositive() {
+		return errors.New("value must be greater than zero")
+	}
+
+	switch p.Scope {
+	case models.PromotionScopeCategory, models.PromotionScopeProduct, models.PromotionScopeSKU:
+	default:
+		return errors.New("scope must be one of: category, product, sku")
+	}
+
+	if p.Target == "" {
+		return errors.New("target is required")
+	}
+
+	if !p.EndsAt.After(p.StartsAt) {
+		return errors.New("ends_at must be after starts_at")
+	}
+
+	return nil
+}
+
+func matches(p models.Promotion, target Target) bool {
+	switch p.Scope {
+	case models.PromotionScopeCategory:
+		return target.CategoryCode != "" && p.Target == target.CategoryCode
+	case models.PromotionScopeProduct:
+		return target.ProductCode != "" && p.Target == target.ProductCode
+	case models.PromotionScopeSKU:
+		return target.SKU != "" && p.Target == target.SKU
+	}
+	return false
+}
+
+func discountFor(p models.Promotion, base decimal.Decimal) decimal.Decimal {
+	var discount decimal.Decimal
+	switch p.Type {
+	case models.PromotionPercentage:
+		discount = base.Mul(p.Value).Div(hundred).Round(2)
+	case models.PromotionFixed:
+		discount = p.Value
+	}
+
+	if discount.GreaterThan(base) {
+		return base
+	}
+	return discount
+}
+
+func better(p models.Promotion, discount decimal.Decimal, best models.Promotion, bestDiscount decimal.Decimal) bool {
+	if s, bs := specificity(p.Scope), specificity(best.Scope); s != bs {
+		return s > bs
+	}
+	if c := discount.Cmp(bestDiscount); c != 0 {
+		return c > 0
+	}
+	return p.ID < best.ID
+}
+
+func specificity(s models.PromotionScope) int {
+	switch s {
+	case models.PromotionScopeSKU:
+		return 3
+	case models.PromotionScopeProduct:
+		return 2
+	case models.PromotionScopeCategory:
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,154 @@
+package promotions
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mytheresa/go-hiring-challenge/models"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEvaluate(t *testing.T) {
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	target := Target{CategoryCode: "clothing", ProductCode: "PROD001", SKU: "SKU001A"}
+
+	promo := func(id uint, typ models.PromotionType, value int64, scope models.PromotionScope, tgt string) models.Promotion {
+		return models.Promotion{
+			ID:       id,
+			Type:     typ,
+			Value:    decimal.NewFromInt(value),
+			Scope:    scope,
+			Target:   tgt,
+			StartsAt: now.Add(-time.Hour),
+			EndsAt:   now.Add(time.Hour),
+		}
+	}
+
+	t.Run("returns base price without promotions", func(t *testing.T) {
+		price := Evaluate(decimal.NewFromFloat(10.99), target, nil, now)
+
+		assert.True(t, price.Original.Equal(decimal.NewFromFloat(10.99)))
+		assert.True(t, price.Final.Equal(decimal.NewFromFloat(10.99)))
+		assert.True(t, price.Discount.IsZero())
+	})
+
+	t.Run("applies percentage discount rounded to cents", func(t *testing.T) {
+		promos := []models.Promotion{promo(1, models.PromotionPercentage, 15, models.PromotionScopeProduct, "PROD001")}
+
+		price := Evaluate(decimal.NewFromFloat(10.99), target, promos, now)
+
+		assert.Equal(t, "1.65", price.Discount.StringFixed(2))
+		assert.Equal(t, "9.34", price.Final.StringFixed(2))
+	})
+
+	t.Run("caps fixed discount at the base price", func(t *testing.T) {
+		promos := []models.Promotion{promo(1, models.PromotionFixed, 50, models.PromotionScopeCategory, "clothing")}
+
+		price := Evaluate(decimal.NewFromInt(20), target, promos, now)
+
+		assert.True(t, price.Discount.Equal(decimal.NewFromInt(20)))
+		assert.True(t, price.Final.IsZero())
+	})
+
+	t.Run("ignores promotions outside their window", func(t *testing.T) {
+		expired := promo(1, models.PromotionFixed, 5, models.PromotionScopeProduct, "PROD001")
+		expired.StartsAt = now.Add(-2 * time.Hour)
+		expired.EndsAt = now
+
+		upcoming := promo(2, models.PromotionFixed, 5, models.PromotionScopeProduct, "PROD001")
+		upcoming.StartsAt = now.Add(time.Second)
+		upcoming.EndsAt = now.Add(time.Hour)
+
+		price := Evaluate(decimal.NewFromInt(20), target, []models.Promotion{expired, upcoming}, now)
+
+		assert.True(t, price.Discount.IsZero())
+	})
+
+	t.Run("ignores promotions for other targets", func(t *testing.T) {
+		promos := []models.Promotion{
+			promo(1, models.PromotionFixed, 5, models.PromotionScopeCategory, "shoes"),
+			promo(2, models.PromotionFixed, 5, models.PromotionScopeSKU, "SKU001B"),
+		}
+
+		price := Evaluate(decimal.NewFromInt(20), target, promos, now)
+
+		assert.True(t, price.Discount.IsZero())
+	})
+
+	t.Run("does not apply sku promotions to products", func(t *testing.T) {
+		promos := []models.Promotion{promo(1, models.PromotionFixed, 5, models.PromotionScopeSKU, "SKU001A")}
+
+		price := Evaluate(decimal.NewFromInt(20), Target{ProductCode: "PROD001"}, promos, now)
+
+		assert.True(t, price.Discount.IsZero())
+	})
+
+	t.Run("prefers the most specific scope over a larger discount", func(t *testing.T) {
+		promos := []models.Promotion{
+			promo(1, models.PromotionPercentage, 50, models.PromotionScopeCategory, "clothing"),
+			promo(2, models.PromotionFixed, 1, models.PromotionScopeSKU, "SKU001A"),
+			promo(3, models.PromotionPercentage, 30, models.PromotionScopeProduct, "PROD001"),
+		}
+
+		price := Evaluate(decimal.NewFromInt(20), target, promos, now)
+
+		assert.True(t, price.Discount.Equal(decimal.NewFromInt(1)))
+	})
+
+	t.Run("picks the largest discount within the same scope", func(t *testing.T) {
+		promos := []models.Promotion{
+			promo(1, models.PromotionFixed, 3, models.PromotionScopeProduct, "PROD001"),
+			promo(2, models.PromotionPercentage, 20, models.PromotionScopeProduct, "PROD001"),
+		}
+
+		price := Evaluate(decimal.NewFromInt(20), target, promos, now)
+
+		assert.True(t, price.Discount.Equal(decimal.NewFromInt(4)))
+	})
+
+	t.Run("is independent of promotion order", func(t *testing.T) {
+		a := promo(7, models.PromotionFixed, 2, models.PromotionScopeProduct, "PROD001")
+		b := promo(3, models.PromotionPercentage, 10, models.PromotionScopeProduct, "PROD001")
+
+		first := Evaluate(decimal.NewFromInt(20), target, []models.Promotion{a, b}, now)
+		second := Evaluate(decimal.NewFromInt(20), target, []models.Promotion{b, a}, now)
+
+		assert.Equal(t, first, second)
+		assert.True(t, first.Discount.Equal(decimal.NewFromInt(2)))
+	})
+}
+
+func TestValidate(t *testing.T) {
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	valid := models.Promotion{
+		Name:     "Summer sale",
+		Type:     models.PromotionPercentage,
+		Value:    decimal.NewFromInt(10),
+		Scope:    models.PromotionScopeCategory,
+		Target:   "clothing",
+		StartsAt: now,
+		EndsAt:   now.Add(24 * time.Hour),
+	}
+
+	t.Run("accepts a well formed promotion", func(t *testing.T) {
+		assert.NoError(t, Validate(valid))
+	})
+
+	tests := map[string]func(p *models.Promotion){
+		"unknown type":             func(p *models.Promotion) { p.Type = "bogo" },
+		"non positive value":       func(p *models.Promotion) { p.Value = decimal.Zero },
+		"percentage above 100":     func(p *models.Promotion) { p.Value = decimal.NewFromInt(101) },
+		"unknown scope":            func(p *models.Promotion) { p.Scope = "brand" },
+		"missing target":           func(p *models.Promotion) { p.Target = "" },
+		"window ends before start": func(p *models.Promotion) { p.EndsAt = p.StartsAt },
+	}
+
+	for name, mutate := range tests {
+		t.Run("rejects "+name, func(t *testing.T) {
+			p := valid
+			mutate(&p)
+			assert.Error(t, Validate(p))
+		})
+	}
+}
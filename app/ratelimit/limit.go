@@ -0,0 +1,160 @@
+package ratelimit
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"net/netip"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Limit is a token bucket holding up to Requests tokens, refilled at a rate
+// of Requests per Period. A client can send a burst of Requests requests and
+// then one every Period/Requests. The zero Limit disables limiting.
+//
+// Limit implements flag.Value in the form "100/1m", with an optional count
+// before the unit ("20/s" equals "20/1s"), or "0" for no limit.
+type Limit struct {
+	Requests int
+	Period   time.Duration
+}
+
+// Enabled reports whether the limit applies.
+func (l Limit) Enabled() bool {
+	return l.Requests > 0
+}
+
+// interval returns the time it takes to refill one token.
+func (l Limit) interval() time.Duration {
+	return l.Period / time.Duration(l.Requests)
+}
+
+func (l *Limit) Set(s string) error {
+	s = strings.TrimSpace(s)
+	if s == "0" || s == "off" {
+		*l = Limit{}
+		return nil
+	}
+
+	count, period, ok := strings.Cut(s, "/")
+	if !ok {
+		return errors.New(`limit must look like "100/1m"`)
+	}
+	requests, err := strconv.Atoi(count)
+	if err != nil || requests <= 0 {
+		return fmt.Errorf("invalid request count %q", count)
+	}
+	if period != "" && (period[0] < '0' || period[0] > '9') {
+		period = "1" + period
+	}
+	d, err := time.ParseDuration(period)
+	if err != nil || d <= 0 {
+		return fmt.Errorf("invalid period %q", period)
+	}
+	if d < time.Duration(requests) {
+		return errors.New("period too short for the request count")
+	}
+	*l = Limit{Requests: requests, Period: d}
+	return nil
+}
+
+func (l *Limit) String() string {
+	if l == nil || !l.Enabled() {
+		return "0"
+	}
+	return strconv.Itoa(l.Requests) + "/" + l.Period.String()
+}
+
+// RouteLimits overrides the limit of single routes, by their ServeMux pattern.
+//
+// RouteLimits implements flag.Value as a comma-separated list of
+// pattern=limit pairs, e.g. "GET /catalog=60/1m,POST /categories=10/1m".
+type RouteLimits map[string]Limit
+
+func (r *RouteLimits) Set(s string) error {
+	limits := RouteLimits{}
+	for _, item := range strings.Split(s, ",") {
+		if strings.TrimSpace(item) == "" {
+			continue
+		}
+		pattern, value, ok := strings.Cut(item, "=")
+		if !ok {
+			return fmt.Errorf("route limit %q must look like \"GET /catalog=60/1m\"", item)
+		}
+		var l Limit
+		if err := l.Set(value); err != nil {
+			return fmt.Errorf("route %s: %w", strings.TrimSpace(pattern), err)
+		}
+		limits[strings.TrimSpace(pattern)] = l
+	}
+	*r = limits
+	return nil
+}
+
+func (r *RouteLimits) String() string {
+	if r == nil {
+		return ""
+	}
+	items := make([]string, 0, len(*r))
+	for _, pattern := range slices.Sorted(maps.Keys(*r)) {
+		l := (*r)[pattern]
+		items = append(items, pattern+"="+l.String())
+	}
+	return strings.Join(items, ",")
+}
+
+// Prefixes is a set of IP address ranges.
+//
+// Prefixes implements flag.Value as a comma-separated list of CIDR prefixes
+// or single addresses, e.g. "10.0.0.0/8,192.168.1.10".
+type Prefixes []netip.Prefix
+
+func (p *Prefixes) Set(s string) error {
+	var prefixes Prefixes
+	for _, item := range strings.Split(s, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		if !strings.Contains(item, "/") {
+			addr, err := netip.ParseAddr(item)
+			if err != nil {
+				return err
+			}
+			prefixes = append(prefixes, netip.PrefixFrom(addr, addr.BitLen()))
+			continue
+		}
+		prefix, err := netip.ParsePrefix(item)
+		if err != nil {
+			return err
+		}
+		prefixes = append(prefixes, prefix.Masked())
+	}
+	*p = prefixes
+	return nil
+}
+
+func (p *Prefixes) String() string {
+	if p == nil {
+		return ""
+	}
+	items := make([]string, len(*p))
+	for i, prefix := range *p {
+		items[i] = prefix.String()
+	}
+	return strings.Join(items, ",")
+}
+
+// Contains reports whether addr is in one of the ranges.
+func (p Prefixes) Contains(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	for _, prefix := range p {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
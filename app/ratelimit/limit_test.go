@@ -0,0 +1,63 @@
+package ratelimit
+
+import (
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLimitSet(t *testing.T) {
+	valid := map[string]Limit{
+		"100/1m":  {Requests: 100, Period: time.Minute},
+		"20/s":    {Requests: 20, Period: time.Second},
+		" 5/30s ": {Requests: 5, Period: 30 * time.Second},
+		"0":       {},
+		"off":     {},
+	}
+	for value, expected := range valid {
+		var l Limit
+		require.NoError(t, l.Set(value), value)
+		assert.Equal(t, expected, l, value)
+	}
+
+	for _, value := range []string{"100", "-1/1m", "ten/1m", "10/0s", "10/forever", "10/-1m"} {
+		var l Limit
+		assert.Error(t, l.Set(value), value)
+	}
+
+	l := Limit{Requests: 100, Period: time.Minute}
+	assert.Equal(t, "100/1m0s", l.String())
+	assert.Equal(t, "0", (&Limit{}).String())
+}
+
+func TestRouteLimitsSet(t *testing.T) {
+	var routes RouteLimits
+	require.NoError(t, routes.Set("GET /catalog=60/1m, POST /categories=10/1m,GET /catalog/{code}=0"))
+
+	assert.Equal(t, RouteLimits{
+		"GET /catalog":        {Requests: 60, Period: time.Minute},
+		"POST /categories":    {Requests: 10, Period: time.Minute},
+		"GET /catalog/{code}": {},
+	}, routes)
+	assert.Equal(t, "GET /catalog=60/1m0s,GET /catalog/{code}=0,POST /categories=10/1m0s", routes.String())
+
+	assert.Error(t, routes.Set("GET /catalog"))
+	assert.ErrorContains(t, routes.Set("GET /catalog=fast"), "GET /catalog")
+}
+
+func TestPrefixes(t *testing.T) {
+	var proxies Prefixes
+	require.NoError(t, proxies.Set("10.0.0.0/8, 192.168.1.10,fd00::/8"))
+
+	assert.Equal(t, "10.0.0.0/8,192.168.1.10/32,fd00::/8", proxies.String())
+	assert.True(t, proxies.Contains(netip.MustParseAddr("10.1.2.3")))
+	assert.True(t, proxies.Contains(netip.MustParseAddr("::ffff:192.168.1.10")))
+	assert.True(t, proxies.Contains(netip.MustParseAddr("fd12::1")))
+	assert.False(t, proxies.Contains(netip.MustParseAddr("192.168.1.11")))
+
+	assert.Error(t, proxies.Set("10.0.0.0/33"))
+	assert.Error(t, proxies.Set("proxy.internal"))
+}
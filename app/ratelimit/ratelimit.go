@@ -0,0 +1,199 @@
+// Package ratelimit limits how fast each client may call each route, with a
+// token bucket per client and route.
+package ratelimit
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"net/netip"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mytheresa/go-hiring-challenge/app/api"
+	"github.com/mytheresa/go-hiring-challenge/app/auth"
+	"github.com/mytheresa/go-hiring-challenge/app/logging"
+	"github.com/mytheresa/go-hiring-challenge/app/middleware"
+)
+
+// Stores accepted in Options.Store.
+const (
+	StoreMemory = "memory"
+	StoreRedis  = "redis"
+)
+
+// Options configures rate limiting.
+type Options struct {
+	// Store is memory, limiting each instance on its own, or redis, sharing
+	// the limits between instances through the cache server.
+	Store string
+	// Reads and Writes are the limits of the read and write routes.
+	Reads  Limit
+	Writes Limit
+	// Routes overrides the limit of single routes.
+	Routes RouteLimits
+	// Addresses is the limit of every address across all routes. It is
+	// checked before the credentials are looked up, so a client cycling
+	// through made-up API keys cannot flood the key store.
+	Addresses Limit
+	// MaxBuckets bounds the buckets kept by the memory store; the least
+	// recently used bucket is dropped to make room for a new one.
+	MaxBuckets int
+	// TrustedProxies are the proxies whose X-Forwarded-For header is believed
+	// when identifying the client address.
+	TrustedProxies Prefixes
+}
+
+// Validate reports an unknown store.
+func (o Options) Validate() error {
+	switch o.Store {
+	case StoreMemory:
+		if o.MaxBuckets <= 0 {
+			return errors.New("rate limit bucket count must be positive")
+		}
+		return nil
+	case StoreRedis:
+		return nil
+	default:
+		return fmt.Errorf("unknown rate limit store %q", o.Store)
+	}
+}
+
+// Limiter rejects the requests of clients exceeding the limit of a route.
+type Limiter struct {
+	store   Store
+	routes  RouteLimits
+	proxies Prefixes
+}
+
+// NewLimiter returns a Limiter keeping its buckets in store.
+func NewLimiter(store Store, opts Options) *Limiter {
+	return &Limiter{
+		store:   store,
+		routes:  opts.Routes,
+		proxies: opts.TrustedProxies,
+	}
+}
+
+// Limit returns middleware limiting each client to limit on each route, unless
+// the route has its own limit. Authenticated clients are told apart by their
+// credentials and others by their address; every client gets a bucket per
+// route pattern. Responses carry the RateLimit-Limit, RateLimit-Remaining,
+// RateLimit-Reset and RateLimit-Policy headers, and rejected requests get 429
+// with Retry-After. A failing store lets requests through.
+func (l *Limiter) Limit(limit Limit) middleware.Middleware {
+	return l.limit(func(r *http.Request) (Limit, string) {
+		limit := limit
+		if override, ok := l.routes[r.Pattern]; ok {
+			limit = override
+		}
+		return limit, r.Pattern + " " + l.client(r)
+	})
+}
+
+// LimitAddress returns middleware limiting each client address to limit
+// across all routes, whatever credentials its requests carry. It runs before
+// the client is identified, so requests rejected by it cost no credential
+// lookup. Otherwise it behaves as Limit, whose headers replace its own on
+// requests it lets through.
+func (l *Limiter) LimitAddress(limit Limit) middleware.Middleware {
+	return l.limit(func(r *http.Request) (Limit, string) {
+		return limit, "* ip:" + l.clientIP(r)
+	})
+}
+
+// limit returns middleware taking a token from the bucket that bucket
+// chooses for a request.
+func (l *Limiter) limit(bucket func(r *http.Request) (Limit, string)) middleware.Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			limit, key := bucket(r)
+			if !limit.Enabled() {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			ctx := r.Context()
+			res, err := l.store.Take(ctx, key, limit)
+			if err != nil {
+				logging.FromContext(ctx).WarnContext(ctx, "rate limit store failed, allowing the request", "error", err)
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			h := w.Header()
+			h.Set("RateLimit-Limit", strconv.Itoa(limit.Requests))
+			h.Set("RateLimit-Remaining", strconv.Itoa(res.Remaining))
+			h.Set("RateLimit-Reset", seconds(res.Reset))
+			h.Set("RateLimit-Policy", strconv.Itoa(limit.Requests)+";w="+seconds(limit.Period))
+			if !res.Allowed {
+				h.Set("Retry-After", seconds(res.RetryAfter))
+				api.ProblemResponse(w, http.StatusTooManyRequests, api.NewProblem(http.StatusTooManyRequests,
+					"Rate limit of "+limit.String()+" exceeded, retry in "+seconds(res.RetryAfter)+"s."))
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// client identifies the client of r: its principal when it authenticated,
+// otherwise its address.
+func (l *Limiter) client(r *http.Request) string {
+	if p, ok := auth.FromContext(r.Context()); ok {
+		return p.Subject
+	}
+	return "ip:" + l.clientIP(r)
+}
+
+// clientIP returns the address of the client. Behind trusted proxies it is
+// the last address of X-Forwarded-For that is not a trusted proxy, since
+// earlier entries are set by the client and cannot be believed. IPv6 clients
+// are identified by their /64 network, as a single host is usually given a
+// whole /64 and could otherwise use a new address for every request.
+func (l *Limiter) clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return host
+	}
+	addr = addr.Unmap()
+	if !l.proxies.Contains(addr) {
+		return network(addr)
+	}
+
+	var hops []string
+	for _, header := range r.Header.Values("X-Forwarded-For") {
+		hops = append(hops, strings.Split(header, ",")...)
+	}
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop, err := netip.ParseAddr(strings.TrimSpace(hops[i]))
+		if err != nil {
+			break
+		}
+		addr = hop.Unmap()
+		if !l.proxies.Contains(addr) {
+			break
+		}
+	}
+	return network(addr)
+}
+
+// network returns addr, or its /64 network for an IPv6 address.
+func network(addr netip.Addr) string {
+	if addr.Is4() {
+		return addr.String()
+	}
+	return netip.PrefixFrom(addr.WithZone(""), 64).Masked().String()
+}
+
+// seconds formats d as a whole number of seconds, rounded up.
+func seconds(d time.Duration) string {
+	return strconv.FormatInt(int64(math.Ceil(d.Seconds())), 10)
+}
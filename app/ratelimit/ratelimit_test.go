@@ -0,0 +1,183 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/mytheresa/go-hiring-challenge/app/auth"
+)
+
+// recordingStore answers every Take with res and remembers the keys.
+type recordingStore struct {
+	res  Result
+	err  error
+	keys []string
+}
+
+func (s *recordingStore) Take(_ context.Context, key string, _ Limit) (Result, error) {
+	s.keys = append(s.keys, key)
+	return s.res, s.err
+}
+
+func newMux(l *Limiter, limit Limit) *http.ServeMux {
+	mux := http.NewServeMux()
+	ok := func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusNoContent) }
+	mux.Handle("GET /catalog", l.Limit(limit)(http.HandlerFunc(ok)))
+	mux.Handle("POST /categories", l.Limit(limit)(http.HandlerFunc(ok)))
+	return mux
+}
+
+func TestLimiterLimit(t *testing.T) {
+	limit := Limit{Requests: 60, Period: time.Minute}
+
+	t.Run("reports the bucket state on allowed requests", func(t *testing.T) {
+		store := &recordingStore{res: Result{Allowed: true, Remaining: 41, Reset: 19500 * time.Millisecond}}
+		mux := newMux(NewLimiter(store, Options{}), limit)
+
+		req := httptest.NewRequest(http.MethodGet, "/catalog", nil)
+		req.RemoteAddr = "192.0.2.1:51234"
+		res := httptest.NewRecorder()
+		mux.ServeHTTP(res, req)
+
+		assert.Equal(t, http.StatusNoContent, res.Code)
+		assert.Equal(t, "60", res.Header().Get("RateLimit-Limit"))
+		assert.Equal(t, "41", res.Header().Get("RateLimit-Remaining"))
+		assert.Equal(t, "20", res.Header().Get("RateLimit-Reset"))
+		assert.Equal(t, "60;w=60", res.Header().Get("RateLimit-Policy"))
+		assert.Empty(t, res.Header().Get("Retry-After"))
+		assert.Equal(t, []string{"GET /catalog ip:192.0.2.1"}, store.keys)
+	})
+
+	t.Run("rejects requests over the limit", func(t *testing.T) {
+		store := &recordingStore{res: Result{RetryAfter: 800 * time.Millisecond, Reset: time.Minute}}
+		mux := newMux(NewLimiter(store, Options{}), limit)
+
+		res := httptest.NewRecorder()
+		mux.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/catalog", nil))
+
+		assert.Equal(t, http.StatusTooManyRequests, res.Code)
+		assert.Equal(t, "application/problem+json", res.Header().Get("Content-Type"))
+		assert.Equal(t, "1", res.Header().Get("Retry-After"))
+		assert.Equal(t, "0", res.Header().Get("RateLimit-Remaining"))
+		assert.JSONEq(t, `{
+			"type": "about:blank",
+			"title": "Too Many Requests",
+			"status": 429,
+			"detail": "Rate limit of 60/1m0s exceeded, retry in 1s."
+		}`, res.Body.String())
+	})
+
+	t.Run("keys authenticated clients by their principal", func(t *testing.T) {
+		store := &recordingStore{res: Result{Allowed: true}}
+		mux := newMux(NewLimiter(store, Options{}), limit)
+
+		req := httptest.NewRequest(http.MethodPost, "/categories", nil)
+		req = req.WithContext(auth.WithPrincipal(req.Context(), &auth.Principal{Subject: "apikey:mhc_Ab3dE6gH"}))
+		mux.ServeHTTP(httptest.NewRecorder(), req)
+
+		assert.Equal(t, []string{"POST /categories apikey:mhc_Ab3dE6gH"}, store.keys)
+	})
+
+	t.Run("applies the limit of the route", func(t *testing.T) {
+		store := &recordingStore{res: Result{Allowed: true}}
+		routes := RouteLimits{
+			"GET /catalog":     {Requests: 5, Period: time.Second},
+			"POST /categories": {},
+		}
+		mux := newMux(NewLimiter(store, Options{Routes: routes}), limit)
+
+		res := httptest.NewRecorder()
+		mux.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/catalog", nil))
+		assert.Equal(t, "5;w=1", res.Header().Get("RateLimit-Policy"))
+
+		res = httptest.NewRecorder()
+		mux.ServeHTTP(res, httptest.NewRequest(http.MethodPost, "/categories", nil))
+		assert.Equal(t, http.StatusNoContent, res.Code)
+		assert.Empty(t, res.Header().Get("RateLimit-Policy"))
+		assert.Len(t, store.keys, 1)
+	})
+
+	t.Run("allows requests when the store fails", func(t *testing.T) {
+		store := &recordingStore{err: errors.New("connection refused")}
+		mux := newMux(NewLimiter(store, Options{}), limit)
+
+		res := httptest.NewRecorder()
+		mux.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/catalog", nil))
+
+		assert.Equal(t, http.StatusNoContent, res.Code)
+		assert.Empty(t, res.Header().Get("RateLimit-Limit"))
+	})
+}
+
+func TestLimiterLimitAddress(t *testing.T) {
+	limit := Limit{Requests: 1200, Period: time.Minute}
+
+	t.Run("keys every route by the address", func(t *testing.T) {
+		store := &recordingStore{res: Result{Allowed: true}}
+		limiter := NewLimiter(store, Options{})
+		handler := limiter.LimitAddress(limit)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+		for _, target := range []string{"/catalog", "/categories"} {
+			req := httptest.NewRequest(http.MethodGet, target, nil)
+			req.RemoteAddr = "192.0.2.1:51234"
+			req = req.WithContext(auth.WithPrincipal(req.Context(), &auth.Principal{Subject: "apikey:mhc_Ab3dE6gH"}))
+			handler.ServeHTTP(httptest.NewRecorder(), req)
+		}
+
+		assert.Equal(t, []string{"* ip:192.0.2.1", "* ip:192.0.2.1"}, store.keys)
+	})
+
+	t.Run("rejects requests before they reach the next handler", func(t *testing.T) {
+		store := &recordingStore{res: Result{RetryAfter: time.Second}}
+		called := false
+		handler := NewLimiter(store, Options{}).LimitAddress(limit)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			called = true
+		}))
+
+		res := httptest.NewRecorder()
+		handler.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/catalog", nil))
+
+		assert.Equal(t, http.StatusTooManyRequests, res.Code)
+		assert.False(t, called)
+	})
+}
+
+func TestLimiterClientIP(t *testing.T) {
+	var proxies Prefixes
+	proxies = append(proxies, netip.MustParsePrefix("10.0.0.0/8"))
+	l := NewLimiter(nil, Options{TrustedProxies: proxies})
+
+	tests := map[string]struct {
+		remote    string
+		forwarded []string
+		expected  string
+	}{
+		"direct client":                {"192.0.2.1:5000", nil, "192.0.2.1"},
+		"ignores untrusted forwarding": {"192.0.2.1:5000", []string{"198.51.100.7"}, "192.0.2.1"},
+		"client behind a proxy":        {"10.0.0.2:5000", []string{"198.51.100.7"}, "198.51.100.7"},
+		"skips chained proxies":        {"10.0.0.2:5000", []string{"203.0.113.9, 198.51.100.7", "10.0.0.3"}, "198.51.100.7"},
+		"stops at malformed entries":   {"10.0.0.2:5000", []string{"unknown, 10.0.0.3"}, "10.0.0.3"},
+		"proxy without header":         {"10.0.0.2:5000", nil, "10.0.0.2"},
+		"unmaps IPv4 in IPv6":          {"[::ffff:192.0.2.1]:5000", nil, "192.0.2.1"},
+		"groups IPv6 by /64":           {"[2001:db8:1:2:aaaa::1]:5000", nil, "2001:db8:1:2::/64"},
+		"forwarded IPv6 client":        {"10.0.0.2:5000", []string{"2001:db8:1:2:bbbb::1"}, "2001:db8:1:2::/64"},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/catalog", nil)
+			req.RemoteAddr = tc.remote
+			for _, header := range tc.forwarded {
+				req.Header.Add("X-Forwarded-For", header)
+			}
+
+			assert.Equal(t, tc.expected, l.clientIP(req))
+		})
+	}
+}
@@ -0,0 +1,80 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// tokenBucketScript takes a token from the bucket hash at KEYS[1] as Memory
+// does, given the capacity, the refill interval in microseconds and the
+// current time in microseconds. It returns whether a token was taken and the
+// tokens left, as a string since Lua numbers are truncated to integers.
+const tokenBucketScript = `
+local capacity = tonumber(ARGV[1])
+local interval = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+local bucket = redis.call('HMGET', KEYS[1], 'tokens', 'at')
+local tokens = tonumber(bucket[1]) or capacity
+local at = tonumber(bucket[2]) or now
+tokens = math.min(capacity, tokens + math.max(0, now - at) / interval)
+local allowed = 0
+if tokens >= 1 then
+  tokens = tokens - 1
+  allowed = 1
+end
+redis.call('HSET', KEYS[1], 'tokens', tostring(tokens), 'at', now)
+redis.call('PEXPIRE', KEYS[1], math.ceil(capacity * interval / 1000))
+return {allowed, tostring(tokens)}
+`
+
+// Scripter runs Lua scripts on a Redis-protocol server, as *cache.Redis does.
+type Scripter interface {
+	Eval(ctx context.Context, script string, keys []string, args ...string) (any, error)
+}
+
+// Redis is a Store keeping the buckets on a Redis-protocol server, so every
+// server instance sharing it enforces the limits together. Buckets expire
+// once they would be full again.
+type Redis struct {
+	client Scripter
+	prefix string
+	now    func() time.Time
+}
+
+// NewRedis returns a store keeping the buckets under keys starting with prefix.
+func NewRedis(client Scripter, prefix string) *Redis {
+	return &Redis{client: client, prefix: prefix, now: time.Now}
+}
+
+// Take implements Store. The bucket is updated by a script, so concurrent
+// requests from several instances cannot take the same token.
+func (s *Redis) Take(ctx context.Context, key string, limit Limit) (Result, error) {
+	reply, err := s.client.Eval(ctx, tokenBucketScript, []string{s.prefix + key},
+		strconv.Itoa(limit.Requests),
+		strconv.FormatInt(max(limit.interval().Microseconds(), 1), 10),
+		strconv.FormatInt(s.now().UnixMicro(), 10))
+	if err != nil {
+		return Result{}, err
+	}
+
+	values, ok := reply.([]any)
+	if !ok || len(values) != 2 {
+		return Result{}, fmt.Errorf("ratelimit: unexpected script reply %v", reply)
+	}
+	allowed, _ := values[0].(int64)
+	raw, _ := values[1].([]byte)
+	tokens, err := strconv.ParseFloat(string(raw), 64)
+	if err != nil {
+		return Result{}, fmt.Errorf("ratelimit: unexpected token count %q", raw)
+	}
+
+	// Rebuild the outcome from the tokens left, as Memory computes it.
+	before := tokens
+	if allowed == 1 {
+		before++
+	}
+	_, res := refill(before, 0, limit)
+	return res, nil
+}
@@ -0,0 +1,133 @@
+package ratelimit
+
+import (
+	"container/list"
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// Result is the outcome of taking a token from a bucket.
+type Result struct {
+	Allowed bool
+	// Remaining is the number of whole tokens left in the bucket.
+	Remaining int
+	// RetryAfter is how long a rejected client must wait for the next token.
+	RetryAfter time.Duration
+	// Reset is how long the bucket takes to fill up again.
+	Reset time.Duration
+}
+
+// Store keeps the token buckets. The in-memory store limits each server
+// instance on its own; a store shared by every instance, such as Redis,
+// enforces the limits across replicas.
+type Store interface {
+	// Take removes a token from the bucket under key, sized by limit, and
+	// reports whether there was one.
+	Take(ctx context.Context, key string, limit Limit) (Result, error)
+}
+
+// refill returns the tokens of a bucket that held tokens elapsed ago, then
+// takes one if it can and describes the outcome.
+func refill(tokens float64, elapsed time.Duration, limit Limit) (float64, Result) {
+	capacity := float64(limit.Requests)
+	tokens = math.Min(capacity, tokens+float64(elapsed)/float64(limit.interval()))
+
+	var res Result
+	if tokens >= 1 {
+		tokens--
+		res.Allowed = true
+	} else {
+		res.RetryAfter = time.Duration((1 - tokens) * float64(limit.interval()))
+	}
+	res.Remaining = int(tokens)
+	res.Reset = time.Duration((capacity - tokens) * float64(limit.interval()))
+	return tokens, res
+}
+
+// sweepInterval is how often the memory store drops full buckets.
+const sweepInterval = time.Minute
+
+// Memory is a Store keeping the buckets in process. It holds at most size
+// buckets: when full, it drops the buckets that filled up again and then, if
+// still full, the least recently used one, which lets that client start over
+// with a full bucket.
+type Memory struct {
+	size int
+	now  func() time.Time
+
+	mu        sync.Mutex
+	order     *list.List
+	buckets   map[string]*list.Element
+	lastSweep time.Time
+}
+
+type bucket struct {
+	key    string
+	tokens float64
+	at     time.Time
+	limit  Limit
+}
+
+func NewMemory(size int) *Memory {
+	return &Memory{
+		size:    size,
+		now:     time.Now,
+		order:   list.New(),
+		buckets: make(map[string]*list.Element),
+	}
+}
+
+// Take implements Store.
+func (m *Memory) Take(_ context.Context, key string, limit Limit) (Result, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.now()
+	if now.Sub(m.lastSweep) >= sweepInterval {
+		m.sweep(now)
+	}
+
+	var b *bucket
+	if el, ok := m.buckets[key]; ok {
+		m.order.MoveToFront(el)
+		b = el.Value.(*bucket)
+	}
+	if b == nil || b.limit != limit {
+		if b == nil && m.order.Len() >= m.size {
+			m.sweep(now)
+			for m.order.Len() >= m.size {
+				m.remove(m.order.Back())
+			}
+		}
+		b = &bucket{key: key, tokens: float64(limit.Requests), at: now, limit: limit}
+		if el, ok := m.buckets[key]; ok {
+			el.Value = b
+		} else {
+			m.buckets[key] = m.order.PushFront(b)
+		}
+	}
+	var res Result
+	b.tokens, res = refill(b.tokens, now.Sub(b.at), limit)
+	b.at = now
+	return res, nil
+}
+
+// sweep drops the buckets that have filled up again, which behave as new
+// ones, so clients seen once do not stay in memory.
+func (m *Memory) sweep(now time.Time) {
+	m.lastSweep = now
+	for _, el := range m.buckets {
+		b := el.Value.(*bucket)
+		refilled := b.tokens + float64(now.Sub(b.at))/float64(b.limit.interval())
+		if refilled >= float64(b.limit.Requests) {
+			m.remove(el)
+		}
+	}
+}
+
+func (m *Memory) remove(el *list.Element) {
+	m.order.Remove(el)
+	delete(m.buckets, el.Value.(*bucket).key)
+}
@@ -0,0 +1,146 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemory(t *testing.T) {
+	limit := Limit{Requests: 3, Period: 3 * time.Second}
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	store := NewMemory(10)
+	store.now = func() time.Time { return now }
+	take := func(key string) Result {
+		res, err := store.Take(context.Background(), key, limit)
+		require.NoError(t, err)
+		return res
+	}
+
+	t.Run("allows a burst of the bucket size, then refills a token per interval", func(t *testing.T) {
+		assert.Equal(t, Result{Allowed: true, Remaining: 2, Reset: time.Second}, take("a"))
+		assert.Equal(t, Result{Allowed: true, Remaining: 1, Reset: 2 * time.Second}, take("a"))
+		assert.Equal(t, Result{Allowed: true, Remaining: 0, Reset: 3 * time.Second}, take("a"))
+		assert.Equal(t, Result{Remaining: 0, RetryAfter: time.Second, Reset: 3 * time.Second}, take("a"))
+
+		now = now.Add(500 * time.Millisecond)
+		assert.Equal(t, Result{Remaining: 0, RetryAfter: 500 * time.Millisecond, Reset: 2500 * time.Millisecond}, take("a"))
+
+		now = now.Add(500 * time.Millisecond)
+		assert.True(t, take("a").Allowed)
+		assert.False(t, take("a").Allowed)
+	})
+
+	t.Run("keeps a bucket per key", func(t *testing.T) {
+		assert.True(t, take("b").Allowed)
+	})
+
+	t.Run("drops the buckets that filled up again", func(t *testing.T) {
+		now = now.Add(sweepInterval)
+		take("c")
+
+		assert.Len(t, store.buckets, 1)
+	})
+
+	t.Run("drops the least recently used bucket when full", func(t *testing.T) {
+		store := NewMemory(2)
+		store.now = func() time.Time { return now }
+		take := func(key string) Result {
+			res, err := store.Take(context.Background(), key, limit)
+			require.NoError(t, err)
+			return res
+		}
+
+		take("a")
+		take("b")
+		take("a")
+		take("c")
+
+		assert.Len(t, store.buckets, 2)
+		assert.Contains(t, store.buckets, "a")
+		assert.NotContains(t, store.buckets, "b")
+		assert.Equal(t, 0, take("a").Remaining, "a keeps its bucket")
+	})
+}
+
+// fakeScripter runs the token bucket script the way Redis would, keeping the
+// bucket fields in memory.
+type fakeScripter struct {
+	buckets map[string][2]float64
+	keys    []string
+	err     error
+}
+
+func (s *fakeScripter) Eval(_ context.Context, script string, keys []string, args ...string) (any, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	s.keys = append(s.keys, keys...)
+	capacity, _ := strconv.ParseFloat(args[0], 64)
+	interval, _ := strconv.ParseFloat(args[1], 64)
+	now, _ := strconv.ParseFloat(args[2], 64)
+
+	tokens, at := capacity, now
+	if b, ok := s.buckets[keys[0]]; ok {
+		tokens, at = b[0], b[1]
+	}
+	tokens = min(capacity, tokens+max(0, now-at)/interval)
+	allowed := int64(0)
+	if tokens >= 1 {
+		tokens--
+		allowed = 1
+	}
+	s.buckets[keys[0]] = [2]float64{tokens, now}
+	return []any{allowed, []byte(strconv.FormatFloat(tokens, 'f', -1, 64))}, nil
+}
+
+func TestRedis(t *testing.T) {
+	limit := Limit{Requests: 2, Period: time.Minute}
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	scripter := &fakeScripter{buckets: map[string][2]float64{}}
+	store := NewRedis(scripter, "ratelimit:")
+	store.now = func() time.Time { return now }
+	take := func() Result {
+		res, err := store.Take(context.Background(), "GET /catalog ip:192.0.2.1", limit)
+		require.NoError(t, err)
+		return res
+	}
+
+	t.Run("reports the bucket state computed by the script", func(t *testing.T) {
+		assert.Equal(t, Result{Allowed: true, Remaining: 1, Reset: 30 * time.Second}, take())
+		assert.Equal(t, Result{Allowed: true, Remaining: 0, Reset: time.Minute}, take())
+		assert.Equal(t, Result{Remaining: 0, RetryAfter: 30 * time.Second, Reset: time.Minute}, take())
+
+		now = now.Add(45 * time.Second)
+		assert.Equal(t, Result{Allowed: true, Remaining: 0, Reset: 45 * time.Second}, take())
+		assert.Equal(t, []string{"ratelimit:GET /catalog ip:192.0.2.1"}, scripter.keys[:1])
+	})
+
+	t.Run("fails with the server", func(t *testing.T) {
+		scripter.err = errors.New("connection refused")
+		defer func() { scripter.err = nil }()
+
+		_, err := store.Take(context.Background(), "GET /catalog ip:192.0.2.1", limit)
+
+		assert.Error(t, err)
+	})
+
+	t.Run("rejects malformed replies", func(t *testing.T) {
+		store := NewRedis(scripterFunc(func() (any, error) { return int64(1), nil }), "")
+
+		_, err := store.Take(context.Background(), "key", limit)
+
+		assert.ErrorContains(t, err, "unexpected script reply")
+	})
+}
+
+type scripterFunc func() (any, error)
+
+func (f scripterFunc) Eval(context.Context, string, []string, ...string) (any, error) {
+	return f()
+}
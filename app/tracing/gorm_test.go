@@ -0,0 +1,38 @@
+package tracing
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+func TestInstrumentDB(t *testing.T) {
+	recorder := recordSpans(t)
+
+	// A dry run executes the callbacks without reaching a database.
+	db, err := gorm.Open(postgres.Open("postgres://app@127.0.0.1:1/challenge"), &gorm.Config{
+		DryRun:               true,
+		DisableAutomaticPing: true,
+	})
+	require.NoError(t, err)
+	require.NoError(t, InstrumentDB("primary", db))
+
+	ctx, parent := otel.Tracer("test").Start(context.Background(), "catalog.load")
+	var rows []map[string]any
+	db.WithContext(ctx).Table("products").Where("code = ?", "PROD001").Find(&rows)
+	parent.End()
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 2)
+	query := spans[0]
+	assert.Equal(t, "query products", query.Name())
+	assert.Equal(t, parent.SpanContext().SpanID(), query.Parent().SpanID())
+	assert.Contains(t, query.Attributes(), attribute.String("db.query.text", "SELECT * FROM \"products\" WHERE code = $1"))
+	assert.Contains(t, query.Attributes(), attribute.String("db.client.connection.pool.name", "primary"))
+}
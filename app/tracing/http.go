@@ -0,0 +1,50 @@
+package tracing
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/mytheresa/go-hiring-challenge/app/api"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const instrumentationName = "github.com/mytheresa/go-hiring-challenge/app/tracing"
+
+// InstrumentHandler starts a server span for every request served by mux,
+// continuing the trace of the caller when the request carries W3C trace context
+// headers. The span is named after the ServeMux pattern that matched.
+func InstrumentHandler(mux http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+		ctx, span := otel.Tracer(instrumentationName).Start(ctx, r.Method,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				attribute.String("http.request.method", r.Method),
+				attribute.String("url.path", r.URL.Path),
+				attribute.String("user_agent.original", r.UserAgent()),
+			),
+		)
+		defer span.End()
+
+		rec := api.NewStatusRecorder(w)
+		r = r.WithContext(ctx)
+		mux.ServeHTTP(rec, r)
+
+		if r.Pattern != "" {
+			span.SetName(r.Pattern)
+			_, route, _ := strings.Cut(r.Pattern, " ")
+			if route == "" {
+				route = r.Pattern
+			}
+			span.SetAttributes(attribute.String("http.route", route))
+		}
+		span.SetAttributes(attribute.Int("http.response.status_code", rec.Status))
+		if rec.Status >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(rec.Status))
+		}
+	})
+}
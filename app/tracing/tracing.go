@@ -0,0 +1,132 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// Exporters accepted in Options.Exporter.
+const (
+	ExporterNone   = "none"
+	ExporterOTLP   = "otlp"
+	ExporterStdout = "stdout"
+	ExporterFile   = "file"
+)
+
+// Options configures where spans are sent.
+type Options struct {
+	// Exporter is none, otlp (OTLP over HTTP), stdout or file. The stdout and
+	// file exporters write one JSON document per span, for local runs.
+	Exporter string
+	// Endpoint is the OTLP collector URL. When empty the standard
+	// OTEL_EXPORTER_OTLP_* variables apply, defaulting to http://localhost:4318.
+	Endpoint string
+	// File is the path spans are appended to with the file exporter.
+	File string
+	// SampleRatio is the fraction of new traces recorded. Requests carrying a
+	// trace context follow the sampling decision of their caller.
+	SampleRatio float64
+	// ServiceName identifies the spans of this service; OTEL_SERVICE_NAME overrides it.
+	ServiceName string
+}
+
+// Validate reports an unknown exporter or an incomplete exporter setting.
+func (o Options) Validate() error {
+	var errs []error
+	switch o.Exporter {
+	case ExporterNone, ExporterOTLP, ExporterStdout:
+	case ExporterFile:
+		if o.File == "" {
+			errs = append(errs, errors.New("tracing file is required with the file exporter"))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("unknown tracing exporter %q", o.Exporter))
+	}
+	if o.SampleRatio < 0 || o.SampleRatio > 1 {
+		errs = append(errs, errors.New("tracing sample ratio must be between 0 and 1"))
+	}
+	return errors.Join(errs...)
+}
+
+// Setup installs the W3C trace context propagator and, unless the exporter is
+// none, a tracer provider exporting to the configured destination. The returned
+// function flushes the pending spans and must be called on shutdown.
+func Setup(ctx context.Context, opts Options) (shutdown func(context.Context) error, err error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+
+	// Propagation also applies without an exporter, so the trace context of a
+	// request still reaches the spans of the services it calls.
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+	if opts.Exporter == ExporterNone {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	var closer io.Closer
+	var exporter sdktrace.SpanExporter
+	switch opts.Exporter {
+	case ExporterOTLP:
+		var options []otlptracehttp.Option
+		if opts.Endpoint != "" {
+			options = append(options, otlptracehttp.WithEndpointURL(opts.Endpoint))
+		}
+		exporter, err = otlptracehttp.New(ctx, options...)
+	case ExporterStdout:
+		exporter, err = stdouttrace.New(stdouttrace.WithWriter(os.Stdout))
+	case ExporterFile:
+		var f *os.File
+		f, err = os.OpenFile(opts.File, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+		if err != nil {
+			return nil, fmt.Errorf("opening trace file: %w", err)
+		}
+		closer = f
+		exporter, err = stdouttrace.New(stdouttrace.WithWriter(f))
+	}
+	if err != nil {
+		if closer != nil {
+			closer.Close()
+		}
+		return nil, fmt.Errorf("creating %s trace exporter: %w", opts.Exporter, err)
+	}
+
+	// Later detectors win, so OTEL_SERVICE_NAME and OTEL_RESOURCE_ATTRIBUTES
+	// override the service name given here.
+	res, err := resource.New(ctx,
+		resource.WithTelemetrySDK(),
+		resource.WithAttributes(attribute.String("service.name", opts.ServiceName)),
+		resource.WithFromEnv(),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(opts.SampleRatio))),
+	)
+	otel.SetTracerProvider(provider)
+
+	return func(ctx context.Context) error {
+		err := provider.Shutdown(ctx)
+		if closer != nil {
+			err = errors.Join(err, closer.Close())
+		}
+		return err
+	}, nil
+}
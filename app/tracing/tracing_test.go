@@ -0,0 +1,74 @@
+package tracing
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// recordSpans installs a tracer provider recording every span for the duration of the test.
+func recordSpans(t *testing.T) *tracetest.SpanRecorder {
+	t.Helper()
+
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(provider)
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+	return recorder
+}
+
+func TestOptionsValidate(t *testing.T) {
+	valid := Options{Exporter: ExporterStdout, SampleRatio: 1}
+	assert.NoError(t, valid.Validate())
+
+	tests := map[string]Options{
+		"unknown exporter":    {Exporter: "jaeger", SampleRatio: 1},
+		"file without a path": {Exporter: ExporterFile, SampleRatio: 1},
+		"ratio above one":     {Exporter: ExporterNone, SampleRatio: 1.5},
+	}
+	for name, opts := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Error(t, opts.Validate())
+		})
+	}
+}
+
+func TestSetup(t *testing.T) {
+	t.Run("appends spans to the trace file", func(t *testing.T) {
+		previous := otel.GetTracerProvider()
+		t.Cleanup(func() { otel.SetTracerProvider(previous) })
+		path := filepath.Join(t.TempDir(), "traces.json")
+
+		shutdown, err := Setup(context.Background(), Options{
+			Exporter:    ExporterFile,
+			File:        path,
+			SampleRatio: 1,
+			ServiceName: "catalog-test",
+		})
+		require.NoError(t, err)
+		_, span := otel.Tracer("test").Start(context.Background(), "GET /catalog")
+		span.End()
+		require.NoError(t, shutdown(context.Background()))
+
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+		assert.Contains(t, string(data), `"Name":"GET /catalog"`)
+		assert.Contains(t, string(data), "catalog-test")
+	})
+
+	t.Run("installs only the propagator without an exporter", func(t *testing.T) {
+		shutdown, err := Setup(context.Background(), Options{Exporter: ExporterNone})
+		require.NoError(t, err)
+
+		assert.ElementsMatch(t, []string{"traceparent", "tracestate", "baggage"}, otel.GetTextMapPropagator().Fields())
+		assert.NoError(t, shutdown(context.Background()))
+	})
+}
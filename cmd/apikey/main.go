@@ -0,0 +1,172 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"log/slog"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"github.com/mytheresa/go-hiring-challenge/app/auth"
+	"github.com/mytheresa/go-hiring-challenge/app/config"
+	"github.com/mytheresa/go-hiring-challenge/app/database"
+	"github.com/mytheresa/go-hiring-challenge/models"
+	"gorm.io/gorm"
+)
+
+const usage = `Usage: apikey [configuration flags] <command> [flags]
+
+Commands:
+  issue -name <name> -scopes <scope,...> -roles <role,...> [-ttl <duration>]
+        Issue a key and print it. It cannot be shown again.
+  revoke -prefix <prefix>
+        Revoke the key starting with prefix.
+  list  List the issued keys.
+
+Scopes: %s
+Roles: %s
+`
+
+func main() {
+	cfg, err := config.Load(os.Args[0], os.Args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
+	if err != nil {
+		log.Fatalf("Invalid configuration: %s", err)
+	}
+	if len(cfg.Args) == 0 {
+		fmt.Fprintf(os.Stderr, usage, strings.Join(auth.Scopes, ", "), strings.Join(auth.Roles, ", "))
+		os.Exit(2)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	// Initialize database connection
+	dbConfig := cfg.Database
+	// Keys are read and written on the primary only.
+	dbConfig.Replicas = nil
+	db, err := database.NewCluster(ctx, dbConfig, slog.Default())
+	if err != nil {
+		log.Fatalf("Failed to connect database: %s", err)
+	}
+	defer db.Close()
+
+	repo := models.NewAPIKeysRepository(db, cfg.QueryTimeout)
+
+	command, args := cfg.Args[0], cfg.Args[1:]
+	switch command {
+	case "issue":
+		err = issue(ctx, repo, args)
+	case "revoke":
+		err = revoke(ctx, repo, args)
+	case "list":
+		err = list(ctx, repo)
+	default:
+		err = fmt.Errorf("unknown command %q", command)
+	}
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
+	if err != nil {
+		log.Fatalf("%s failed: %s", command, err)
+	}
+}
+
+func issue(ctx context.Context, repo *models.APIKeysRepository, args []string) error {
+	flags := flag.NewFlagSet("issue", flag.ContinueOnError)
+	name := flags.String("name", "", "who or what the key is for")
+	scopes := flags.String("scopes", "", "comma-separated scopes: "+strings.Join(auth.Scopes, ", "))
+	roles := flags.String("roles", "", "comma-separated roles: "+strings.Join(auth.Roles, ", "))
+	ttl := flags.Duration("ttl", 0, "lifetime of the key; 0 never expires")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if *name == "" {
+		return errors.New("-name is required")
+	}
+	scopeList, roleList := splitList(*scopes), splitList(*roles)
+	if err := auth.ValidateScopes(scopeList); err != nil {
+		return err
+	}
+	if err := auth.ValidateRoles(roleList); err != nil {
+		return err
+	}
+	if *ttl < 0 {
+		return errors.New("-ttl must not be negative")
+	}
+
+	key, stored, err := auth.NewKey(*name, scopeList, roleList)
+	if err != nil {
+		return err
+	}
+	if *ttl > 0 {
+		expiresAt := time.Now().Add(*ttl)
+		stored.ExpiresAt = &expiresAt
+	}
+	if err := repo.Create(ctx, stored); err != nil {
+		return err
+	}
+
+	log.Printf("Issued key %s for %q with scopes %s and roles %s", stored.Prefix, stored.Name, stored.Scopes, stored.Roles)
+	fmt.Println(key)
+	return nil
+}
+
+func revoke(ctx context.Context, repo *models.APIKeysRepository, args []string) error {
+	flags := flag.NewFlagSet("revoke", flag.ContinueOnError)
+	prefix := flags.String("prefix", "", "prefix of the key to revoke, as shown by list")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *prefix == "" {
+		return errors.New("-prefix is required")
+	}
+
+	err := repo.Revoke(ctx, *prefix)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return fmt.Errorf("no active key with prefix %s", *prefix)
+	}
+	if err != nil {
+		return err
+	}
+	log.Printf("Revoked key %s", *prefix)
+	return nil
+}
+
+func list(ctx context.Context, repo *models.APIKeysRepository) error {
+	keys, err := repo.GetAll(ctx)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "PREFIX\tNAME\tSCOPES\tROLES\tCREATED\tSTATUS")
+	for _, k := range keys {
+		status := "active"
+		switch {
+		case k.RevokedAt != nil:
+			status = "revoked " + k.RevokedAt.Format(time.RFC3339)
+		case k.ExpiresAt != nil && !k.ExpiresAt.After(time.Now()):
+			status = "expired " + k.ExpiresAt.Format(time.RFC3339)
+		case k.ExpiresAt != nil:
+			status = "expires " + k.ExpiresAt.Format(time.RFC3339)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", k.Prefix, k.Name, k.Scopes, k.Roles, k.CreatedAt.Format(time.RFC3339), status)
+	}
+	return w.Flush()
+}
+
+// splitList splits a comma- or space-separated flag value.
+func splitList(s string) []string {
+	return strings.FieldsFunc(s, func(r rune) bool { return r == ',' || r == ' ' })
+}
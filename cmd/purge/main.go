@@ -0,0 +1,76 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/mytheresa/go-hiring-challenge/app/config"
+	"github.com/mytheresa/go-hiring-challenge/app/database"
+	"github.com/mytheresa/go-hiring-challenge/models"
+)
+
+func main() {
+	cfg, err := config.Load(os.Args[0], os.Args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
+	if err != nil {
+		log.Fatalf("Invalid configuration: %s", err)
+	}
+	log.Printf("Configuration:\n%s", cfg)
+
+	// Interrupting the command cancels the connection attempts and the running purge.
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	// Initialize database connection
+	dbConfig := cfg.Database
+	// Purging only writes, so replicas are not needed.
+	dbConfig.Replicas = nil
+	db, err := database.NewCluster(ctx, dbConfig, slog.Default())
+	if err != nil {
+		log.Fatalf("Failed to connect database: %s", err)
+	}
+
+	err = purge(ctx, db, cfg)
+	db.Close()
+	if err != nil {
+		// A failed purge must fail the command, so schedulers notice and retry.
+		log.Fatal(err)
+	}
+}
+
+// purge removes the products and categories deleted longer than the
+// retention ago and the expired idempotency keys.
+func purge(ctx context.Context, db *database.Cluster, cfg *config.Config) error {
+	before := time.Now().Add(-cfg.PurgeRetention)
+
+	products, err := models.NewProductsRepository(db, 0).PurgeDeleted(ctx, before)
+	if err != nil {
+		return fmt.Errorf("purging products failed: %w", err)
+	}
+	log.Printf("Purged %d products deleted before %s", products, before.Format(time.RFC3339))
+
+	categories, err := models.NewCategoriesRepository(db, 0).PurgeDeleted(ctx, before)
+	if err != nil {
+		return fmt.Errorf("purging categories failed: %w", err)
+	}
+	log.Printf("Purged %d categories deleted before %s", categories, before.Format(time.RFC3339))
+
+	// Idempotency keys have their own retention, so every expired one goes.
+	now := time.Now()
+	requests, err := models.NewIdempotentRequestsRepository(db, 0).PurgeExpired(ctx, now)
+	if err != nil {
+		return fmt.Errorf("purging idempotency keys failed: %w", err)
+	}
+	log.Printf("Purged %d idempotency keys expired before %s", requests, now.Format(time.RFC3339))
+	return nil
+}
@@ -1,33 +1,38 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"flag"
 	"log"
+	"log/slog"
 	"os"
 	"path/filepath"
 	"sort"
 	"strings"
 
-	"github.com/joho/godotenv"
-
+	"github.com/mytheresa/go-hiring-challenge/app/config"
 	"github.com/mytheresa/go-hiring-challenge/app/database"
 )
 
 func main() {
-	// Load environment variables from .env file
-	if err := godotenv.Load(".env"); err != nil {
-		log.Fatalf("Error loading .env file: %s", err)
+	cfg, err := config.Load(os.Args[0], os.Args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
+	if err != nil {
+		log.Fatalf("Invalid configuration: %s", err)
 	}
+	log.Printf("Configuration:\n%s", cfg)
 
 	// Initialize database connection
-	db, close := database.New(
-		os.Getenv("POSTGRES_USER"),
-		os.Getenv("POSTGRES_PASSWORD"),
-		os.Getenv("POSTGRES_DB"),
-		os.Getenv("POSTGRES_PORT"),
-	)
+	db, close, err := database.New(context.Background(), cfg.Database, slog.Default())
+	if err != nil {
+		log.Fatalf("Failed to connect database: %s", err)
+	}
 	defer close()
 
-	dir := os.Getenv("POSTGRES_SQL_DIR")
+	dir := cfg.SQLDir
 	files, err := os.ReadDir(dir)
 	if err != nil {
 		log.Fatalf("reading directory failed: %v", err)
@@ -2,75 +2,282 @@ package main
 
 import (
 	"context"
-	"fmt"
+	"errors"
+	"flag"
 	"log"
+	"log/slog"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
-	"github.com/joho/godotenv"
+	"github.com/mytheresa/go-hiring-challenge/app/api"
+	"github.com/mytheresa/go-hiring-challenge/app/auth"
+	"github.com/mytheresa/go-hiring-challenge/app/cache"
 	"github.com/mytheresa/go-hiring-challenge/app/catalog"
 	"github.com/mytheresa/go-hiring-challenge/app/categories"
+	"github.com/mytheresa/go-hiring-challenge/app/compression"
+	"github.com/mytheresa/go-hiring-challenge/app/config"
+	"github.com/mytheresa/go-hiring-challenge/app/cors"
 	"github.com/mytheresa/go-hiring-challenge/app/database"
+	"github.com/mytheresa/go-hiring-challenge/app/health"
+	"github.com/mytheresa/go-hiring-challenge/app/idempotency"
+	"github.com/mytheresa/go-hiring-challenge/app/logging"
+	"github.com/mytheresa/go-hiring-challenge/app/metrics"
+	"github.com/mytheresa/go-hiring-challenge/app/middleware"
+	"github.com/mytheresa/go-hiring-challenge/app/promotions"
+	"github.com/mytheresa/go-hiring-challenge/app/ratelimit"
+	"github.com/mytheresa/go-hiring-challenge/app/tracing"
 	"github.com/mytheresa/go-hiring-challenge/models"
 )
 
+// Cache policies for the public read routes. Prices change with promotions,
+// so catalog responses are kept short-lived and revalidated through their ETag.
+var (
+	listCachePolicy       = api.CachePolicy{MaxAge: 30 * time.Second}
+	detailCachePolicy     = api.CachePolicy{MaxAge: time.Minute}
+	categoriesCachePolicy = api.CachePolicy{MaxAge: 5 * time.Minute}
+)
+
+// readinessTimeout bounds the dependency checks of a readiness probe.
+const readinessTimeout = 2 * time.Second
+
 func main() {
-	// Load environment variables from .env file
-	if err := godotenv.Load(".env"); err != nil {
-		log.Fatalf("Error loading .env file: %s", err)
+	cfg, err := config.Load(os.Args[0], os.Args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
+	if err != nil {
+		log.Fatalf("Invalid configuration: %s", err)
+	}
+
+	// Structured logging; the log package is redirected to it as well.
+	logger, err := logging.New(os.Stdout, cfg.Logging)
+	if err != nil {
+		log.Fatalf("Invalid logging configuration: %s", err)
+	}
+	slog.SetDefault(logger)
+	fatal := func(msg string, err error) {
+		logger.Error(msg, "error", err)
+		os.Exit(1)
 	}
+	logger.Info("Configuration loaded", "config", cfg)
 
 	// signal handling for graceful shutdown
 	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
 	defer stop()
 
+	shutdownTracing, err := tracing.Setup(ctx, cfg.Tracing)
+	if err != nil {
+		fatal("Failed to set up tracing", err)
+	}
+	defer func() {
+		// Flush the spans of the last requests.
+		flushCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := shutdownTracing(flushCtx); err != nil {
+			logger.Error("Flushing traces failed", "error", err)
+		}
+	}()
+
 	// Initialize database connection
-	db, close := database.New(
-		os.Getenv("POSTGRES_USER"),
-		os.Getenv("POSTGRES_PASSWORD"),
-		os.Getenv("POSTGRES_DB"),
-		os.Getenv("POSTGRES_PORT"),
-	)
-	defer close()
+	db, err := database.NewCluster(ctx, cfg.Database, logger)
+	if err != nil {
+		fatal("Failed to connect database", err)
+	}
+	defer db.Close()
+
+	// Metrics and traces cover the database pools and every statement they run.
+	registry := metrics.NewRegistry()
+	dbMetrics := metrics.NewDB(registry)
+	for _, pool := range db.Pools() {
+		if err := dbMetrics.Instrument(pool.Name, pool.DB); err != nil {
+			fatal("Failed to instrument database "+pool.Name, err)
+		}
+		if err := tracing.InstrumentDB(pool.Name, pool.DB); err != nil {
+			fatal("Failed to trace database "+pool.Name, err)
+		}
+	}
 
 	// Initialize repositories and handlers
-	prodRepo := models.NewProductsRepository(db)
-	catRepo := models.NewCategoriesRepository(db)
+	prodRepo := models.NewProductsRepository(db, cfg.QueryTimeout)
+	catRepo := models.NewCategoriesRepository(db, cfg.QueryTimeout)
+	promoRepo := models.NewPromotionsRepository(db, cfg.QueryTimeout)
+	keysRepo := models.NewAPIKeysRepository(db, cfg.QueryTimeout)
+	idempotentRepo := models.NewIdempotentRequestsRepository(db, cfg.QueryTimeout)
 
-	catalogHandler := catalog.NewCatalogHandler(prodRepo)
-	categoriesHandler := categories.NewCategoriesHandler(catRepo)
+	// Readiness covers the database, its schema and a remote cache backend.
+	checker := health.NewChecker(readinessTimeout)
+	checker.Add("database", db.Ping)
+	checker.Add("migrations", func(ctx context.Context) error { return models.CheckSchema(ctx, db) })
+
+	// The Redis-protocol server can hold the cache and the rate limits.
+	var redis *cache.Redis
+	if cfg.CacheBackend == "redis" || cfg.RateLimit.Store == ratelimit.StoreRedis {
+		redis = cache.NewRedis(cfg.Redis)
+		defer redis.Close()
+		checker.Add("cache", redis.Ping)
+	}
+
+	// Initialize the cache shared by the catalog and categories repositories
+	var backend cache.Cache
+	switch cfg.CacheBackend {
+	case "memory":
+		backend = cache.NewMemory(cfg.CacheSize)
+	case "redis":
+		backend = redis
+	}
+	catalogCache := cache.NewReadThrough(backend, "catalog", cfg.CatalogCacheTTL, cfg.QueryTimeout)
+	categoriesCache := cache.NewReadThrough(backend, "categories", cfg.CategoriesCacheTTL, cfg.QueryTimeout)
+
+	cachedProducts := catalog.NewCachedProductsRepository(prodRepo, catalogCache)
+	cachedCategories := categories.NewCachedCategoriesRepository(catRepo, categoriesCache, catalogCache)
+
+	catalogHandler := catalog.NewCatalogHandler(cachedProducts, promoRepo)
+	categoriesHandler := categories.NewCategoriesHandler(cachedCategories)
+	promotionsHandler := promotions.NewPromotionsHandler(promoRepo)
+
+	// Clients authenticate with an API key, or with a bearer token from the
+	// identity provider when its key set is configured.
+	authenticator := auth.Any{auth.NewAPIKeys(keysRepo)}
+	if cfg.JWT.JWKS != "" {
+		jwks, err := auth.LoadJWKS(ctx, cfg.JWT.JWKS, cfg.JWT.RefreshInterval)
+		if err != nil {
+			fatal("Failed to load JWKS", err)
+		}
+		defer jwks.Close()
+		authenticator = append(authenticator, auth.NewJWT(jwks, cfg.JWT))
+	}
+
+	// Rate limit buckets are kept per instance, or shared through Redis.
+	var limitStore ratelimit.Store = ratelimit.NewMemory(cfg.RateLimit.MaxBuckets)
+	if cfg.RateLimit.Store == ratelimit.StoreRedis {
+		limitStore = ratelimit.NewRedis(redis, "ratelimit:")
+	}
+	limiter := ratelimit.NewLimiter(limitStore, cfg.RateLimit)
+
+	// Route groups: probes and metrics run unbounded, reads are bounded by the
+	// request timeout and writes additionally limit their body size. Each
+	// address is limited before its credentials are looked up, so made-up API
+	// keys cannot flood the database. Clients are identified next, so the
+	// route limits apply per API key or token and per address for anonymous
+	// clients. Public reads are open; admin reads
+	// and every write need credentials granting the scope of their group, and
+	// the handlers check the roles of the caller. Authenticated POST requests
+	// may carry an Idempotency-Key, making retries replay the first response;
+	// PATCH and DELETE are guarded by versions instead and skip that middleware.
+	base := middleware.NewChain(
+		middleware.Timeout(cfg.RequestTimeout),
+		limiter.LimitAddress(cfg.RateLimit.Addresses),
+		auth.Identify(authenticator),
+	)
+	reads := base.Append(limiter.Limit(cfg.RateLimit.Reads))
+	writes := base.Append(limiter.Limit(cfg.RateLimit.Writes), middleware.MaxBodySize(int64(cfg.MaxBodyBytes)))
+	idempotent := idempotency.New(idempotentRepo, cfg.IdempotencyKeyRetention)
+
+	adminReads := reads.Append(auth.Require(authenticator, auth.ScopeCatalogRead))
+	catalogWrites := writes.Append(auth.Require(authenticator, auth.ScopeCatalogWrite))
+	categoriesWrites := writes.Append(auth.Require(authenticator, auth.ScopeCategoriesWrite))
+	promotionsWrites := writes.Append(auth.Require(authenticator, auth.ScopePromotionsWrite))
+	catalogPosts := catalogWrites.Append(idempotent)
+	categoriesPosts := categoriesWrites.Append(idempotent)
+	promotionsPosts := promotionsWrites.Append(idempotent)
 
 	// Set up routing
 	mux := http.NewServeMux()
 
+	// Probes
+	mux.HandleFunc("GET /healthz", checker.HandleLive)
+	mux.HandleFunc("GET /readyz", checker.HandleReady)
+	mux.HandleFunc("GET /metrics", registry.Handler())
+
+	// Caller identity
+	mux.Handle("GET /me", reads.Append(auth.Authenticated(authenticator)).ThenFunc(auth.HandleMe))
+
 	// Catalog routes
-	mux.HandleFunc("GET /catalog", catalogHandler.HandleGet)
-	mux.HandleFunc("GET /catalog/{code}", catalogHandler.HandleGetByCode)
+	mux.Handle("GET /catalog", reads.ThenFunc(api.Conditional(listCachePolicy, catalogHandler.HandleGet)))
+	mux.Handle("GET /catalog/{code}", reads.ThenFunc(api.Conditional(detailCachePolicy, catalogHandler.HandleGetByCode)))
+	mux.Handle("GET /catalog/{code}/price-history", reads.ThenFunc(api.Conditional(detailCachePolicy, catalogHandler.HandleGetPriceHistory)))
 
 	// Categories routes
-	mux.HandleFunc("GET /categories", categoriesHandler.HandleList)
-	mux.HandleFunc("POST /categories", categoriesHandler.HandleCreate)
+	mux.Handle("GET /categories", reads.ThenFunc(api.Conditional(categoriesCachePolicy, categoriesHandler.HandleList)))
+	mux.Handle("POST /categories", categoriesPosts.ThenFunc(categoriesHandler.HandleCreate))
+	mux.Handle("DELETE /categories/{code}", categoriesWrites.ThenFunc(categoriesHandler.HandleDelete))
+	mux.Handle("POST /categories/{code}/restore", categoriesPosts.ThenFunc(categoriesHandler.HandleRestore))
+
+	// Admin routes
+	mux.Handle("GET /admin/catalog", adminReads.ThenFunc(catalogHandler.HandleAdminGet))
+	mux.Handle("GET /admin/catalog/{code}", adminReads.ThenFunc(catalogHandler.HandleAdminGetByCode))
+	mux.Handle("GET /admin/catalog/{code}/price-history", adminReads.ThenFunc(catalogHandler.HandleAdminGetPriceHistory))
+	mux.Handle("PATCH /admin/catalog/{code}/status", catalogWrites.ThenFunc(catalogHandler.HandleUpdateStatus))
+	mux.Handle("DELETE /admin/catalog/{code}", catalogWrites.ThenFunc(catalogHandler.HandleDelete))
+	mux.Handle("POST /admin/catalog/{code}/restore", catalogPosts.ThenFunc(catalogHandler.HandleRestore))
+	mux.Handle("DELETE /admin/catalog/{code}/variants/{sku}", catalogWrites.ThenFunc(catalogHandler.HandleDeleteVariant))
+	mux.Handle("POST /admin/catalog/{code}/variants/{sku}/restore", catalogPosts.ThenFunc(catalogHandler.HandleRestoreVariant))
+	mux.Handle("PATCH /admin/catalog/{code}/prices", catalogWrites.ThenFunc(catalogHandler.HandleUpdatePrices))
+	mux.Handle("GET /admin/promotions", adminReads.ThenFunc(promotionsHandler.HandleList))
+	mux.Handle("POST /admin/promotions", promotionsPosts.ThenFunc(promotionsHandler.HandleCreate))
+	mux.Handle("GET /admin/promotions/{id}", adminReads.ThenFunc(promotionsHandler.HandleGet))
+	mux.Handle("DELETE /admin/promotions/{id}", promotionsWrites.ThenFunc(promotionsHandler.HandleDelete))
+	mux.Handle("GET /admin/cache/stats", adminReads.ThenFunc(cache.HandleStats(catalogCache, categoriesCache)))
+
+	// Requests derive their context from baseCtx, so cancelling it aborts the
+	// queries still running once the shutdown drain deadline has passed.
+	baseCtx, cancelRequests := context.WithCancel(context.Background())
+	defer cancelRequests()
+
+	// Middleware shared by every route. The request ID comes first so every
+	// later log carries it; the access log and panic recovery come last and
+	// pass the request on unchanged, so they see the matched route and report
+	// recovered panics. CORS answers preflight requests before they reach the
+	// mux, which has no OPTIONS routes, and adds its headers to every other
+	// response, errors included. Compression runs inside the access log, which
+	// thus reports the bytes actually sent.
+	handler := middleware.NewChain(
+		func(next http.Handler) http.Handler { return api.WithRequestID(logger, next) },
+		tracing.InstrumentHandler,
+		func(next http.Handler) http.Handler { return metrics.InstrumentHandler(registry, next) },
+		api.AccessLog,
+		cors.New(cfg.CORS),
+		compression.New(cfg.Compression),
+		middleware.Recover,
+	).Then(mux)
 
 	// Set up the HTTP server
 	srv := &http.Server{
-		Addr:    fmt.Sprintf("localhost:%s", os.Getenv("HTTP_PORT")),
-		Handler: mux,
+		Addr:        cfg.HTTPAddr(),
+		Handler:     handler,
+		BaseContext: func(net.Listener) context.Context { return baseCtx },
+		ErrorLog:    slog.NewLogLogger(logger.Handler(), slog.LevelWarn),
 	}
 
 	// Start the server
 	go func() {
-		log.Printf("Starting server on http://%s", srv.Addr)
+		logger.Info("Starting server", "addr", "http://"+srv.Addr)
 		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			log.Fatalf("Server failed: %s", err)
+			fatal("Server failed", err)
 		}
 
-		log.Println("Server stopped gracefully")
+		logger.Info("Server stopped gracefully")
 	}()
 
 	<-ctx.Done()
-	log.Println("Shutting down server...")
-	srv.Shutdown(ctx)
 	stop()
+	logger.Info("Shutting down server", "delay", cfg.ShutdownDelay, "timeout", cfg.ShutdownTimeout)
+
+	// Keep serving while readiness fails, so load balancers stop routing here
+	// before the listener closes. A second signal exits immediately.
+	checker.Drain()
+	time.Sleep(cfg.ShutdownDelay)
+
+	// The signal context is already cancelled, so draining gets its own deadline.
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		logger.Warn("Shutdown drain deadline exceeded, aborting in-flight requests", "error", err)
+		cancelRequests()
+		srv.Close()
+	}
 }
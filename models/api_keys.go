@@ -0,0 +1,35 @@
+package models
+
+import (
+	"strings"
+	"time"
+)
+
+// APIKey is a credential issued to an API client. Only the SHA-256 Hash of the
+// key is kept; Prefix is its public beginning, used to tell keys apart.
+// Scopes and Roles are the space-separated scopes and roles the key grants.
+type APIKey struct {
+	ID        uint       `gorm:"primaryKey"`
+	Name      string     `gorm:"not null"`
+	Prefix    string     `gorm:"not null;uniqueIndex"`
+	Hash      string     `gorm:"not null;uniqueIndex"`
+	Scopes    string     `gorm:"not null"`
+	Roles     string     `gorm:"not null"`
+	CreatedAt time.Time  `gorm:"not null"`
+	ExpiresAt *time.Time `gorm:"null"`
+	RevokedAt *time.Time `gorm:"null"`
+}
+
+func (k *APIKey) TableName() string {
+	return "api_keys"
+}
+
+// ScopeList returns the scopes granted by the key.
+func (k *APIKey) ScopeList() []string {
+	return strings.Fields(k.Scopes)
+}
+
+// RoleList returns the roles granted by the key.
+func (k *APIKey) RoleList() []string {
+	return strings.Fields(k.Roles)
+}
@@ -0,0 +1,78 @@
+package models
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type APIKeysRepository struct {
+	db           Connections
+	queryTimeout time.Duration
+}
+
+func NewAPIKeysRepository(db Connections, queryTimeout time.Duration) *APIKeysRepository {
+	return &APIKeysRepository{
+		db:           db,
+		queryTimeout: queryTimeout,
+	}
+}
+
+// conn returns the primary connection. Keys are always read from the primary
+// as well, so a revocation takes effect without waiting for the replicas.
+func (r *APIKeysRepository) conn(ctx context.Context) (*gorm.DB, context.CancelFunc) {
+	return withContext(ctx, r.db.Primary(), r.queryTimeout)
+}
+
+func (r *APIKeysRepository) Create(ctx context.Context, key *APIKey) error {
+	db, cancel := r.conn(ctx)
+	defer cancel()
+
+	return db.Create(key).Error
+}
+
+// GetAll returns every key, revoked and expired ones included, oldest first.
+func (r *APIKeysRepository) GetAll(ctx context.Context) ([]APIKey, error) {
+	db, cancel := r.conn(ctx)
+	defer cancel()
+
+	var keys []APIKey
+	if err := db.Order("id").Find(&keys).Error; err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+// FindActiveByHash returns the key with the given hash unless it was revoked
+// or has expired, in which case it fails with gorm.ErrRecordNotFound.
+func (r *APIKeysRepository) FindActiveByHash(ctx context.Context, hash string) (*APIKey, error) {
+	db, cancel := r.conn(ctx)
+	defer cancel()
+
+	var key APIKey
+	err := db.Where("hash = ? AND revoked_at IS NULL AND (expires_at IS NULL OR expires_at > ?)", hash, time.Now()).
+		First(&key).Error
+	if err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
+
+// Revoke revokes the key with the given prefix.
+// It fails with gorm.ErrRecordNotFound when no such key is active.
+func (r *APIKeysRepository) Revoke(ctx context.Context, prefix string) error {
+	db, cancel := r.conn(ctx)
+	defer cancel()
+
+	result := db.Model(&APIKey{}).
+		Where("prefix = ? AND revoked_at IS NULL", prefix).
+		Update("revoked_at", time.Now())
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
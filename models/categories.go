@@ -1,11 +1,21 @@
 package models
 
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
 // Category represents a product category.
 type Category struct {
-	ID       uint      `gorm:"primaryKey"`
-	Code     string    `gorm:"uniqueIndex;not null"`
-	Name     string    `gorm:"not null"`
-	Products []Product `gorm:"foreignKey:CategoryID"`
+	ID        uint      `gorm:"primaryKey"`
+	Code      string    `gorm:"uniqueIndex;not null"`
+	Name      string    `gorm:"not null"`
+	Products  []Product `gorm:"foreignKey:CategoryID"`
+	Version   uint      `gorm:"not null;default:1"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	DeletedAt gorm.DeletedAt `gorm:"index"`
 }
 
 func (c *Category) TableName() string {
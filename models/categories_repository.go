@@ -1,35 +1,131 @@
 package models
 
 import (
+	"context"
+	"errors"
+	"time"
+
 	"gorm.io/gorm"
 )
 
 type CategoriesRepository struct {
-	db *gorm.DB
+	db           Connections
+	queryTimeout time.Duration
 }
 
-func NewCategoriesRepository(db *gorm.DB) *CategoriesRepository {
+func NewCategoriesRepository(db Connections, queryTimeout time.Duration) *CategoriesRepository {
 	return &CategoriesRepository{
-		db: db,
+		db:           db,
+		queryTimeout: queryTimeout,
 	}
 }
 
-func (r *CategoriesRepository) GetAll() ([]Category, error) {
+// conn returns the primary connection, for writes.
+func (r *CategoriesRepository) conn(ctx context.Context) (*gorm.DB, context.CancelFunc) {
+	return withContext(ctx, r.db.Primary(), r.queryTimeout)
+}
+
+// readConn returns the connection for reads, usually a replica.
+func (r *CategoriesRepository) readConn(ctx context.Context) (*gorm.DB, context.CancelFunc) {
+	return withContext(ctx, reader(ctx, r.db), r.queryTimeout)
+}
+
+func (r *CategoriesRepository) GetAll(ctx context.Context) ([]Category, error) {
+	db, cancel := r.readConn(ctx)
+	defer cancel()
+
 	var categories []Category
-	if err := r.db.Find(&categories).Error; err != nil {
+	if err := db.Find(&categories).Error; err != nil {
 		return nil, err
 	}
 	return categories, nil
 }
 
-func (r *CategoriesRepository) Create(category *Category) error {
-	return r.db.Create(category).Error
+// ErrCategoryDeleted is returned by Create when the code belongs to a
+// soft-deleted category.
+var ErrCategoryDeleted = errors.New("category code belongs to a deleted category")
+
+// Create inserts a category. Codes stay unique across deleted categories, so
+// that restoring one never clashes with a newer category and purging keeps the
+// history of a code unambiguous: a code taken by a live category fails with
+// gorm.ErrDuplicatedKey, and one taken by a deleted category with
+// ErrCategoryDeleted, which is to be restored instead.
+func (r *CategoriesRepository) Create(ctx context.Context, category *Category) error {
+	db, cancel := r.conn(ctx)
+	defer cancel()
+
+	err := db.Create(category).Error
+	if !errors.Is(err, gorm.ErrDuplicatedKey) {
+		return err
+	}
+	var deleted int64
+	if err := db.Unscoped().Model(&Category{}).Where("code = ? AND deleted_at IS NOT NULL", category.Code).Count(&deleted).Error; err != nil {
+		return err
+	}
+	if deleted > 0 {
+		return ErrCategoryDeleted
+	}
+	return err
 }
 
-func (r *CategoriesRepository) FindByCode(code string) (*Category, error) {
+func (r *CategoriesRepository) FindByCode(ctx context.Context, code string) (*Category, error) {
+	db, cancel := r.readConn(ctx)
+	defer cancel()
+
 	var category Category
-	if err := r.db.Where("code = ?", code).First(&category).Error; err != nil {
+	if err := db.Where("code = ?", code).First(&category).Error; err != nil {
 		return nil, err
 	}
 	return &category, nil
 }
+
+// Delete soft-deletes a category. Its products keep their category reference.
+// It fails with ErrVersionConflict when the category changed since the given version.
+func (r *CategoriesRepository) Delete(ctx context.Context, code string, version uint) (*Category, error) {
+	db, cancel := r.conn(ctx)
+	defer cancel()
+
+	category, err := r.FindByCode(WithPrimary(ctx), code)
+	if err != nil {
+		return nil, err
+	}
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := bumpVersion(tx, &Category{}, category.ID, version); err != nil {
+			return err
+		}
+		return tx.Delete(category).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	category.Version++
+	return category, nil
+}
+
+// Restore brings back a soft-deleted category.
+func (r *CategoriesRepository) Restore(ctx context.Context, code string) (*Category, error) {
+	db, cancel := r.conn(ctx)
+	defer cancel()
+
+	var category Category
+	if err := db.Unscoped().Where("code = ? AND deleted_at IS NOT NULL", code).First(&category).Error; err != nil {
+		return nil, err
+	}
+	if err := db.Unscoped().Model(&category).Updates(map[string]any{"deleted_at": nil, "version": gorm.Expr("version + 1")}).Error; err != nil {
+		return nil, err
+	}
+	category.DeletedAt = gorm.DeletedAt{}
+	category.Version++
+	return &category, nil
+}
+
+// PurgeDeleted permanently removes categories deleted before the given instant.
+// Products still referencing a purged category are left without category.
+// It returns the number of purged categories.
+func (r *CategoriesRepository) PurgeDeleted(ctx context.Context, before time.Time) (int64, error) {
+	db, cancel := r.conn(ctx)
+	defer cancel()
+
+	result := db.Unscoped().Where("deleted_at < ?", before).Delete(&Category{})
+	return result.RowsAffected, result.Error
+}
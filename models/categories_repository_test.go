@@ -0,0 +1,32 @@
+package models
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func TestCategoriesRepositoryCreate(t *testing.T) {
+	repo := NewCategoriesRepository(openTestDB(t), 0)
+	ctx := context.Background()
+
+	require.NoError(t, repo.Create(ctx, &Category{Code: "test-shoes", Name: "Shoes"}))
+	require.NoError(t, repo.Create(ctx, &Category{Code: "test-bags", Name: "Bags"}))
+	_, err := repo.Delete(ctx, "test-bags", 1)
+	require.NoError(t, err)
+
+	t.Run("rejects the code of a live category", func(t *testing.T) {
+		err := repo.Create(ctx, &Category{Code: "test-shoes", Name: "Footwear"})
+
+		assert.ErrorIs(t, err, gorm.ErrDuplicatedKey)
+	})
+
+	t.Run("rejects the code of a deleted category", func(t *testing.T) {
+		err := repo.Create(ctx, &Category{Code: "test-bags", Name: "Handbags"})
+
+		assert.ErrorIs(t, err, ErrCategoryDeleted)
+	})
+}
@@ -0,0 +1,25 @@
+package models
+
+import "time"
+
+// IdempotentRequest records a request sent with an Idempotency-Key, so that
+// retries with the same key get the same response. Keys belong to the
+// Principal that sent them; Fingerprint identifies the request the key was
+// first used for. StatusCode is nil until the response is stored, while the
+// first request is still being processed; Headers holds the response headers
+// set by the handler, encoded as JSON.
+type IdempotentRequest struct {
+	ID          uint      `gorm:"primaryKey"`
+	Principal   string    `gorm:"not null"`
+	Key         string    `gorm:"not null"`
+	Fingerprint string    `gorm:"not null"`
+	StatusCode  *int      `gorm:"null"`
+	Headers     string    `gorm:"not null"`
+	Body        []byte    `gorm:"null"`
+	CreatedAt   time.Time `gorm:"not null"`
+	ExpiresAt   time.Time `gorm:"not null"`
+}
+
+func (r *IdempotentRequest) TableName() string {
+	return "idempotent_requests"
+}
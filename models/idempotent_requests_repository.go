@@ -0,0 +1,91 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type IdempotentRequestsRepository struct {
+	db           Connections
+	queryTimeout time.Duration
+}
+
+func NewIdempotentRequestsRepository(db Connections, queryTimeout time.Duration) *IdempotentRequestsRepository {
+	return &IdempotentRequestsRepository{
+		db:           db,
+		queryTimeout: queryTimeout,
+	}
+}
+
+// conn returns the primary connection, which every query uses: a retry must
+// see the request it repeats even before the replicas have caught up.
+func (r *IdempotentRequestsRepository) conn(ctx context.Context) (*gorm.DB, context.CancelFunc) {
+	return withContext(ctx, r.db.Primary(), r.queryTimeout)
+}
+
+// Reserve records req as being processed, unless its principal already used
+// its key. It then returns the existing record instead, and nil otherwise.
+// An expired record is replaced as if the key had never been used.
+func (r *IdempotentRequestsRepository) Reserve(ctx context.Context, req *IdempotentRequest) (*IdempotentRequest, error) {
+	db, cancel := r.conn(ctx)
+	defer cancel()
+
+	err := db.Where("principal = ? AND key = ? AND expires_at <= ?", req.Principal, req.Key, time.Now()).
+		Delete(&IdempotentRequest{}).Error
+	if err != nil {
+		return nil, err
+	}
+
+	// The existing record may be released between the failed insert and the
+	// lookup, in which case the insert is tried once more.
+	for range 2 {
+		result := db.Clauses(clause.OnConflict{DoNothing: true}).Create(req)
+		if result.Error != nil {
+			return nil, result.Error
+		}
+		if result.RowsAffected == 1 {
+			return nil, nil
+		}
+
+		var existing IdempotentRequest
+		err := db.Where("principal = ? AND key = ?", req.Principal, req.Key).First(&existing).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		return &existing, nil
+	}
+	return nil, errors.New("idempotency key kept changing while being reserved")
+}
+
+// Complete stores the response of a reserved request.
+func (r *IdempotentRequestsRepository) Complete(ctx context.Context, req *IdempotentRequest) error {
+	db, cancel := r.conn(ctx)
+	defer cancel()
+
+	return db.Model(req).Select("status_code", "headers", "body").Updates(req).Error
+}
+
+// Release deletes a reserved request, so its key can be used again.
+func (r *IdempotentRequestsRepository) Release(ctx context.Context, req *IdempotentRequest) error {
+	db, cancel := r.conn(ctx)
+	defer cancel()
+
+	return db.Delete(req).Error
+}
+
+// PurgeExpired removes the requests that expired before the given instant.
+// It returns the number of purged requests.
+func (r *IdempotentRequestsRepository) PurgeExpired(ctx context.Context, before time.Time) (int64, error) {
+	db, cancel := r.conn(ctx)
+	defer cancel()
+
+	result := db.Where("expires_at <= ?", before).Delete(&IdempotentRequest{})
+	return result.RowsAffected, result.Error
+}
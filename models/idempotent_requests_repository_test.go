@@ -0,0 +1,98 @@
+package models
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func TestIdempotentRequestsRepositoryReserve(t *testing.T) {
+	conns := openTestDB(t)
+	db := conns.Primary()
+	repo := NewIdempotentRequestsRepository(conns, 0)
+	ctx := context.Background()
+	request := func(key string, expiresAt time.Time) *IdempotentRequest {
+		return &IdempotentRequest{Principal: "apikey:mhc_test", Key: key, Fingerprint: "f", ExpiresAt: expiresAt}
+	}
+	later := time.Now().Add(time.Hour)
+
+	// releaseOnLookup deletes key right before each of the next times
+	// lookups of idempotent requests, as a concurrent Release would.
+	releaseOnLookup := func(t *testing.T, key string, times int) {
+		t.Helper()
+		require.NoError(t, db.Callback().Query().Before("gorm:query").Register("test:release", func(tx *gorm.DB) {
+			if times > 0 && tx.Statement.Table == "idempotent_requests" {
+				times--
+				tx.Session(&gorm.Session{NewDB: true}).Exec("DELETE FROM idempotent_requests WHERE key = ?", key)
+			}
+		}))
+		t.Cleanup(func() { db.Callback().Query().Remove("test:release") })
+	}
+
+	t.Run("reserves an unused key", func(t *testing.T) {
+		existing, err := repo.Reserve(ctx, request("new", later))
+
+		require.NoError(t, err)
+		assert.Nil(t, existing)
+	})
+
+	t.Run("returns the request holding the key", func(t *testing.T) {
+		first := request("taken", later)
+		_, err := repo.Reserve(ctx, first)
+		require.NoError(t, err)
+
+		existing, err := repo.Reserve(ctx, request("taken", later))
+
+		require.NoError(t, err)
+		require.NotNil(t, existing)
+		assert.Equal(t, first.ID, existing.ID)
+		var count int64
+		require.NoError(t, db.Model(&IdempotentRequest{}).Where("key = ?", "taken").Count(&count).Error)
+		assert.Equal(t, int64(1), count)
+	})
+
+	t.Run("replaces an expired request", func(t *testing.T) {
+		expired := request("expired", time.Now().Add(-time.Minute))
+		require.NoError(t, db.Create(expired).Error)
+
+		replacement := request("expired", later)
+		existing, err := repo.Reserve(ctx, replacement)
+
+		require.NoError(t, err)
+		assert.Nil(t, existing)
+		assert.NotEqual(t, expired.ID, replacement.ID)
+		assert.ErrorIs(t, db.First(&IdempotentRequest{}, expired.ID).Error, gorm.ErrRecordNotFound)
+	})
+
+	t.Run("reserves a key released during the lookup", func(t *testing.T) {
+		_, err := repo.Reserve(ctx, request("released", later))
+		require.NoError(t, err)
+		releaseOnLookup(t, "released", 1)
+
+		existing, err := repo.Reserve(ctx, request("released", later))
+
+		require.NoError(t, err)
+		assert.Nil(t, existing)
+	})
+
+	t.Run("gives up on a key that keeps changing", func(t *testing.T) {
+		_, err := repo.Reserve(ctx, request("busy", later))
+		require.NoError(t, err)
+		releaseOnLookup(t, "busy", 2)
+		// Each release is followed by another client reserving the key again.
+		require.NoError(t, db.Callback().Query().After("gorm:query").Register("test:reserve", func(tx *gorm.DB) {
+			if tx.Statement.Table == "idempotent_requests" {
+				tx.Session(&gorm.Session{NewDB: true}).Create(request("busy", later))
+			}
+		}))
+		t.Cleanup(func() { db.Callback().Query().Remove("test:reserve") })
+
+		_, err = repo.Reserve(ctx, request("busy", later))
+
+		assert.ErrorContains(t, err, "kept changing")
+	})
+}
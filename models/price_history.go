@@ -0,0 +1,36 @@
+package models
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// PriceChange represents an audit entry for a price update on a product or one of its variants.
+// VariantID and SKU are empty for product level changes. A null price on a variant
+// means it inherits the product price.
+type PriceChange struct {
+	ID        uint                `gorm:"primaryKey"`
+	ProductID uint                `gorm:"not null"`
+	VariantID *uint               `gorm:"null"`
+	SKU       string              `gorm:"null"`
+	OldPrice  decimal.NullDecimal `gorm:"type:decimal(10,2);null"`
+	NewPrice  decimal.NullDecimal `gorm:"type:decimal(10,2);null"`
+	ChangedBy string              `gorm:"not null"`
+	ChangedAt time.Time           `gorm:"not null"`
+}
+
+func (c *PriceChange) TableName() string {
+	return "price_history"
+}
+
+// PriceUpdate describes the price changes to apply to a product and its variants.
+// Price is left untouched when nil. VariantPrices is keyed by SKU; an invalid
+// NullDecimal clears the variant price so it inherits the product price again.
+// Version is the product version the caller last read.
+type PriceUpdate struct {
+	Version       uint
+	Price         *decimal.Decimal
+	VariantPrices map[string]decimal.NullDecimal
+	ChangedBy     string
+}
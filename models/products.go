@@ -1,20 +1,101 @@
 package models
 
 import (
+	"slices"
+	"time"
+
 	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// ProductStatus represents the lifecycle stage of a product.
+type ProductStatus string
+
+const (
+	StatusDraft     ProductStatus = "draft"
+	StatusPublished ProductStatus = "published"
+	StatusArchived  ProductStatus = "archived"
 )
 
+// statusTransitions lists the statuses each status may move to.
+var statusTransitions = map[ProductStatus][]ProductStatus{
+	StatusDraft:     {StatusPublished, StatusArchived},
+	StatusPublished: {StatusDraft, StatusArchived},
+	StatusArchived:  {StatusDraft},
+}
+
+// Valid reports whether s is a known product status.
+func (s ProductStatus) Valid() bool {
+	_, ok := statusTransitions[s]
+	return ok
+}
+
+// CanTransitionTo reports whether a product may move from s to next.
+func (s ProductStatus) CanTransitionTo(next ProductStatus) bool {
+	return slices.Contains(statusTransitions[s], next)
+}
+
 // Product represents a product in the catalog.
 // It includes a unique code, a price, and an optional category.
+// Only published products are visible to storefront clients; a draft with a
+// PublishAt in the past counts as published, and any product with an
+// UnpublishAt in the past counts as archived. Deleted products are kept until purged.
 type Product struct {
-	ID         uint            `gorm:"primaryKey"`
-	Code       string          `gorm:"uniqueIndex;not null"`
-	Price      decimal.Decimal `gorm:"type:decimal(10,2);not null"`
-	CategoryID uint
-	Category   *Category `gorm:"foreignKey:CategoryID"`
-	Variants   []Variant `gorm:"foreignKey:ProductID"`
+	ID          uint            `gorm:"primaryKey"`
+	Code        string          `gorm:"uniqueIndex;not null"`
+	Price       decimal.Decimal `gorm:"type:decimal(10,2);not null"`
+	Status      ProductStatus   `gorm:"not null;default:draft"`
+	PublishAt   *time.Time
+	UnpublishAt *time.Time
+	CategoryID  uint
+	Version     uint      `gorm:"not null;default:1"`
+	Category    *Category `gorm:"foreignKey:CategoryID"`
+	Variants    []Variant `gorm:"foreignKey:ProductID"`
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	DeletedAt   gorm.DeletedAt `gorm:"index"`
 }
 
 func (p *Product) TableName() string {
 	return "products"
 }
+
+// EffectiveStatus returns the status of the product at the given instant,
+// taking scheduled publish and unpublish timestamps into account.
+func (p *Product) EffectiveStatus(at time.Time) ProductStatus {
+	if p.Status == StatusArchived {
+		return StatusArchived
+	}
+	if p.UnpublishAt != nil && !at.Before(*p.UnpublishAt) {
+		return StatusArchived
+	}
+	if p.Status == StatusDraft && p.PublishAt != nil && !at.Before(*p.PublishAt) {
+		return StatusPublished
+	}
+	return p.Status
+}
+
+// ProductFilter narrows down a product listing.
+// Nil fields and an empty Status match every product.
+type ProductFilter struct {
+	CategoryID    *uint
+	PriceLessThan *decimal.Decimal
+	Status        ProductStatus
+	// UpdatedSince matches products changed at or after the given instant,
+	// including changes made only to one of their variants or their category,
+	// and products whose publishing schedule took effect since.
+	UpdatedSince *time.Time
+	// WithDeleted includes soft-deleted products, so an incremental sync
+	// learns about deletions.
+	WithDeleted bool
+}
+
+// StatusUpdate describes a lifecycle change on a product.
+// Status is left untouched when empty; the schedule timestamps are always replaced.
+// Version is the product version the caller last read.
+type StatusUpdate struct {
+	Version     uint
+	Status      ProductStatus
+	PublishAt   *time.Time
+	UnpublishAt *time.Time
+}
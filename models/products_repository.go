@@ -1,24 +1,49 @@
 package models
 
 import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"time"
+
 	"github.com/shopspring/decimal"
 	"gorm.io/gorm"
 )
 
 type ProductsRepository struct {
-	db *gorm.DB
+	db           Connections
+	queryTimeout time.Duration
 }
 
-func NewProductsRepository(db *gorm.DB) *ProductsRepository {
+// NewProductsRepository returns a repository whose calls are each bounded by
+// queryTimeout on top of the caller's context. A zero timeout disables the bound.
+func NewProductsRepository(db Connections, queryTimeout time.Duration) *ProductsRepository {
 	return &ProductsRepository{
-		db: db,
+		db:           db,
+		queryTimeout: queryTimeout,
 	}
 }
 
+// conn returns the primary connection, for writes.
+func (r *ProductsRepository) conn(ctx context.Context) (*gorm.DB, context.CancelFunc) {
+	return withContext(ctx, r.db.Primary(), r.queryTimeout)
+}
+
+// readConn returns the connection for reads, usually a replica.
+func (r *ProductsRepository) readConn(ctx context.Context) (*gorm.DB, context.CancelFunc) {
+	return withContext(ctx, reader(ctx, r.db), r.queryTimeout)
+}
+
 // GetAllProducts returns all products with variants preloaded.
-func (r *ProductsRepository) GetAllProducts() ([]Product, error) {
+func (r *ProductsRepository) GetAllProducts(ctx context.Context) ([]Product, error) {
+	db, cancel := r.readConn(ctx)
+	defer cancel()
+
 	var products []Product
-	if err := r.db.Preload("Variants").Preload("Category").Find(&products).Error; err != nil {
+	if err := db.Preload("Variants").Preload("Category").Find(&products).Error; err != nil {
 		return nil, err
 	}
 	return products, nil
@@ -27,9 +52,11 @@ func (r *ProductsRepository) GetAllProducts() ([]Product, error) {
 // GetProductsByFilter returns products with pagination and optional filters.
 // offset: number of products to skip (default 0)
 // limit: maximum number of products to return (default 10, max 100)
-// categoryID: optional category filter
-// priceLessThan: optional price filter
-func (r *ProductsRepository) GetProductsByFilter(offset, limit int, categoryID *uint, priceLessThan *decimal.Decimal) ([]Product, int64, error) {
+// filter: optional category, price, status and last update filters
+func (r *ProductsRepository) GetProductsByFilter(ctx context.Context, offset, limit int, filter ProductFilter) ([]Product, int64, error) {
+	db, cancel := r.readConn(ctx)
+	defer cancel()
+
 	// Validate and normalize limit
 	if limit <= 0 {
 		limit = 10
@@ -41,14 +68,23 @@ func (r *ProductsRepository) GetProductsByFilter(offset, limit int, categoryID *
 	var products []Product
 	var total int64
 
-	query := r.db.Preload("Variants").Preload("Category")
+	if filter.WithDeleted {
+		db = db.Unscoped()
+	}
+	query := db.Preload("Variants").Preload("Category")
 
 	// Apply filters
-	if categoryID != nil {
-		query = query.Where("category_id = ?", *categoryID)
+	if filter.CategoryID != nil {
+		query = query.Where("category_id = ?", *filter.CategoryID)
 	}
-	if priceLessThan != nil {
-		query = query.Where("price < ?", priceLessThan)
+	if filter.PriceLessThan != nil {
+		query = query.Where("price < ?", filter.PriceLessThan)
+	}
+	if filter.Status != "" {
+		query = query.Scopes(withStatus(filter.Status, time.Now()))
+	}
+	if filter.UpdatedSince != nil {
+		query = query.Scopes(changedSince(*filter.UpdatedSince, time.Now()))
 	}
 
 	// Get total count
@@ -57,7 +93,7 @@ func (r *ProductsRepository) GetProductsByFilter(offset, limit int, categoryID *
 	}
 
 	// Get paginated results
-	if err := query.Offset(offset).Limit(limit).Find(&products).Error; err != nil {
+	if err := query.Order("id").Offset(offset).Limit(limit).Find(&products).Error; err != nil {
 		return nil, 0, err
 	}
 
@@ -65,10 +101,364 @@ func (r *ProductsRepository) GetProductsByFilter(offset, limit int, categoryID *
 }
 
 // GetProductByCode returns a single product by its code with variants preloaded.
-func (r *ProductsRepository) GetProductByCode(code string) (*Product, error) {
+// An empty status matches products in any status.
+func (r *ProductsRepository) GetProductByCode(ctx context.Context, code string, status ProductStatus) (*Product, error) {
+	db, cancel := r.readConn(ctx)
+	defer cancel()
+
+	var product Product
+	query := db.Preload("Variants").Preload("Category").Where("code = ?", code)
+	if status != "" {
+		query = query.Scopes(withStatus(status, time.Now()))
+	}
+	if err := query.First(&product).Error; err != nil {
+		return nil, err
+	}
+	return &product, nil
+}
+
+// NextScheduled returns the earliest publish_at or unpublish_at of any product
+// after the given instant, when the status of a product changes without a
+// write, or the zero time when no such change is scheduled.
+func (r *ProductsRepository) NextScheduled(ctx context.Context, after time.Time) (time.Time, error) {
+	db, cancel := r.readConn(ctx)
+	defer cancel()
+
+	var next sql.NullTime
+	err := db.Raw("SELECT LEAST("+
+		"(SELECT MIN(publish_at) FROM products WHERE publish_at > @after), "+
+		"(SELECT MIN(unpublish_at) FROM products WHERE unpublish_at > @after))",
+		sql.Named("after", after)).Row().Scan(&next)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return next.Time, nil
+}
+
+// ErrInvalidTransition is returned when a status change is not allowed.
+var ErrInvalidTransition = errors.New("invalid status transition")
+
+// UpdateStatus changes the lifecycle status and publishing schedule of a product.
+// It fails with ErrVersionConflict when the product changed since update.Version.
+func (r *ProductsRepository) UpdateStatus(ctx context.Context, code string, update StatusUpdate) (*Product, error) {
+	db, cancel := r.conn(ctx)
+	defer cancel()
+
+	var product Product
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Preload("Variants").Preload("Category").Where("code = ?", code).First(&product).Error; err != nil {
+			return err
+		}
+		if err := bumpVersion(tx, &Product{}, product.ID, update.Version); err != nil {
+			return err
+		}
+		product.Version++
+
+		if update.Status != "" && update.Status != product.Status {
+			if !product.Status.CanTransitionTo(update.Status) {
+				return fmt.Errorf("%w: %s to %s", ErrInvalidTransition, product.Status, update.Status)
+			}
+			product.Status = update.Status
+		}
+		product.PublishAt = update.PublishAt
+		product.UnpublishAt = update.UnpublishAt
+
+		return tx.Model(&product).Select("status", "publish_at", "unpublish_at").Updates(&product).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &product, nil
+}
+
+// changedSince restricts a product query to the products that changed between
+// since and now: the product itself, one of its variants, deleted ones
+// included, or its category, or whose publish_at or unpublish_at passed.
+func changedSince(since, now time.Time) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("(products.updated_at >= @since"+
+			" OR EXISTS (SELECT 1 FROM product_variants v WHERE v.product_id = products.id AND v.updated_at >= @since)"+
+			" OR EXISTS (SELECT 1 FROM categories c WHERE c.id = products.category_id AND c.updated_at >= @since)"+
+			" OR products.publish_at BETWEEN @since AND @now"+
+			" OR products.unpublish_at BETWEEN @since AND @now)",
+			sql.Named("since", since), sql.Named("now", now))
+	}
+}
+
+// withStatus restricts a product query to the products whose effective status
+// at the given instant matches, mirroring Product.EffectiveStatus.
+func withStatus(status ProductStatus, at time.Time) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		switch status {
+		case StatusPublished:
+			return db.Where("(status = ? OR (status = ? AND publish_at <= ?)) AND (unpublish_at IS NULL OR unpublish_at > ?)",
+				StatusPublished, StatusDraft, at, at)
+		case StatusDraft:
+			return db.Where("status = ? AND (publish_at IS NULL OR publish_at > ?) AND (unpublish_at IS NULL OR unpublish_at > ?)",
+				StatusDraft, at, at)
+		case StatusArchived:
+			return db.Where("(status = ? OR unpublish_at <= ?)", StatusArchived, at)
+		}
+		return db.Where("status = ?", status)
+	}
+}
+
+// ErrVariantNotFound is returned when a price update references an unknown SKU.
+var ErrVariantNotFound = errors.New("variant not found")
+
+// UpdatePrices applies a price update to a product and its variants.
+// Every effective change is recorded in the price history within the same transaction.
+// It fails with ErrVersionConflict when the product changed since update.Version.
+func (r *ProductsRepository) UpdatePrices(ctx context.Context, code string, update PriceUpdate) (*Product, error) {
+	db, cancel := r.conn(ctx)
+	defer cancel()
+
 	var product Product
-	if err := r.db.Preload("Variants").Preload("Category").Where("code = ?", code).First(&product).Error; err != nil {
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Preload("Variants").Preload("Category").Where("code = ?", code).First(&product).Error; err != nil {
+			return err
+		}
+		if err := bumpVersion(tx, &Product{}, product.ID, update.Version); err != nil {
+			return err
+		}
+		product.Version++
+
+		changedAt := time.Now().UTC()
+
+		if update.Price != nil && !update.Price.Equal(product.Price) {
+			if err := tx.Model(&product).Update("price", *update.Price).Error; err != nil {
+				return err
+			}
+			change := PriceChange{
+				ProductID: product.ID,
+				OldPrice:  decimal.NewNullDecimal(product.Price),
+				NewPrice:  decimal.NewNullDecimal(*update.Price),
+				ChangedBy: update.ChangedBy,
+				ChangedAt: changedAt,
+			}
+			if err := tx.Create(&change).Error; err != nil {
+				return err
+			}
+			product.Price = *update.Price
+		}
+
+		for _, sku := range slices.Sorted(maps.Keys(update.VariantPrices)) {
+			price := update.VariantPrices[sku]
+			i := slices.IndexFunc(product.Variants, func(v Variant) bool { return v.SKU == sku })
+			if i < 0 {
+				return fmt.Errorf("%w: %s", ErrVariantNotFound, sku)
+			}
+			variant := &product.Variants[i]
+
+			old := variantPrice(*variant)
+			if old.Valid == price.Valid && old.Decimal.Equal(price.Decimal) {
+				continue
+			}
+
+			if err := tx.Model(variant).Updates(map[string]any{"price": price, "version": gorm.Expr("version + 1")}).Error; err != nil {
+				return err
+			}
+			variant.Version++
+			change := PriceChange{
+				ProductID: product.ID,
+				VariantID: &variant.ID,
+				SKU:       variant.SKU,
+				OldPrice:  old,
+				NewPrice:  price,
+				ChangedBy: update.ChangedBy,
+				ChangedAt: changedAt,
+			}
+			if err := tx.Create(&change).Error; err != nil {
+				return err
+			}
+			variant.Price = price.Decimal
+		}
+
+		return nil
+	})
+	if err != nil {
 		return nil, err
 	}
 	return &product, nil
 }
+
+// GetPriceHistory returns the price changes recorded for a product, newest first.
+// Unless status is empty, only products with that effective status are found.
+// from and to optionally bound the change timestamp (inclusive).
+func (r *ProductsRepository) GetPriceHistory(ctx context.Context, code string, status ProductStatus, from, to *time.Time, offset, limit int) ([]PriceChange, int64, error) {
+	db, cancel := r.readConn(ctx)
+	defer cancel()
+
+	// Validate and normalize limit
+	if limit <= 0 {
+		limit = 10
+	}
+	if limit > 100 {
+		limit = 100
+	}
+
+	var product Product
+	query := db.Select("id").Where("code = ?", code)
+	if status != "" {
+		query = query.Scopes(withStatus(status, time.Now()))
+	}
+	if err := query.First(&product).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var changes []PriceChange
+	var total int64
+
+	query = db.Model(&PriceChange{}).Where("product_id = ?", product.ID)
+	if from != nil {
+		query = query.Where("changed_at >= ?", *from)
+	}
+	if to != nil {
+		query = query.Where("changed_at <= ?", *to)
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	if err := query.Order("changed_at DESC, id DESC").Offset(offset).Limit(limit).Find(&changes).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return changes, total, nil
+}
+
+// variantPrice returns the stored variant price, treating zero as "inherits the product price".
+func variantPrice(v Variant) decimal.NullDecimal {
+	if v.Price.IsZero() {
+		return decimal.NullDecimal{}
+	}
+	return decimal.NewNullDecimal(v.Price)
+}
+
+// Delete soft-deletes a product together with its variants.
+// It fails with ErrVersionConflict when the product changed since the given version.
+func (r *ProductsRepository) Delete(ctx context.Context, code string, version uint) (*Product, error) {
+	db, cancel := r.conn(ctx)
+	defer cancel()
+
+	var product Product
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Preload("Variants").Preload("Category").Where("code = ?", code).First(&product).Error; err != nil {
+			return err
+		}
+		if err := bumpVersion(tx, &Product{}, product.ID, version); err != nil {
+			return err
+		}
+		product.Version++
+
+		// Variants share the product deletion time, so a restore brings back
+		// exactly the variants that were deleted along with the product.
+		deletedAt := time.Now().UTC().Truncate(time.Microsecond)
+		if err := tx.Model(&Variant{}).Where("product_id = ?", product.ID).Update("deleted_at", deletedAt).Error; err != nil {
+			return err
+		}
+		return tx.Model(&product).Update("deleted_at", deletedAt).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &product, nil
+}
+
+// Restore brings back a soft-deleted product and the variants deleted along with it.
+func (r *ProductsRepository) Restore(ctx context.Context, code string) (*Product, error) {
+	db, cancel := r.conn(ctx)
+	defer cancel()
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var product Product
+		if err := tx.Unscoped().Where("code = ? AND deleted_at IS NOT NULL", code).First(&product).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Unscoped().Model(&Variant{}).
+			Where("product_id = ? AND deleted_at = ?", product.ID, product.DeletedAt).
+			Updates(map[string]any{"deleted_at": nil, "version": gorm.Expr("version + 1")}).Error; err != nil {
+			return err
+		}
+		return tx.Unscoped().Model(&product).Updates(map[string]any{"deleted_at": nil, "version": gorm.Expr("version + 1")}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return r.GetProductByCode(WithPrimary(ctx), code, "")
+}
+
+// DeleteVariant soft-deletes a single variant of a product.
+// It fails with ErrVersionConflict when the variant changed since the given version.
+func (r *ProductsRepository) DeleteVariant(ctx context.Context, code, sku string, version uint) (*Variant, error) {
+	db, cancel := r.conn(ctx)
+	defer cancel()
+
+	variant, err := findVariant(db, db, code, sku)
+	if err != nil {
+		return nil, err
+	}
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := bumpVersion(tx, &Variant{}, variant.ID, version); err != nil {
+			return err
+		}
+		return tx.Delete(variant).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	variant.Version++
+	return variant, nil
+}
+
+// RestoreVariant brings back a soft-deleted variant of a product.
+func (r *ProductsRepository) RestoreVariant(ctx context.Context, code, sku string) (*Variant, error) {
+	db, cancel := r.conn(ctx)
+	defer cancel()
+
+	variant, err := findVariant(db, db.Unscoped().Where("product_variants.deleted_at IS NOT NULL"), code, sku)
+	if err != nil {
+		return nil, err
+	}
+	if err := db.Unscoped().Model(variant).Updates(map[string]any{"deleted_at": nil, "version": gorm.Expr("version + 1")}).Error; err != nil {
+		return nil, err
+	}
+	variant.DeletedAt = gorm.DeletedAt{}
+	variant.Version++
+	return variant, nil
+}
+
+// PurgeDeleted permanently removes products deleted before the given instant,
+// along with their variants and price history. It returns the number of purged products.
+func (r *ProductsRepository) PurgeDeleted(ctx context.Context, before time.Time) (int64, error) {
+	db, cancel := r.conn(ctx)
+	defer cancel()
+
+	var purged int64
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Where("deleted_at < ?", before).Delete(&Variant{}).Error; err != nil {
+			return err
+		}
+		result := tx.Unscoped().Where("deleted_at < ?", before).Delete(&Product{})
+		purged = result.RowsAffected
+		return result.Error
+	})
+	return purged, err
+}
+
+// findVariant looks up a variant by SKU within the live product identified by code.
+// The variant is searched with the variants query, so callers can widen its scope.
+func findVariant(db, variants *gorm.DB, code, sku string) (*Variant, error) {
+	var product Product
+	if err := db.Select("id").Where("code = ?", code).First(&product).Error; err != nil {
+		return nil, err
+	}
+
+	var variant Variant
+	if err := variants.Where("product_id = ? AND sku = ?", product.ID, sku).First(&variant).Error; err != nil {
+		return nil, err
+	}
+	return &variant, nil
+}
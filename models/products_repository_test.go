@@ -0,0 +1,104 @@
+package models
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProductsRepositoryUpdatedSince(t *testing.T) {
+	conns := openTestDB(t)
+	db := conns.Primary()
+	repo := NewProductsRepository(conns, 0)
+	ctx := context.Background()
+
+	shoes := &Category{Code: "test-shoes", Name: "Shoes"}
+	bags := &Category{Code: "test-bags", Name: "Bags"}
+	require.NoError(t, db.Create(shoes).Error)
+	require.NoError(t, db.Create(bags).Error)
+	product := func(code string, category *Category) *Product {
+		p := &Product{Code: code, Price: decimal.NewFromInt(10), Status: StatusPublished, CategoryID: category.ID,
+			Variants: []Variant{{Name: code + " A", SKU: code + "-A"}}}
+		require.NoError(t, db.Create(p).Error)
+		return p
+	}
+	unchanged := product("UNCHANGED", bags)
+	variantChanged := product("VARIANT", bags)
+	categoryChanged := product("CATEGORY", shoes)
+	deleted := product("DELETED", bags)
+	scheduled := product("SCHEDULED", bags)
+	require.NoError(t, db.Model(scheduled).Update("status", StatusDraft).Error)
+
+	since := time.Now()
+	time.Sleep(10 * time.Millisecond)
+
+	require.NoError(t, db.Model(&variantChanged.Variants[0]).Update("name", "renamed").Error)
+	require.NoError(t, db.Model(shoes).Update("name", "Footwear").Error)
+	var current Product
+	require.NoError(t, db.First(&current, deleted.ID).Error)
+	_, err := repo.Delete(ctx, deleted.Code, current.Version)
+	require.NoError(t, err)
+	// A schedule set earlier takes effect after since, which leaves
+	// updated_at alone.
+	require.NoError(t, db.Exec("ALTER TABLE products DISABLE TRIGGER products_set_updated_at").Error)
+	require.NoError(t, db.Exec("UPDATE products SET publish_at = ? WHERE id = ?", since.Add(5*time.Millisecond), scheduled.ID).Error)
+	require.NoError(t, db.Exec("ALTER TABLE products ENABLE TRIGGER products_set_updated_at").Error)
+
+	codes := func(filter ProductFilter) []string {
+		filter.UpdatedSince = &since
+		products, total, err := repo.GetProductsByFilter(ctx, 0, 100, filter)
+		require.NoError(t, err)
+		assert.Equal(t, int64(len(products)), total)
+		var codes []string
+		for _, p := range products {
+			codes = append(codes, p.Code)
+		}
+		return codes
+	}
+
+	t.Run("matches changed variants, categories and schedules", func(t *testing.T) {
+		got := codes(ProductFilter{})
+
+		assert.ElementsMatch(t, []string{variantChanged.Code, categoryChanged.Code, scheduled.Code}, got)
+		assert.NotContains(t, got, unchanged.Code)
+	})
+
+	t.Run("includes deleted products on request", func(t *testing.T) {
+		got := codes(ProductFilter{WithDeleted: true})
+
+		assert.ElementsMatch(t, []string{variantChanged.Code, categoryChanged.Code, scheduled.Code, deleted.Code}, got)
+	})
+}
+
+func TestProductsRepositoryNextScheduled(t *testing.T) {
+	conns := openTestDB(t)
+	db := conns.Primary()
+	repo := NewProductsRepository(conns, 0)
+	ctx := context.Background()
+
+	now := time.Now().UTC().Truncate(time.Second)
+	next, err := repo.NextScheduled(ctx, now)
+	require.NoError(t, err)
+	assert.True(t, next.IsZero())
+
+	category := &Category{Code: "test-shoes", Name: "Shoes"}
+	require.NoError(t, db.Create(category).Error)
+	publishAt, unpublishAt, past := now.Add(2*time.Hour), now.Add(time.Hour), now.Add(-time.Hour)
+	for i, p := range []*Product{
+		{Code: "LATER", Status: StatusDraft, PublishAt: &publishAt},
+		{Code: "SOONER", Status: StatusPublished, UnpublishAt: &unpublishAt},
+		{Code: "PAST", Status: StatusDraft, PublishAt: &past},
+	} {
+		p.Price, p.CategoryID = decimal.NewFromInt(int64(i+1)), category.ID
+		require.NoError(t, db.Create(p).Error)
+	}
+
+	next, err = repo.NextScheduled(ctx, now)
+
+	require.NoError(t, err)
+	assert.True(t, unpublishAt.Equal(next), "got %s", next)
+}
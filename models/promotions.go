@@ -0,0 +1,43 @@
+package models
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// PromotionType defines how a promotion value is applied to a price.
+type PromotionType string
+
+const (
+	PromotionPercentage PromotionType = "percentage"
+	PromotionFixed      PromotionType = "fixed"
+)
+
+// PromotionScope defines what kind of catalog entry a promotion targets.
+type PromotionScope string
+
+const (
+	PromotionScopeCategory PromotionScope = "category"
+	PromotionScopeProduct  PromotionScope = "product"
+	PromotionScopeSKU      PromotionScope = "sku"
+)
+
+// Promotion represents a scheduled discount rule.
+// It applies a percentage or fixed discount to a category, a product or a single SKU,
+// identified by its code, between StartsAt (inclusive) and EndsAt (exclusive).
+type Promotion struct {
+	ID       uint            `gorm:"primaryKey"`
+	Name     string          `gorm:"not null"`
+	Type     PromotionType   `gorm:"not null"`
+	Value    decimal.Decimal `gorm:"type:decimal(10,2);not null"`
+	Scope    PromotionScope  `gorm:"not null"`
+	Target   string          `gorm:"not null"`
+	StartsAt time.Time       `gorm:"not null"`
+	EndsAt   time.Time       `gorm:"not null"`
+	Version  uint            `gorm:"not null;default:1"`
+}
+
+func (p *Promotion) TableName() string {
+	return "promotions"
+}
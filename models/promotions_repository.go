@@ -0,0 +1,102 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type PromotionsRepository struct {
+	db           Connections
+	queryTimeout time.Duration
+}
+
+func NewPromotionsRepository(db Connections, queryTimeout time.Duration) *PromotionsRepository {
+	return &PromotionsRepository{
+		db:           db,
+		queryTimeout: queryTimeout,
+	}
+}
+
+// conn returns the primary connection, for writes.
+func (r *PromotionsRepository) conn(ctx context.Context) (*gorm.DB, context.CancelFunc) {
+	return withContext(ctx, r.db.Primary(), r.queryTimeout)
+}
+
+// readConn returns the connection for reads, usually a replica.
+func (r *PromotionsRepository) readConn(ctx context.Context) (*gorm.DB, context.CancelFunc) {
+	return withContext(ctx, reader(ctx, r.db), r.queryTimeout)
+}
+
+// GetAll returns all promotions ordered by start date.
+func (r *PromotionsRepository) GetAll(ctx context.Context) ([]Promotion, error) {
+	db, cancel := r.readConn(ctx)
+	defer cancel()
+
+	var promotions []Promotion
+	if err := db.Order("starts_at, id").Find(&promotions).Error; err != nil {
+		return nil, err
+	}
+	return promotions, nil
+}
+
+// GetActive returns the promotions running at the given instant.
+func (r *PromotionsRepository) GetActive(ctx context.Context, at time.Time) ([]Promotion, error) {
+	db, cancel := r.readConn(ctx)
+	defer cancel()
+
+	var promotions []Promotion
+	if err := db.Where("starts_at <= ? AND ends_at > ?", at, at).Order("id").Find(&promotions).Error; err != nil {
+		return nil, err
+	}
+	return promotions, nil
+}
+
+// LastEnded returns the latest end of the promotions ended by the given
+// instant, or the zero time when none has ended.
+func (r *PromotionsRepository) LastEnded(ctx context.Context, at time.Time) (time.Time, error) {
+	db, cancel := r.readConn(ctx)
+	defer cancel()
+
+	var ended sql.NullTime
+	if err := db.Model(&Promotion{}).Where("ends_at <= ?", at).Select("MAX(ends_at)").Row().Scan(&ended); err != nil {
+		return time.Time{}, err
+	}
+	return ended.Time, nil
+}
+
+func (r *PromotionsRepository) Create(ctx context.Context, promotion *Promotion) error {
+	db, cancel := r.conn(ctx)
+	defer cancel()
+
+	return db.Create(promotion).Error
+}
+
+func (r *PromotionsRepository) FindByID(ctx context.Context, id uint) (*Promotion, error) {
+	db, cancel := r.readConn(ctx)
+	defer cancel()
+
+	var promotion Promotion
+	if err := db.First(&promotion, id).Error; err != nil {
+		return nil, err
+	}
+	return &promotion, nil
+}
+
+// Delete removes a promotion.
+// It fails with ErrVersionConflict when the promotion changed since the given version.
+func (r *PromotionsRepository) Delete(ctx context.Context, id uint, version uint) error {
+	db, cancel := r.conn(ctx)
+	defer cancel()
+
+	result := db.Where("version = ?", version).Delete(&Promotion{}, id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrVersionConflict
+	}
+	return nil
+}
@@ -0,0 +1,42 @@
+package models
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Connections routes repository queries. Writes, and reads that must observe
+// them, use the primary; other reads may be served by a replica.
+type Connections interface {
+	Primary() *gorm.DB
+	Replica() *gorm.DB
+}
+
+type primaryKey struct{}
+
+// WithPrimary marks ctx so that repository reads go to the primary. Use it for
+// reads that must see a preceding write or that decide a subsequent one.
+func WithPrimary(ctx context.Context) context.Context {
+	return context.WithValue(ctx, primaryKey{}, true)
+}
+
+// reader returns the connection a read running under ctx should use.
+func reader(ctx context.Context, c Connections) *gorm.DB {
+	if primary, _ := ctx.Value(primaryKey{}).(bool); primary {
+		return c.Primary()
+	}
+	return c.Replica()
+}
+
+// withContext binds db to ctx so queries are cancelled along with the caller.
+// A positive timeout additionally bounds the whole repository call; the returned
+// cancel function must be called once the call is done.
+func withContext(ctx context.Context, db *gorm.DB, timeout time.Duration) (*gorm.DB, context.CancelFunc) {
+	cancel := context.CancelFunc(func() {})
+	if timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+	}
+	return db.WithContext(ctx), cancel
+}
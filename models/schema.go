@@ -0,0 +1,46 @@
+package models
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"gorm.io/gorm/schema"
+)
+
+// schemaModels are the models whose tables the repositories query.
+var schemaModels = []any{&Product{}, &Variant{}, &Category{}, &Promotion{}, &PriceChange{}, &APIKey{}, &IdempotentRequest{}}
+
+// CheckSchema reports the first table or column the models map that is missing
+// from the primary, meaning the SQL migrations have not all been applied.
+func CheckSchema(ctx context.Context, db Connections) error {
+	primary := db.Primary().WithContext(ctx)
+
+	var columns []struct {
+		TableName  string
+		ColumnName string
+	}
+	err := primary.Raw("SELECT table_name, column_name FROM information_schema.columns WHERE table_schema = current_schema()").
+		Scan(&columns).Error
+	if err != nil {
+		return err
+	}
+	existing := make(map[string]bool, len(columns))
+	for _, c := range columns {
+		existing[c.TableName+"."+c.ColumnName] = true
+	}
+
+	cache := &sync.Map{}
+	for _, model := range schemaModels {
+		s, err := schema.Parse(model, cache, primary.NamingStrategy)
+		if err != nil {
+			return err
+		}
+		for _, column := range s.DBNames {
+			if !existing[s.Table+"."+column] {
+				return fmt.Errorf("column %s.%s is missing", s.Table, column)
+			}
+		}
+	}
+	return nil
+}
@@ -1,7 +1,10 @@
 package models
 
 import (
+	"time"
+
 	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
 )
 
 // Variant represents a product variant in the catalog.
@@ -13,6 +16,10 @@ type Variant struct {
 	Name      string          `gorm:"not null"`
 	SKU       string          `gorm:"uniqueIndex;not null"`
 	Price     decimal.Decimal `gorm:"type:decimal(10,2);null"`
+	Version   uint            `gorm:"not null;default:1"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	DeletedAt gorm.DeletedAt `gorm:"index"`
 }
 
 func (v *Variant) TableName() string {
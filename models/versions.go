@@ -0,0 +1,26 @@
+package models
+
+import (
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+// ErrVersionConflict is returned when a row changed since the caller read it.
+var ErrVersionConflict = errors.New("resource has been modified since it was read")
+
+// bumpVersion increments the version of a row if it still matches the expected one.
+// The update also locks the row until the surrounding transaction ends,
+// so concurrent writers are serialized and the loser gets ErrVersionConflict.
+func bumpVersion(tx *gorm.DB, model any, id uint, expected uint) error {
+	result := tx.Model(model).
+		Where("id = ? AND version = ?", id, expected).
+		Update("version", gorm.Expr("version + 1"))
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrVersionConflict
+	}
+	return nil
+}
@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS promotions (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(256) NOT NULL,
+    type VARCHAR(16) NOT NULL CHECK (type IN ('percentage', 'fixed')),
+    value DECIMAL(10, 2) NOT NULL CHECK (value > 0),
+    scope VARCHAR(16) NOT NULL CHECK (scope IN ('category', 'product', 'sku')),
+    target VARCHAR(32) NOT NULL,
+    starts_at TIMESTAMP NOT NULL,
+    ends_at TIMESTAMP NOT NULL CHECK (ends_at > starts_at),
+    created_at TIMESTAMP DEFAULT NOW(),
+    updated_at TIMESTAMP DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS promotions_window_idx ON promotions (starts_at, ends_at);
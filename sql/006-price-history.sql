@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS price_history (
+    id SERIAL PRIMARY KEY,
+    product_id INTEGER NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    variant_id INTEGER NULL REFERENCES product_variants(id) ON DELETE CASCADE,
+    sku VARCHAR(32) NULL,
+    old_price DECIMAL(10, 2) NULL,
+    new_price DECIMAL(10, 2) NULL,
+    changed_by VARCHAR(256) NOT NULL,
+    changed_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS price_history_product_changed_at_idx ON price_history (product_id, changed_at);
@@ -0,0 +1,10 @@
+-- Existing products were already public, so they start out published.
+ALTER TABLE products
+ADD COLUMN status VARCHAR(16) NOT NULL DEFAULT 'published' CHECK (status IN ('draft', 'published', 'archived')),
+ADD COLUMN publish_at TIMESTAMP NULL,
+ADD COLUMN unpublish_at TIMESTAMP NULL;
+
+-- New products are hidden until they are published.
+ALTER TABLE products ALTER COLUMN status SET DEFAULT 'draft';
+
+CREATE INDEX IF NOT EXISTS products_status_idx ON products (status);
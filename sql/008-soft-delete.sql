@@ -0,0 +1,12 @@
+ALTER TABLE products ADD COLUMN deleted_at TIMESTAMP NULL;
+ALTER TABLE product_variants ADD COLUMN deleted_at TIMESTAMP NULL;
+ALTER TABLE categories ADD COLUMN deleted_at TIMESTAMP NULL;
+
+CREATE INDEX IF NOT EXISTS products_deleted_at_idx ON products (deleted_at);
+CREATE INDEX IF NOT EXISTS product_variants_deleted_at_idx ON product_variants (deleted_at);
+CREATE INDEX IF NOT EXISTS categories_deleted_at_idx ON categories (deleted_at);
+
+-- Products keep pointing at a deleted category until it is purged.
+ALTER TABLE products DROP CONSTRAINT IF EXISTS products_category_id_fkey;
+ALTER TABLE products ADD CONSTRAINT products_category_id_fkey
+FOREIGN KEY (category_id) REFERENCES categories(id) ON DELETE SET NULL;
@@ -0,0 +1,22 @@
+-- Keep updated_at current for every write, including the ones made outside the application.
+CREATE OR REPLACE FUNCTION set_updated_at() RETURNS TRIGGER AS $$
+BEGIN
+    NEW.updated_at = NOW();
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER products_set_updated_at
+BEFORE UPDATE ON products
+FOR EACH ROW EXECUTE FUNCTION set_updated_at();
+
+CREATE TRIGGER product_variants_set_updated_at
+BEFORE UPDATE ON product_variants
+FOR EACH ROW EXECUTE FUNCTION set_updated_at();
+
+CREATE TRIGGER categories_set_updated_at
+BEFORE UPDATE ON categories
+FOR EACH ROW EXECUTE FUNCTION set_updated_at();
+
+CREATE INDEX IF NOT EXISTS products_updated_at_idx ON products (updated_at);
+CREATE INDEX IF NOT EXISTS product_variants_updated_at_idx ON product_variants (updated_at);
@@ -0,0 +1,5 @@
+-- Row versions used for optimistic concurrency control on writes.
+ALTER TABLE products ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE product_variants ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE categories ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE promotions ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
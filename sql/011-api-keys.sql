@@ -0,0 +1,12 @@
+-- API keys authenticating write and admin requests. Only the SHA-256 hash of a
+-- key is stored; its prefix identifies the key in listings and revocations.
+CREATE TABLE IF NOT EXISTS api_keys (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(256) NOT NULL,
+    prefix VARCHAR(16) NOT NULL UNIQUE,
+    hash CHAR(64) NOT NULL UNIQUE,
+    scopes TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    expires_at TIMESTAMP NULL,
+    revoked_at TIMESTAMP NULL
+);
@@ -0,0 +1,5 @@
+-- Roles decide what the holder of an API key may do within its scopes.
+ALTER TABLE api_keys ADD COLUMN roles TEXT NOT NULL DEFAULT '';
+
+-- Keys issued before roles existed could do everything their scopes allowed.
+UPDATE api_keys SET roles = 'admin';
@@ -0,0 +1,17 @@
+-- Responses to POST requests sent with an Idempotency-Key header, replayed when
+-- a client retries with the same key. status_code is NULL while the first
+-- request is still being processed.
+CREATE TABLE IF NOT EXISTS idempotent_requests (
+    id SERIAL PRIMARY KEY,
+    principal VARCHAR(256) NOT NULL,
+    key VARCHAR(255) NOT NULL,
+    fingerprint CHAR(64) NOT NULL,
+    status_code INTEGER NULL,
+    headers TEXT NOT NULL DEFAULT '',
+    body BYTEA NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    expires_at TIMESTAMP NOT NULL,
+    UNIQUE (principal, key)
+);
+
+CREATE INDEX IF NOT EXISTS idx_idempotent_requests_expires_at ON idempotent_requests (expires_at);